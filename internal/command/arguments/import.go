@@ -6,6 +6,16 @@
 package arguments
 
 import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+
+	"github.com/opentofu/opentofu/internal/addrs"
+	"github.com/opentofu/opentofu/internal/command/flags"
 	"github.com/opentofu/opentofu/internal/command/workdir"
 	"github.com/opentofu/opentofu/internal/tfdiags"
 )
@@ -17,11 +27,78 @@ type Import struct {
 	ResourceAddress string
 	// ResourceID is the platform provided ID of the resource to be imported.
 	ResourceID string
+	// Identity is the JSON encoding of an object that identifies the
+	// resource to import, for resource types that support identity-based
+	// import. When it is set, ResourceID is empty.
+	Identity string
+	// AdditionalTargets are the resources to import in the same operation
+	// as ResourceAddress, given as further pairs of ADDR and ID arguments.
+	AdditionalTargets []ImportTarget
+	// ImportFilePath is an optional path to a file listing more resources
+	// to import in the same operation, one address and ID pair per line.
+	ImportFilePath string
+	// InputFormat is the format of the file at ImportFilePath: either "text"
+	// for lines of address and ID pairs, or "json" for an array of objects
+	// with "address" and "id" properties.
+	InputFormat string
 	// ConfigPath is the path to the directory where the configuration containing the ResourceAddress is
 	// accessible.
 	ConfigPath string
 	// Parallelism is the limit of concurrent operation as OpenTofu walks the graph
 	Parallelism int
+	// SnapshotOutPath is an optional path to a file where a JSON description of the
+	// imported resource instance's state before and after the import is written.
+	SnapshotOutPath string
+	// Workspace, if set, overrides the currently selected workspace for the
+	// duration of this import.
+	Workspace string
+	// CreateWorkspace requests that the workspace given by Workspace be created
+	// in the backend if it does not already exist.
+	CreateWorkspace bool
+	// Bare requests that the import use a temporary configuration containing
+	// only the target resource, instead of the configuration in ConfigPath.
+	Bare bool
+	// ProviderSource is the source address of the provider to require in the
+	// temporary configuration used in Bare mode. If unset, the provider is
+	// implied by the resource type as usual.
+	ProviderSource string
+	// PlanOutPath is an optional path where, after a successful import, a
+	// plan created against the new state is saved.
+	PlanOutPath string
+	// ProviderVars are the raw -provider-var arguments, each overriding one
+	// argument of a provider configuration in the root module for the
+	// duration of the import only.
+	ProviderVars flags.RawFlags
+	// IDSensitive requests that ResourceID be treated as a secret, replacing
+	// it with a redaction marker in all logs and UI output.
+	IDSensitive bool
+	// DependsOn are the resources given with -depends-on, which are recorded
+	// as dependencies of the imported resource instance in the state.
+	DependsOn []addrs.ConfigResource
+	// MoveTo is an optional resource instance address that the imported
+	// object is moved to after a successful import, before the state is
+	// saved.
+	MoveTo string
+	// ReadOnly requests that the imported object be shown, with its
+	// sensitive attributes redacted, instead of being saved to the state.
+	// It is set by either -read-only or its alias -dry-run.
+	ReadOnly bool
+	// SchemaVersion, if not nil, overrides the schema version recorded in
+	// the state for the imported object, so that the provider upgrades it
+	// from that version during the next operation.
+	SchemaVersion *uint64
+	// AgainstPlanPath is an optional path to a saved plan that must include
+	// an import of ResourceID to the target address for the import to
+	// proceed.
+	AgainstPlanPath string
+	// RollbackOutPath is an optional path where, after a successful import,
+	// a shell script that removes the imported object from the state again
+	// is written.
+	RollbackOutPath string
+	// Capabilities requests a report of the import capabilities of each
+	// provider that the configuration requires, instead of an import. No
+	// ADDR or ID is expected in that case.
+	Capabilities bool
 
 	// ViewOptions specifies which view options to use
 	ViewOptions ViewOptions
@@ -31,15 +108,23 @@ type Import struct {
 	Vars    *Vars
 }
 
+// ImportTarget is a resource address and the ID of the object to import to
+// it, as given on the command line or in an import file.
+type ImportTarget struct {
+	Address string
+	ID      string
+}
+
 // ParseImport processes CLI arguments, returning an Import value, a closer function, and errors.
 // If errors are encountered, an Import value is still returned representing
 // the best effort interpretation of the arguments.
 func ParseImport(args []string, wd *workdir.Dir) (*Import, func(), tfdiags.Diagnostics) {
 	var diags tfdiags.Diagnostics
 	ret := &Import{
-		Vars:    &Vars{},
-		State:   &State{},
-		Backend: &Backend{},
+		Vars:         &Vars{},
+		State:        &State{},
+		Backend:      &Backend{},
+		ProviderVars: flags.NewRawFlags("-provider-var"),
 	}
 	// Get the pwd since its our default -config flag value
 	pwd := wd.NormalizePath(wd.RootModuleDir())
@@ -47,6 +132,27 @@ func ParseImport(args []string, wd *workdir.Dir) (*Import, func(), tfdiags.Diagn
 	cmdFlags := extendedFlagSet("import", nil, ret.Vars)
 	cmdFlags.IntVar(&ret.Parallelism, "parallelism", DefaultParallelism, "parallelism")
 	cmdFlags.StringVar(&ret.ConfigPath, "config", pwd, "path")
+	cmdFlags.StringVar(&ret.SnapshotOutPath, "snapshot-out", "", "path")
+	cmdFlags.StringVar(&ret.Workspace, "workspace", "", "workspace")
+	cmdFlags.BoolVar(&ret.CreateWorkspace, "create-workspace", false, "create-workspace")
+	cmdFlags.BoolVar(&ret.Bare, "bare", false, "bare")
+	cmdFlags.StringVar(&ret.ProviderSource, "provider-source", "", "source")
+	cmdFlags.StringVar(&ret.PlanOutPath, "out", "", "path")
+	cmdFlags.Var(ret.ProviderVars, "provider-var", "provider-var")
+	cmdFlags.BoolVar(&ret.IDSensitive, "id-sensitive", false, "id-sensitive")
+	cmdFlags.StringVar(&ret.Identity, "identity", "", "identity")
+	cmdFlags.StringVar(&ret.MoveTo, "move-to", "", "address")
+	cmdFlags.BoolVar(&ret.ReadOnly, "read-only", false, "read-only")
+	cmdFlags.BoolVar(&ret.ReadOnly, "dry-run", false, "dry-run")
+	cmdFlags.StringVar(&ret.AgainstPlanPath, "against-plan", "", "path")
+	cmdFlags.StringVar(&ret.RollbackOutPath, "rollback-out", "", "path")
+	cmdFlags.BoolVar(&ret.Capabilities, "capabilities", false, "capabilities")
+	cmdFlags.StringVar(&ret.ImportFilePath, "import-file", "", "path")
+	cmdFlags.StringVar(&ret.InputFormat, "input-format", "text", "format")
+	var schemaVersionRaw string
+	cmdFlags.StringVar(&schemaVersionRaw, "schema-version", "", "version")
+	var dependsOnRaw []string
+	cmdFlags.Var((*flags.FlagStringSlice)(&dependsOnRaw), "depends-on", "depends-on")
 	ret.Backend.AddIgnoreRemoteVersionFlag(cmdFlags)
 	ret.State.addFlags(cmdFlags, stateFlagAll)
 	ret.ViewOptions.AddFlags(cmdFlags, true)
@@ -65,16 +171,210 @@ func ParseImport(args []string, wd *workdir.Dir) (*Import, func(), tfdiags.Diagn
 		return ret, closer, diags
 	}
 
+	if ret.CreateWorkspace && ret.Workspace == "" {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Invalid combination of flags",
+			"The -create-workspace flag requires -workspace to name the workspace to create.",
+		))
+	}
+
+	if ret.ProviderSource != "" && !ret.Bare {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Invalid combination of flags",
+			"The -provider-source flag can be used only with -bare.",
+		))
+	}
+
+	if schemaVersionRaw != "" {
+		v, err := strconv.ParseUint(schemaVersionRaw, 10, 64)
+		if err != nil {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"Invalid -schema-version value",
+				fmt.Sprintf("The -schema-version flag requires a non-negative whole number, but got %q.", schemaVersionRaw),
+			))
+		} else {
+			ret.SchemaVersion = &v
+		}
+	}
+
+	switch {
+	case ret.InputFormat != "text" && ret.InputFormat != "json":
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Invalid -input-format value",
+			fmt.Sprintf("The -input-format flag must be either \"text\" or \"json\", but got %q.", ret.InputFormat),
+		))
+	case ret.InputFormat != "text" && ret.ImportFilePath == "":
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Invalid combination of flags",
+			"The -input-format flag can be used only with -import-file.",
+		))
+	}
+
+	if ret.ReadOnly {
+		var conflicts []string
+		for _, f := range []struct {
+			name string
+			set  bool
+		}{
+			{"-create-workspace", ret.CreateWorkspace},
+			{"-depends-on", len(dependsOnRaw) > 0},
+			{"-move-to", ret.MoveTo != ""},
+			{"-out", ret.PlanOutPath != ""},
+			{"-rollback-out", ret.RollbackOutPath != ""},
+			{"-schema-version", schemaVersionRaw != ""},
+			{"-snapshot-out", ret.SnapshotOutPath != ""},
+		} {
+			if f.set {
+				conflicts = append(conflicts, f.name)
+			}
+		}
+		if len(conflicts) > 0 {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"Invalid combination of flags",
+				fmt.Sprintf("The -read-only flag is mutually exclusive with the following flags, because the imported object is not saved to the state: %s.", strings.Join(conflicts, ", ")),
+			))
+		}
+	}
+
+	for _, raw := range dependsOnRaw {
+		traversal, syntaxDiags := hclsyntax.ParseTraversalAbs([]byte(raw), "", hcl.Pos{Line: 1, Column: 1})
+		if syntaxDiags.HasErrors() {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				fmt.Sprintf("Invalid -depends-on address %q", raw),
+				syntaxDiags[0].Detail,
+			))
+			continue
+		}
+
+		// Dependencies are recorded per resource, so any instance keys in
+		// the address are accepted but discarded.
+		addr, addrDiags := addrs.ParseAbsResourceInstance(traversal)
+		if addrDiags.HasErrors() {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				fmt.Sprintf("Invalid -depends-on address %q", raw),
+				addrDiags[0].Description().Detail,
+			))
+			continue
+		}
+
+		ret.DependsOn = append(ret.DependsOn, addr.ContainingResource().Config())
+	}
+
 	args = cmdFlags.Args()
-	if len(args) != 2 {
+	if ret.Capabilities {
+		if len(args) != 0 {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"Invalid number of arguments",
+				"The import command expects no arguments when -capabilities is set",
+			))
+		}
+		return ret, closer, diags
+	}
+	if ret.Identity != "" {
+		return ret, closer, diags.Append(ret.parseIdentityArgs(args))
+	}
+	if len(args)%2 != 0 || (len(args) == 0 && ret.ImportFilePath == "") {
 		diags = diags.Append(tfdiags.Sourceless(
 			tfdiags.Error,
 			"Invalid number of arguments",
-			"The import command expects two arguments",
+			"The import command expects two arguments, or more pairs of ADDR and ID arguments to import several resources at once",
 		))
 		return ret, closer, diags
 	}
-	ret.ResourceAddress = args[0]
-	ret.ResourceID = args[1]
+
+	if len(args) > 2 || ret.ImportFilePath != "" {
+		var conflicts []string
+		for _, f := range []struct {
+			name string
+			set  bool
+		}{
+			{"-against-plan", ret.AgainstPlanPath != ""},
+			{"-bare", ret.Bare},
+			{"-depends-on", len(dependsOnRaw) > 0},
+			{"-move-to", ret.MoveTo != ""},
+			{"-read-only", ret.ReadOnly},
+			{"-rollback-out", ret.RollbackOutPath != ""},
+			{"-schema-version", schemaVersionRaw != ""},
+			{"-snapshot-out", ret.SnapshotOutPath != ""},
+		} {
+			if f.set {
+				conflicts = append(conflicts, f.name)
+			}
+		}
+		if len(conflicts) > 0 {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"Invalid combination of flags",
+				fmt.Sprintf("Importing several resources at once is mutually exclusive with the following flags, because they apply to a single resource: %s.", strings.Join(conflicts, ", ")),
+			))
+		}
+	}
+
+	if len(args) >= 2 {
+		ret.ResourceAddress = args[0]
+		ret.ResourceID = args[1]
+	}
+	for i := 2; i < len(args); i += 2 {
+		ret.AdditionalTargets = append(ret.AdditionalTargets, ImportTarget{
+			Address: args[i],
+			ID:      args[i+1],
+		})
+	}
 	return ret, closer, diags
 }
+
+// parseIdentityArgs validates the -identity flag and the positional
+// arguments that go with it: the address alone, because the identity takes
+// the place of the ID.
+func (imp *Import) parseIdentityArgs(args []string) tfdiags.Diagnostics {
+	var diags tfdiags.Diagnostics
+	if len(args) != 1 {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Invalid number of arguments",
+			"The import command expects only the ADDR argument when -identity is set",
+		))
+		return diags
+	}
+	imp.ResourceAddress = args[0]
+
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(imp.Identity), &obj); err != nil || obj == nil {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Invalid -identity value",
+			fmt.Sprintf("The -identity flag requires a JSON object, such as '{\"name\":\"example\"}', but got %q.", imp.Identity),
+		))
+	}
+
+	var conflicts []string
+	for _, f := range []struct {
+		name string
+		set  bool
+	}{
+		{"-against-plan", imp.AgainstPlanPath != ""},
+		{"-id-sensitive", imp.IDSensitive},
+		{"-import-file", imp.ImportFilePath != ""},
+	} {
+		if f.set {
+			conflicts = append(conflicts, f.name)
+		}
+	}
+	if len(conflicts) > 0 {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Invalid combination of flags",
+			fmt.Sprintf("The -identity flag is mutually exclusive with the following flags, because they work with string IDs: %s.", strings.Join(conflicts, ", ")),
+		))
+	}
+	return diags
+}
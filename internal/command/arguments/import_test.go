@@ -12,6 +12,7 @@ import (
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/opentofu/opentofu/internal/addrs"
 	"github.com/opentofu/opentofu/internal/command/flags"
 	"github.com/opentofu/opentofu/internal/command/workdir"
 )
@@ -46,6 +47,146 @@ func TestParseImport_basicValidation(t *testing.T) {
 				imp.ConfigPath = "/path/to/config"
 			}),
 		},
+		"snapshot-out flag": {
+			args: []string{"-snapshot-out=snapshot.json", "addr", "id"},
+			want: importArgsWithDefaults(func(imp *Import) {
+				imp.ResourceAddress = "addr"
+				imp.ResourceID = "id"
+				imp.SnapshotOutPath = "snapshot.json"
+			}),
+		},
+		"workspace flags": {
+			args: []string{"-workspace=staging", "-create-workspace", "addr", "id"},
+			want: importArgsWithDefaults(func(imp *Import) {
+				imp.ResourceAddress = "addr"
+				imp.ResourceID = "id"
+				imp.Workspace = "staging"
+				imp.CreateWorkspace = true
+			}),
+		},
+		"bare flags": {
+			args: []string{"-bare", "-provider-source=example.com/acme/test", "addr", "id"},
+			want: importArgsWithDefaults(func(imp *Import) {
+				imp.ResourceAddress = "addr"
+				imp.ResourceID = "id"
+				imp.Bare = true
+				imp.ProviderSource = "example.com/acme/test"
+			}),
+		},
+		"out flag": {
+			args: []string{"-out=tfplan", "addr", "id"},
+			want: importArgsWithDefaults(func(imp *Import) {
+				imp.ResourceAddress = "addr"
+				imp.ResourceID = "id"
+				imp.PlanOutPath = "tfplan"
+			}),
+		},
+		"id-sensitive flag": {
+			args: []string{"-id-sensitive", "addr", "id"},
+			want: importArgsWithDefaults(func(imp *Import) {
+				imp.ResourceAddress = "addr"
+				imp.ResourceID = "id"
+				imp.IDSensitive = true
+			}),
+		},
+		"depends-on flags": {
+			args: []string{"-depends-on=test_instance.a", "-depends-on", "module.child[0].test_instance.b[1]", "addr", "id"},
+			want: importArgsWithDefaults(func(imp *Import) {
+				imp.ResourceAddress = "addr"
+				imp.ResourceID = "id"
+				imp.DependsOn = []addrs.ConfigResource{
+					addrs.RootModule.Resource(addrs.ManagedResourceMode, "test_instance", "a"),
+					addrs.RootModule.Child("child").Resource(addrs.ManagedResourceMode, "test_instance", "b"),
+				}
+			}),
+		},
+		"move-to flag": {
+			args: []string{"-move-to=module.child.test_instance.foo", "addr", "id"},
+			want: importArgsWithDefaults(func(imp *Import) {
+				imp.ResourceAddress = "addr"
+				imp.ResourceID = "id"
+				imp.MoveTo = "module.child.test_instance.foo"
+			}),
+		},
+		"read-only flag": {
+			args: []string{"-read-only", "addr", "id"},
+			want: importArgsWithDefaults(func(imp *Import) {
+				imp.ResourceAddress = "addr"
+				imp.ResourceID = "id"
+				imp.ReadOnly = true
+			}),
+		},
+		"dry-run flag": {
+			args: []string{"-dry-run", "addr", "id"},
+			want: importArgsWithDefaults(func(imp *Import) {
+				imp.ResourceAddress = "addr"
+				imp.ResourceID = "id"
+				imp.ReadOnly = true
+			}),
+		},
+		"against-plan flag": {
+			args: []string{"-against-plan=tfplan", "addr", "id"},
+			want: importArgsWithDefaults(func(imp *Import) {
+				imp.ResourceAddress = "addr"
+				imp.ResourceID = "id"
+				imp.AgainstPlanPath = "tfplan"
+			}),
+		},
+		"rollback-out flag": {
+			args: []string{"-rollback-out=rollback.sh", "addr", "id"},
+			want: importArgsWithDefaults(func(imp *Import) {
+				imp.ResourceAddress = "addr"
+				imp.ResourceID = "id"
+				imp.RollbackOutPath = "rollback.sh"
+			}),
+		},
+		"capabilities flag": {
+			args: []string{"-capabilities"},
+			want: importArgsWithDefaults(func(imp *Import) {
+				imp.Capabilities = true
+			}),
+		},
+		"capabilities flag with arguments": {
+			args: []string{"-capabilities", "addr", "id"},
+			want: importArgsWithDefaults(func(imp *Import) {
+				imp.Capabilities = true
+			}),
+			wantErrText: "Invalid number of arguments: The import command expects no arguments when -capabilities is set",
+		},
+		"schema-version flag": {
+			args: []string{"-schema-version=2", "addr", "id"},
+			want: importArgsWithDefaults(func(imp *Import) {
+				imp.ResourceAddress = "addr"
+				imp.ResourceID = "id"
+				v := uint64(2)
+				imp.SchemaVersion = &v
+			}),
+		},
+		"invalid schema-version": {
+			args: []string{"-schema-version=-1", "addr", "id"},
+			want: importArgsWithDefaults(func(imp *Import) {
+				imp.ResourceAddress = "addr"
+				imp.ResourceID = "id"
+			}),
+			wantErrText: `Invalid -schema-version value: The -schema-version flag requires a non-negative whole number, but got "-1".`,
+		},
+		"invalid depends-on": {
+			args: []string{"-depends-on=module.child", "addr", "id"},
+			want: importArgsWithDefaults(func(imp *Import) {
+				imp.ResourceAddress = "addr"
+				imp.ResourceID = "id"
+			}),
+			wantErrText: `Invalid -depends-on address "module.child"`,
+		},
+		"provider-var flags": {
+			args: []string{"-provider-var", "aws.region=us-west-2", "-provider-var=aws.west.profile=other", "addr", "id"},
+			want: importArgsWithDefaults(func(imp *Import) {
+				imp.ResourceAddress = "addr"
+				imp.ResourceID = "id"
+				imp.ProviderVars.Set("aws.region=us-west-2")
+				imp.ProviderVars.Set("aws.west.profile=other")
+			}),
+		},
 		"ignore-remote-version flag": {
 			args: []string{"-ignore-remote-version", "addr", "id"},
 			want: importArgsWithDefaults(func(imp *Import) {
@@ -98,6 +239,35 @@ func TestParseImport_basicValidation(t *testing.T) {
 				imp.ViewOptions.InputEnabled = false
 			}),
 		},
+		"create-workspace without workspace": {
+			args: []string{"-create-workspace", "addr", "id"},
+			want: importArgsWithDefaults(func(imp *Import) {
+				imp.ResourceAddress = "addr"
+				imp.ResourceID = "id"
+				imp.CreateWorkspace = true
+			}),
+			wantErrText: "Invalid combination of flags: The -create-workspace flag requires -workspace to name the workspace to create.",
+		},
+		"provider-source without bare": {
+			args: []string{"-provider-source=hashicorp/test", "addr", "id"},
+			want: importArgsWithDefaults(func(imp *Import) {
+				imp.ResourceAddress = "addr"
+				imp.ResourceID = "id"
+				imp.ProviderSource = "hashicorp/test"
+			}),
+			wantErrText: "Invalid combination of flags: The -provider-source flag can be used only with -bare.",
+		},
+		"read-only with flags that save the object": {
+			args: []string{"-read-only", "-out=tfplan", "-move-to=test_instance.bar", "addr", "id"},
+			want: importArgsWithDefaults(func(imp *Import) {
+				imp.ResourceAddress = "addr"
+				imp.ResourceID = "id"
+				imp.ReadOnly = true
+				imp.PlanOutPath = "tfplan"
+				imp.MoveTo = "test_instance.bar"
+			}),
+			wantErrText: "Invalid combination of flags: The -read-only flag is mutually exclusive with the following flags, because the imported object is not saved to the state: -move-to, -out.",
+		},
 		"no arguments": {
 			args:        []string{},
 			want:        importArgsWithDefaults(nil),
@@ -113,6 +283,97 @@ func TestParseImport_basicValidation(t *testing.T) {
 			want:        importArgsWithDefaults(nil),
 			wantErrText: "Invalid number of arguments: The import command expects two arguments",
 		},
+		"several resources": {
+			args: []string{"addr1", "id1", "addr2", "id2", "addr3", "id3"},
+			want: importArgsWithDefaults(func(imp *Import) {
+				imp.ResourceAddress = "addr1"
+				imp.ResourceID = "id1"
+				imp.AdditionalTargets = []ImportTarget{
+					{Address: "addr2", ID: "id2"},
+					{Address: "addr3", ID: "id3"},
+				}
+			}),
+		},
+		"import-file flag without arguments": {
+			args: []string{"-import-file=imports.txt"},
+			want: importArgsWithDefaults(func(imp *Import) {
+				imp.ImportFilePath = "imports.txt"
+			}),
+		},
+		"import-file flag with arguments": {
+			args: []string{"-import-file=imports.txt", "addr", "id"},
+			want: importArgsWithDefaults(func(imp *Import) {
+				imp.ResourceAddress = "addr"
+				imp.ResourceID = "id"
+				imp.ImportFilePath = "imports.txt"
+			}),
+		},
+		"import-file flag with json input format": {
+			args: []string{"-import-file=imports.json", "-input-format=json"},
+			want: importArgsWithDefaults(func(imp *Import) {
+				imp.ImportFilePath = "imports.json"
+				imp.InputFormat = "json"
+			}),
+		},
+		"invalid input format": {
+			args: []string{"-import-file=imports.yaml", "-input-format=yaml"},
+			want: importArgsWithDefaults(func(imp *Import) {
+				imp.ImportFilePath = "imports.yaml"
+				imp.InputFormat = "yaml"
+			}),
+			wantErrText: `Invalid -input-format value: The -input-format flag must be either "text" or "json", but got "yaml".`,
+		},
+		"input format without import-file": {
+			args: []string{"-input-format=json", "addr", "id"},
+			want: importArgsWithDefaults(func(imp *Import) {
+				imp.ResourceAddress = "addr"
+				imp.ResourceID = "id"
+				imp.InputFormat = "json"
+			}),
+			wantErrText: "Invalid combination of flags: The -input-format flag can be used only with -import-file.",
+		},
+		"identity flag": {
+			args: []string{`-identity={"name":"example"}`, "addr"},
+			want: importArgsWithDefaults(func(imp *Import) {
+				imp.ResourceAddress = "addr"
+				imp.Identity = `{"name":"example"}`
+			}),
+		},
+		"identity flag with an ID": {
+			args: []string{`-identity={"name":"example"}`, "addr", "id"},
+			want: importArgsWithDefaults(func(imp *Import) {
+				imp.Identity = `{"name":"example"}`
+			}),
+			wantErrText: "Invalid number of arguments: The import command expects only the ADDR argument when -identity is set",
+		},
+		"identity flag not an object": {
+			args: []string{`-identity=["example"]`, "addr"},
+			want: importArgsWithDefaults(func(imp *Import) {
+				imp.ResourceAddress = "addr"
+				imp.Identity = `["example"]`
+			}),
+			wantErrText: `Invalid -identity value: The -identity flag requires a JSON object`,
+		},
+		"identity flag with string ID flags": {
+			args: []string{`-identity={"name":"example"}`, "-id-sensitive", "addr"},
+			want: importArgsWithDefaults(func(imp *Import) {
+				imp.ResourceAddress = "addr"
+				imp.Identity = `{"name":"example"}`
+				imp.IDSensitive = true
+			}),
+			wantErrText: "Invalid combination of flags: The -identity flag is mutually exclusive with the following flags, because they work with string IDs: -id-sensitive.",
+		},
+		"several resources with single resource flags": {
+			args: []string{"-move-to=test_instance.bar", "-snapshot-out=snapshot.json", "addr1", "id1", "addr2", "id2"},
+			want: importArgsWithDefaults(func(imp *Import) {
+				imp.ResourceAddress = "addr1"
+				imp.ResourceID = "id1"
+				imp.AdditionalTargets = []ImportTarget{{Address: "addr2", ID: "id2"}}
+				imp.MoveTo = "test_instance.bar"
+				imp.SnapshotOutPath = "snapshot.json"
+			}),
+			wantErrText: "Invalid combination of flags: Importing several resources at once is mutually exclusive with the following flags, because they apply to a single resource: -move-to, -snapshot-out.",
+		},
 	}
 
 	cmpOpts := cmpopts.IgnoreUnexported(Vars{}, ViewOptions{})
@@ -206,7 +467,9 @@ func importArgsWithDefaults(mutate func(imp *Import)) *Import {
 			Lock:      true,
 			StatePath: "",
 		},
-		Backend: &Backend{},
+		Backend:      &Backend{},
+		ProviderVars: flags.NewRawFlags("-provider-var"),
+		InputFormat:  "text",
 	}
 	if mutate != nil {
 		mutate(ret)
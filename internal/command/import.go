@@ -6,22 +6,42 @@
 package command
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"log"
+	"os"
+	"path/filepath"
+	"slices"
 	"strings"
+	"sync"
+	"unicode"
 
 	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
 	"github.com/mitchellh/cli"
 	"github.com/opentofu/opentofu/internal/configs/configload"
 	"github.com/opentofu/opentofu/internal/tracing"
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
 
 	"github.com/opentofu/opentofu/internal/addrs"
 	"github.com/opentofu/opentofu/internal/backend"
 	"github.com/opentofu/opentofu/internal/command/arguments"
+	"github.com/opentofu/opentofu/internal/command/clistate"
+	"github.com/opentofu/opentofu/internal/command/flags"
 	"github.com/opentofu/opentofu/internal/command/views"
 	"github.com/opentofu/opentofu/internal/configs"
+	"github.com/opentofu/opentofu/internal/configs/configschema"
+	"github.com/opentofu/opentofu/internal/encryption"
+	"github.com/opentofu/opentofu/internal/lang/marks"
+	"github.com/opentofu/opentofu/internal/plans"
+	"github.com/opentofu/opentofu/internal/plans/planfile"
+	"github.com/opentofu/opentofu/internal/providers"
+	"github.com/opentofu/opentofu/internal/states"
+	"github.com/opentofu/opentofu/internal/states/statefile"
 	"github.com/opentofu/opentofu/internal/tfdiags"
 	"github.com/opentofu/opentofu/internal/tofu"
 )
@@ -70,48 +90,99 @@ func (c *ImportCommand) Run(rawArgs []string) int {
 	// continue to mutate the Meta object state for now.
 	c.Meta.input = args.ViewOptions.InputEnabled
 
-	// Parse the provided resource address.
-	traversalSrc := []byte(args.ResourceAddress)
-	traversal, travDiags := hclsyntax.ParseTraversalAbs(traversalSrc, "<import-address>", hcl.Pos{Line: 1, Column: 1})
-	diags = diags.Append(travDiags)
-	if travDiags.HasErrors() {
-		// NOTE: The call to Loader.ForceFileSource works well with the view.Diagnostics too since the view is
-		// configured in [Meta.configLoader] with a callback to get the sources when it prints the diagnostics.
-		c.configLoader().ForceFileSource("<import-address>", traversalSrc) // so we can include a source snippet
-		view.Diagnostics(diags)
-		view.InvalidAddressReference()
-		return 1
+	if args.Workspace != "" {
+		if !validWorkspaceName(args.Workspace) {
+			view.Diagnostics(diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"Invalid workspace name",
+				fmt.Sprintf("The workspace name %q is not allowed. The name must contain only URL safe characters, and no path separators.", args.Workspace),
+			)))
+			return 1
+		}
+		c.Meta.workspaceOverride = args.Workspace
+		c.Meta.createWorkspace = args.CreateWorkspace
 	}
-	addr, addrDiags := addrs.ParseAbsResourceInstance(traversal)
-	diags = diags.Append(addrDiags)
-	if addrDiags.HasErrors() {
-		// NOTE: The call to Loader.ForceFileSource works well with the view.Diagnostics too since the view is
-		// configured in [Meta.configLoader] with a callback to get the sources when it prints the diagnostics.
-		c.configLoader().ForceFileSource("<import-address>", traversalSrc) // so we can include a source snippet
-		view.Diagnostics(diags)
-		view.InvalidAddressReference()
-		return 1
+
+	if args.Capabilities {
+		return c.showImportCapabilities(ctx, args, view)
 	}
 
-	if addr.Resource.Resource.Mode != addrs.ManagedResourceMode {
-		var what string
-		switch addr.Resource.Resource.Mode {
-		case addrs.DataResourceMode:
-			what = "a data resource"
-		case addrs.EphemeralResourceMode:
-			what = "an ephemeral resource"
-		default:
-			what = "a resource type"
+	var targets []arguments.ImportTarget
+	if args.ResourceAddress != "" {
+		targets = append(targets, arguments.ImportTarget{Address: args.ResourceAddress, ID: args.ResourceID})
+	}
+	targets = append(targets, args.AdditionalTargets...)
+	if args.ImportFilePath != "" {
+		fileTargets, fileDiags := readImportFile(args.ImportFilePath, args.InputFormat)
+		diags = diags.Append(fileDiags)
+		if fileDiags.HasErrors() {
+			view.Diagnostics(diags)
+			return 1
 		}
+		targets = append(targets, fileTargets...)
+	}
+	if len(targets) == 0 {
 		diags = diags.Append(tfdiags.Sourceless(
 			tfdiags.Error,
-			"Invalid target resource address",
-			fmt.Sprintf("A managed resource address is required. Importing into %s is not allowed.", what),
+			"No resources to import",
+			fmt.Sprintf("The import file %s does not list any resources to import.", args.ImportFilePath),
 		))
 		view.Diagnostics(diags)
 		return 1
 	}
 
+	// Parse the provided resource addresses.
+	targetAddrs := make([]addrs.AbsResourceInstance, 0, len(targets))
+	for _, target := range targets {
+		addr, parseDiags, ok := c.parseImportAddress(target.Address, diags, view)
+		diags = parseDiags
+		if !ok {
+			return 1
+		}
+		for _, other := range targetAddrs {
+			if other.Equal(addr) {
+				diags = diags.Append(tfdiags.Sourceless(
+					tfdiags.Error,
+					"Duplicate import address",
+					fmt.Sprintf("%s is given more than once. Each resource instance can be imported only once.", addr),
+				))
+				view.Diagnostics(diags)
+				return 1
+			}
+		}
+		targetAddrs = append(targetAddrs, addr)
+	}
+	// Most options apply to a single resource, which the arguments parser
+	// guarantees is the only one when they are set.
+	addr := targetAddrs[0]
+
+	// The backend configuration is normally looked up in the working
+	// directory when the configuration doesn't declare one, but a -bare or
+	// single-file configuration lives in its own temporary directory and
+	// must not pick up the files alongside it.
+	var backendConfigDir string
+	if args.Bare {
+		configPath, bareDiags := writeBareImportConfig(addr, args.ProviderSource)
+		diags = diags.Append(bareDiags)
+		if bareDiags.HasErrors() {
+			view.Diagnostics(diags)
+			return 1
+		}
+		defer os.RemoveAll(configPath)
+		args.ConfigPath = configPath
+		backendConfigDir = configPath
+	} else if info, err := os.Stat(args.ConfigPath); err == nil && !info.IsDir() {
+		configPath, fileDiags := singleFileImportConfig(args.ConfigPath)
+		diags = diags.Append(fileDiags)
+		if fileDiags.HasErrors() {
+			view.Diagnostics(diags)
+			return 1
+		}
+		defer os.RemoveAll(configPath)
+		args.ConfigPath = configPath
+		backendConfigDir = configPath
+	}
+
 	if !c.configLoader().IsConfigDir(args.ConfigPath) {
 		diags = diags.Append(&hcl.Diagnostic{
 			Severity: hcl.DiagError,
@@ -142,45 +213,106 @@ func (c *ImportCommand) Run(rawArgs []string) int {
 		return 1
 	}
 
+	// The snapshot is written in plain text, so it must not be used to get
+	// around state encryption.
+	if args.SnapshotOutPath != "" && encryptionConfigured(config.Module) {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Invalid -snapshot-out option",
+			"The -snapshot-out file is not encrypted, so it cannot be written when encryption is configured.",
+		))
+		view.Diagnostics(diags)
+		return 1
+	}
+
 	// Verify that the given address points to something that exists in config.
 	// This is to reduce the risk that a typo in the resource address will
 	// import something that OpenTofu will want to immediately destroy on
 	// the next plan, and generally acts as a reassurance of user intent.
-	targetConfig := config.DescendentForInstance(addr.Module)
-	if targetConfig == nil {
-		modulePath := addr.Module.String()
-		diags = diags.Append(&hcl.Diagnostic{
-			Severity: hcl.DiagError,
-			Summary:  "Import to non-existent module",
-			Detail: fmt.Sprintf(
-				"%s is not defined in the configuration. Please add configuration for this module before importing into it.",
-				modulePath,
-			),
-		})
-		view.Diagnostics(diags)
-		return 1
-	}
-	targetMod := targetConfig.Module
-	rcs := targetMod.ManagedResources
-	var rc *configs.Resource
-	resourceRelAddr := addr.Resource.Resource
-	for _, thisRc := range rcs {
-		if resourceRelAddr.Type == thisRc.Type && resourceRelAddr.Name == thisRc.Name {
-			rc = thisRc
-			break
+	for _, addr := range targetAddrs {
+		targetConfig := config.DescendentForInstance(addr.Module)
+		if targetConfig == nil {
+			modulePath := addr.Module.String()
+			diags = diags.Append(&hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Import to non-existent module",
+				Detail: fmt.Sprintf(
+					"%s is not defined in the configuration. Please add configuration for this module before importing into it.",
+					modulePath,
+				),
+			})
+			view.Diagnostics(diags)
+			return 1
+		}
+		targetMod := targetConfig.Module
+		rcs := targetMod.ManagedResources
+		var rc *configs.Resource
+		resourceRelAddr := addr.Resource.Resource
+		for _, thisRc := range rcs {
+			if resourceRelAddr.Type == thisRc.Type && resourceRelAddr.Name == thisRc.Name {
+				rc = thisRc
+				break
+			}
+		}
+		if rc == nil {
+			modulePath := addr.Module.String()
+			if modulePath == "" {
+				modulePath = "the root module"
+			}
+
+			view.Diagnostics(diags)
+			view.MissingResourceConfiguration(addr, modulePath, resourceRelAddr.Type, resourceRelAddr.Name)
+			return 1
+		}
+
+		// The instance key must also agree with the resource's repetition
+		// meta-arguments, or the next plan would find no configuration for the
+		// imported instance and propose to destroy it.
+		if keyDiags := importInstanceKeyDiags(addr, rc); keyDiags.HasErrors() {
+			view.Diagnostics(diags.Append(keyDiags))
+			return 1
 		}
 	}
-	if rc == nil {
-		modulePath := addr.Module.String()
-		if modulePath == "" {
-			modulePath = "the root module"
+
+	// The final address for the imported object, if different, must be
+	// valid on its own terms before we contact the provider at all.
+	finalAddr := addr
+	if args.MoveTo != "" {
+		moveTo, moveDiags := parseImportMoveTo(config, addr, args.MoveTo)
+		diags = diags.Append(moveDiags)
+		if moveDiags.HasErrors() {
+			view.Diagnostics(diags)
+			return 1
 		}
+		finalAddr = moveTo
+	}
 
+	// Dependencies recorded in state must refer to resources in the
+	// configuration, or OpenTofu would ignore them on the next operation.
+	for _, dep := range args.DependsOn {
+		depConfig := config.Descendent(dep.Module)
+		if depConfig == nil || depConfig.Module.ResourceByAddr(dep.Resource) == nil {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"Invalid -depends-on address",
+				fmt.Sprintf("%s is not defined in the configuration, so it cannot be recorded as a dependency of %s.", dep, addr),
+			))
+		}
+	}
+	if diags.HasErrors() {
 		view.Diagnostics(diags)
-		view.MissingResourceConfiguration(addr, modulePath, resourceRelAddr.Type, resourceRelAddr.Name)
 		return 1
 	}
 
+	if args.AgainstPlanPath != "" {
+		planDiags := checkImportAgainstPlan(args.AgainstPlanPath, enc, finalAddr, args.ResourceID, args.IDSensitive)
+		diags = diags.Append(planDiags)
+		if planDiags.HasErrors() {
+			view.Diagnostics(diags)
+			return 1
+		}
+	}
+
 	// Check for user-supplied plugin path
 	var err error
 	if c.pluginPath, err = c.loadPluginPath(); err != nil {
@@ -194,8 +326,9 @@ func (c *ImportCommand) Run(rawArgs []string) int {
 
 	// Load the backend
 	b, backendDiags := c.Backend(ctx, &BackendOpts{
-		Config: config.Module.Backend,
-		View:   view.Backend(),
+		Config:    config.Module.Backend,
+		ConfigDir: backendConfigDir,
+		View:      view.Backend(),
 	}, enc.State())
 	diags = diags.Append(backendDiags)
 	if backendDiags.HasErrors() {
@@ -203,6 +336,14 @@ func (c *ImportCommand) Run(rawArgs []string) int {
 		return 1
 	}
 
+	if args.Workspace != "" {
+		diags = diags.Append(c.ensureWorkspace(ctx, b, args.Workspace, args.CreateWorkspace))
+		if diags.HasErrors() {
+			view.Diagnostics(diags)
+			return 1
+		}
+	}
+
 	// We require a backend.Local to build a context.
 	// This isn't necessarily a "local.Local" backend, which provides local
 	// operations, however that is the only current implementation. A
@@ -227,7 +368,8 @@ func (c *ImportCommand) Run(rawArgs []string) int {
 		view.Diagnostics(diags)
 		return 1
 	}
-	opReq.Hooks = view.Hooks()
+	progress := &importProgressHook{}
+	opReq.Hooks = append(view.Hooks(), progress)
 	{
 		// Setup required variables/call for operation (usually done in Meta.RunOperation)
 		var moreDiags, callDiags tfdiags.Diagnostics
@@ -241,6 +383,15 @@ func (c *ImportCommand) Run(rawArgs []string) int {
 	}
 	opReq.View = view.Operation()
 
+	// Make sure the state is reachable before starting the operation, so
+	// that a misconfigured backend is reported as such rather than as a
+	// failure partway through the import.
+	diags = diags.Append(c.checkBackendReachable(ctx, b, opReq.Workspace))
+	if diags.HasErrors() {
+		view.Diagnostics(diags)
+		return 1
+	}
+
 	// Check remote OpenTofu version is compatible
 	remoteVersionDiags := c.remoteVersionCheck(b, opReq.Workspace)
 	diags = diags.Append(remoteVersionDiags)
@@ -267,18 +418,54 @@ func (c *ImportCommand) Run(rawArgs []string) int {
 		}
 	}()
 
-	// Perform the import. Note that as you can see it is possible for this
-	// API to import more than one resource at once. For now, we only allow
-	// one while we stabilize this feature.
-	newState, importDiags := lr.Core.Import(ctx, lr.Config, lr.InputState, &tofu.ImportOpts{
-		Targets: []*tofu.ImportTarget{
-			{
-				CommandLineImportTarget: &tofu.CommandLineImportTarget{
-					Addr: addr,
-					ID:   args.ResourceID,
-				},
+	if !finalAddr.Equal(addr) && lr.InputState != nil && lr.InputState.ResourceInstance(finalAddr) != nil {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Invalid -move-to address",
+			fmt.Sprintf("%s is already managed by OpenTofu, so the imported object cannot be moved there. To import to that address, remove the existing object from the state first.", finalAddr),
+		))
+		view.Diagnostics(diags)
+		return 1
+	}
+
+	if !args.ProviderVars.Empty() {
+		overrideDiags := overrideProviderConfigs(ctx, lr, args.ProviderVars.AllItems())
+		diags = diags.Append(overrideDiags)
+		if overrideDiags.HasErrors() {
+			view.Diagnostics(diags)
+			return 1
+		}
+	}
+
+	// An identity can only be decoded once we know the identity schema of
+	// the resource type, but it must still be valid before we import.
+	var identity cty.Value
+	if args.Identity != "" {
+		var identityDiags tfdiags.Diagnostics
+		identity, identityDiags = importIdentity(ctx, lr, addr, args.Identity)
+		diags = diags.Append(identityDiags)
+		if identityDiags.HasErrors() {
+			view.Diagnostics(diags)
+			return 1
+		}
+	}
+
+	// Perform the import.
+	importTargets := make([]*tofu.ImportTarget, len(targets))
+	for i, target := range targets {
+		importTargets[i] = &tofu.ImportTarget{
+			CommandLineImportTarget: &tofu.CommandLineImportTarget{
+				Addr:        targetAddrs[i],
+				ID:          target.ID,
+				IDSensitive: args.IDSensitive,
 			},
-		},
+		}
+	}
+	if args.Identity != "" {
+		importTargets[0].Identity = identity
+	}
+	newState, importDiags := lr.Core.Import(ctx, lr.Config, lr.InputState, &tofu.ImportOpts{
+		Targets: importTargets,
 
 		// The LocalRun idea is designed around our primary operations, so
 		// the input variables end up represented as plan options even though
@@ -287,10 +474,41 @@ func (c *ImportCommand) Run(rawArgs []string) int {
 	})
 	diags = diags.Append(importDiags)
 	if diags.HasErrors() {
+		// The state is saved only if every import succeeds, but when importing
+		// several resources it helps to know which of them could be imported.
+		if imported := progress.Imported(); len(targets) > 1 && len(imported) > 0 {
+			names := make([]string, len(imported))
+			for i, addr := range imported {
+				names[i] = "\n  - " + addr.String()
+			}
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Warning,
+				"Some resources were imported successfully",
+				fmt.Sprintf("The following resources were imported successfully, but no changes were saved to the state because others failed:%s\n\nCorrect the errors and run the import again.", strings.Join(names, "")),
+			))
+		}
 		view.Diagnostics(diags)
 		return 1
 	}
 
+	if args.ReadOnly {
+		return c.showReadOnlyImport(ctx, lr, newState, addr, args.IDSensitive, view)
+	}
+
+	if len(args.DependsOn) > 0 {
+		setImportedDependencies(newState, addr, args.DependsOn)
+	}
+	if args.SchemaVersion != nil {
+		diags = diags.Append(setImportedSchemaVersion(newState, addr, *args.SchemaVersion))
+		if diags.HasErrors() {
+			view.Diagnostics(diags)
+			return 1
+		}
+	}
+	if !finalAddr.Equal(addr) {
+		newState.MoveAbsResourceInstance(addr, finalAddr)
+	}
+
 	// Get schemas, if possible, before writing state
 	var schemas *tofu.Schemas
 	if isCloudMode(b) {
@@ -318,66 +536,1217 @@ func (c *ImportCommand) Run(rawArgs []string) int {
 		return 1
 	}
 
-	view.Success()
+	if args.SnapshotOutPath != "" {
+		obj, _, _, objDiags := redactedImportedObject(ctx, lr, newState, finalAddr, args.IDSensitive)
+		diags = diags.Append(objDiags)
+		if !objDiags.HasErrors() {
+			err := writeImportSnapshot(args.SnapshotOutPath, finalAddr, obj)
+			if err != nil {
+				diags = diags.Append(tfdiags.Sourceless(
+					tfdiags.Error,
+					"Error writing import snapshot",
+					fmt.Sprintf("The import was successful and the state has been saved, but OpenTofu could not write the snapshot file %s: %s.", args.SnapshotOutPath, err),
+				))
+			}
+		}
+	}
+	if args.RollbackOutPath != "" {
+		statePath := args.State.StateOutPath
+		if statePath == "" {
+			statePath = args.State.StatePath
+		}
+		err := writeImportRollback(args.RollbackOutPath, finalAddr, args.Workspace, statePath)
+		if err != nil {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"Error writing rollback script",
+				fmt.Sprintf("The import was successful and the state has been saved, but OpenTofu could not write the rollback script %s: %s. To undo the import, run \"tofu state rm\" for %s.", args.RollbackOutPath, err, finalAddr),
+			))
+		}
+	}
+
+	view.Success(len(targets))
 	view.Diagnostics(diags)
 	if diags.HasErrors() {
 		return 1
 	}
 
+	if args.PlanOutPath != "" {
+		return c.planAfterImport(ctx, b, opReq, view, enc, args)
+	}
+
 	return 0
 }
 
-func (c *ImportCommand) Help() string {
-	helpText := `
-Usage: tofu [global options] import [options] ADDR ID
+// showImportCapabilities reports the import capabilities of each provider
+// that the configuration requires, for the -capabilities option. Nothing is
+// imported.
+func (c *ImportCommand) showImportCapabilities(ctx context.Context, args *arguments.Import, view views.Import) int {
+	var diags tfdiags.Diagnostics
 
-  Import existing infrastructure into your OpenTofu state.
+	config, configDiags := c.loadConfig(ctx, args.ConfigPath)
+	diags = diags.Append(configDiags)
+	if configDiags.HasErrors() {
+		view.Diagnostics(diags)
+		return 1
+	}
 
-  This will find and import the specified resource into your OpenTofu
-  state, allowing existing infrastructure to come under OpenTofu
-  management without having to be initially created by OpenTofu.
+	enc, encDiags := c.EncryptionFromPath(ctx, args.ConfigPath)
+	diags = diags.Append(encDiags)
+	if encDiags.HasErrors() {
+		view.Diagnostics(diags)
+		return 1
+	}
 
-  The ADDR specified is the address to import the resource to. Please
-  see the documentation online for resource addresses. The ID is a
-  resource-specific ID to identify that resource being imported. Please
-  reference the documentation for the resource type you're importing to
-  determine the ID syntax to use. It typically matches directly to the ID
-  that the provider uses.
+	var err error
+	if c.pluginPath, err = c.loadPluginPath(); err != nil {
+		view.Diagnostics(diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Error loading plugin path",
+			err.Error(),
+		)))
+		return 1
+	}
 
-  This command will not modify your infrastructure, but it will make
-  network requests to inspect parts of your infrastructure relevant to
-  the resource being imported.
+	b, backendDiags := c.Backend(ctx, &BackendOpts{
+		Config: config.Module.Backend,
+		View:   view.Backend(),
+	}, enc.State())
+	diags = diags.Append(backendDiags)
+	if backendDiags.HasErrors() {
+		view.Diagnostics(diags)
+		return 1
+	}
+	local, ok := b.(backend.Local)
+	if !ok {
+		view.Diagnostics(diags)
+		view.UnsupportedLocalOp()
+		return 1
+	}
 
-Options:
+	// This only reads provider schemas, so the remote version doesn't matter.
+	c.ignoreRemoteVersionConflict(b)
 
-  -compact-warnings       If OpenTofu produces any warnings that are not
-                          accompanied by errors, show them in a more compact
-                          form that includes only the summary messages.
+	opReq := c.Operation(ctx, b, view.Backend(), enc)
+	opReq.ConfigDir = args.ConfigPath
+	opReq.ConfigLoader, err = configload.Initialise(c.configLoader())
+	if err != nil {
+		view.Diagnostics(diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Error loading the configuration",
+			err.Error(),
+		)))
+		return 1
+	}
+	var callDiags tfdiags.Diagnostics
+	opReq.RootCall, callDiags = c.rootModuleCall(ctx, opReq.ConfigDir)
+	diags = diags.Append(callDiags)
+	if callDiags.HasErrors() {
+		view.Diagnostics(diags)
+		return 1
+	}
+	opReq.AllowUnsetVariables = true
+	opReq.View = view.Operation()
 
-  -consolidate-warnings   If OpenTofu produces any warnings, no consolidation
-                          will be performed. All locations, for all warnings
-                          will be listed. Enabled by default.
+	stopCtx, cancel := c.InterruptibleContext(ctx)
+	defer cancel()
+	lr, _, ctxDiags := local.LocalRun(ctx, stopCtx, opReq)
+	diags = diags.Append(ctxDiags)
+	if ctxDiags.HasErrors() {
+		view.Diagnostics(diags)
+		return 1
+	}
+	defer func() {
+		diags := opReq.StateLocker.Unlock()
+		if diags.HasErrors() {
+			view.Diagnostics(diags)
+		}
+	}()
 
-  -consolidate-errors     If OpenTofu produces any errors, no consolidation
-                          will be performed. All locations, for all errors
-                          will be listed. Disabled by default
+	schemas, schemaDiags := lr.Core.Schemas(ctx, lr.Config, lr.InputState)
+	diags = diags.Append(schemaDiags)
+	if schemaDiags.HasErrors() {
+		view.Diagnostics(diags)
+		return 1
+	}
 
-  -config=path            Path to a directory of OpenTofu configuration files
-                          to use to configure the provider. Defaults to pwd.
-                          If no config files are present, they must be provided
-                          via the input prompts or env vars.
+	report, err := json.Marshal(importCapabilities(schemas))
+	if err != nil {
+		view.Diagnostics(diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Failed to marshal import capabilities to json",
+			err.Error(),
+		)))
+		return 1
+	}
+	view.Diagnostics(diags)
+	view.Capabilities(report)
+	return 0
+}
 
-  -input=false            Disable interactive input prompts.
+// importCapabilityReport is the JSON representation of the import
+// capabilities of the providers that a configuration requires.
+type importCapabilityReport struct {
+	FormatVersion string                                `json:"format_version"`
+	Providers     map[string]providerImportCapabilities `json:"providers"`
+}
 
-  -lock=false             Don't hold a state lock during the operation. This is
-                          dangerous if others might concurrently run commands
-                          against the same workspace.
+type providerImportCapabilities struct {
+	// ResourceTypes has an entry for each managed resource type of the
+	// provider. Providers do not declare whether they support importing
+	// each type by ID, so that can only be found by trying.
+	ResourceTypes map[string]resourceTypeImportCapabilities `json:"resource_types"`
+}
 
-  -lock-timeout=0s        Duration to retry a state lock.
+type resourceTypeImportCapabilities struct {
+	// IdentityImport is set if the provider declares a resource identity
+	// schema for the type, so that it can be imported by identity in an
+	// "import" block as well as by ID.
+	IdentityImport        bool   `json:"identity_import"`
+	IdentitySchemaVersion *int64 `json:"identity_schema_version,omitempty"`
+}
 
-  -no-color               If specified, output won't contain any color.
+// importCapabilities returns the import capability report for the given
+// provider schemas.
+func importCapabilities(schemas *tofu.Schemas) importCapabilityReport {
+	ret := importCapabilityReport{
+		FormatVersion: "1.0",
+		Providers:     make(map[string]providerImportCapabilities, len(schemas.Providers)),
+	}
+	for addr, schema := range schemas.Providers {
+		types := make(map[string]resourceTypeImportCapabilities, len(schema.ResourceTypes))
+		for name, rs := range schema.ResourceTypes {
+			var caps resourceTypeImportCapabilities
+			if rs.IdentitySchema != nil {
+				version := rs.IdentitySchemaVersion
+				caps.IdentityImport = true
+				caps.IdentitySchemaVersion = &version
+			}
+			types[name] = caps
+		}
+		ret.Providers[addr.String()] = providerImportCapabilities{ResourceTypes: types}
+	}
+	return ret
+}
 
-  -var 'foo=bar'          Set a variable in the OpenTofu configuration. This
+// showReadOnlyImport shows the object imported to addr in newState, with its
+// sensitive attributes redacted, for the -read-only option. The state that
+// the import was performed against is left unchanged.
+func (c *ImportCommand) showReadOnlyImport(ctx context.Context, lr *backend.LocalRun, newState *states.State, addr addrs.AbsResourceInstance, idSensitive bool, view views.Import) int {
+	obj, providerAddr, schemas, diags := redactedImportedObject(ctx, lr, newState, addr, idSensitive)
+	if diags.HasErrors() {
+		view.Diagnostics(diags)
+		return 1
+	}
+
+	single := states.NewState()
+	single.EnsureModule(addr.Module).SetResourceInstanceCurrent(addr.Resource, obj, providerAddr, addrs.NoKey)
+	view.Diagnostics(diags)
+	return view.ReadOnlyResult(ctx, statefile.New(single, "", 0), schemas)
+}
+
+// redactedImportedObject returns the current object imported to addr in
+// newState, with its sensitive attributes redacted by
+// [redactImportedObject], along with the address of its provider
+// configuration and the schemas used to redact it.
+func redactedImportedObject(ctx context.Context, lr *backend.LocalRun, newState *states.State, addr addrs.AbsResourceInstance, idSensitive bool) (*states.ResourceInstanceObjectSrc, addrs.AbsProviderConfig, *tofu.Schemas, tfdiags.Diagnostics) {
+	var diags tfdiags.Diagnostics
+
+	rs := newState.Resource(addr.ContainingResource())
+	is := newState.ResourceInstance(addr)
+	if rs == nil || is == nil || is.Current == nil {
+		// Should not get here, because the import itself would have
+		// failed if the provider returned no object.
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"No object imported",
+			fmt.Sprintf("The provider did not return an object for %s.", addr),
+		))
+		return nil, addrs.AbsProviderConfig{}, nil, diags
+	}
+
+	schemas, schemaDiags := lr.Core.Schemas(ctx, lr.Config, newState)
+	diags = diags.Append(schemaDiags)
+	if schemaDiags.HasErrors() {
+		return nil, addrs.AbsProviderConfig{}, nil, diags
+	}
+	schema, _ := schemas.ResourceTypeConfig(rs.ProviderConfig.Provider, addr.Resource.Resource.Mode, addr.Resource.Resource.Type)
+	if schema == nil || schema.Block == nil {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Missing resource type schema",
+			fmt.Sprintf("The provider %s did not return a schema for %s, so the sensitive attributes of the imported object cannot be redacted.", rs.ProviderConfig.Provider, addr.Resource.Resource.Type),
+		))
+		return nil, addrs.AbsProviderConfig{}, nil, diags
+	}
+
+	obj, err := redactImportedObject(is.Current, schema.Block, idSensitive)
+	if err != nil {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Failed to decode the imported object",
+			fmt.Sprintf("The object imported to %s does not conform to its schema: %s.", addr, err),
+		))
+		return nil, addrs.AbsProviderConfig{}, nil, diags
+	}
+	return obj, rs.ProviderConfig, schemas, diags
+}
+
+// redactImportedObject returns a copy of the given object in which the
+// values of all of the attributes that are sensitive, either as recorded in
+// the object or as declared in the given resource type schema, are replaced
+// with null. If idSensitive is set, the top-level "id" attribute, which
+// conventionally holds the import ID, is treated as sensitive too.
+func redactImportedObject(src *states.ResourceInstanceObjectSrc, schema *configschema.Block, idSensitive bool) (*states.ResourceInstanceObjectSrc, error) {
+	ty := schema.ImpliedType()
+	obj, err := src.Decode(ty)
+	if err != nil {
+		return nil, err
+	}
+
+	val, pvms := obj.Value.UnmarkDeepWithPaths()
+	pvms = append(pvms, schema.ValueMarks(val, nil, nil)...)
+	if _, ok := schema.Attributes["id"]; ok && idSensitive {
+		pvms = append(pvms, cty.PathValueMarks{
+			Path:  cty.GetAttrPath("id"),
+			Marks: cty.NewValueMarks(marks.Sensitive),
+		})
+	}
+	var sensitive []cty.PathValueMarks
+	for _, pvm := range pvms {
+		if _, ok := pvm.Marks[marks.Sensitive]; ok {
+			sensitive = append(sensitive, cty.PathValueMarks{
+				Path:  pvm.Path,
+				Marks: cty.NewValueMarks(marks.Sensitive),
+			})
+		}
+	}
+
+	redacted, err := cty.Transform(val, func(path cty.Path, v cty.Value) (cty.Value, error) {
+		for _, pvm := range sensitive {
+			if path.Equals(pvm.Path) {
+				return cty.NullVal(v.Type()), nil
+			}
+		}
+		return v, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	attrs, err := ctyjson.Marshal(redacted, ty)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := src.DeepCopy()
+	ret.AttrsJSON = attrs
+	ret.AttrsFlat = nil
+	ret.AttrSensitivePaths = sensitive
+	ret.Private = nil
+	return ret, nil
+}
+
+// parseImportAddress parses the given resource instance address to import
+// to, appending any problems to the given diagnostics. If the address is
+// invalid then it renders the diagnostics itself and returns false.
+func (c *ImportCommand) parseImportAddress(raw string, diags tfdiags.Diagnostics, view views.Import) (addrs.AbsResourceInstance, tfdiags.Diagnostics, bool) {
+	traversalSrc := []byte(raw)
+	traversal, travDiags := hclsyntax.ParseTraversalAbs(traversalSrc, "<import-address>", hcl.Pos{Line: 1, Column: 1})
+	if travDiags.HasErrors() && hasUnquotedInstanceKey(raw) {
+		// The generic error about index values doesn't explain that the
+		// shell most likely removed the quotes, so report that instead.
+		travDiags = hcl.Diagnostics{{
+			Severity: hcl.DiagError,
+			Summary:  "String instance keys must be quoted",
+			Detail:   `An instance key that is a string, such as a for_each key, must be written in double quotes, like test_instance.example["a/b"]. Most shells remove double quotes from arguments, so enclose the whole address in single quotes, like 'test_instance.example["a/b"]', or escape each double quote with a backslash.`,
+			Subject:  travDiags[0].Subject,
+		}}
+	}
+	diags = diags.Append(travDiags)
+	if travDiags.HasErrors() {
+		// NOTE: The call to Loader.ForceFileSource works well with the view.Diagnostics too since the view is
+		// configured in [Meta.configLoader] with a callback to get the sources when it prints the diagnostics.
+		c.configLoader().ForceFileSource("<import-address>", traversalSrc) // so we can include a source snippet
+		view.Diagnostics(diags)
+		view.InvalidAddressReference()
+		return addrs.AbsResourceInstance{}, diags, false
+	}
+	addr, addrDiags := addrs.ParseAbsResourceInstance(traversal)
+	diags = diags.Append(addrDiags)
+	if addrDiags.HasErrors() {
+		// NOTE: The call to Loader.ForceFileSource works well with the view.Diagnostics too since the view is
+		// configured in [Meta.configLoader] with a callback to get the sources when it prints the diagnostics.
+		c.configLoader().ForceFileSource("<import-address>", traversalSrc) // so we can include a source snippet
+		view.Diagnostics(diags)
+		view.InvalidAddressReference()
+		return addrs.AbsResourceInstance{}, diags, false
+	}
+
+	if addr.Resource.Resource.Mode != addrs.ManagedResourceMode {
+		var what string
+		switch addr.Resource.Resource.Mode {
+		case addrs.DataResourceMode:
+			what = "a data resource"
+		case addrs.EphemeralResourceMode:
+			what = "an ephemeral resource"
+		default:
+			what = "a resource type"
+		}
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Invalid target resource address",
+			fmt.Sprintf("A managed resource address is required. Importing into %s is not allowed.", what),
+		))
+		view.Diagnostics(diags)
+		return addrs.AbsResourceInstance{}, diags, false
+	}
+
+	// String instance keys often contain characters that are special to the
+	// shell, so we echo back what we parsed to let the user confirm it.
+	if key, ok := addr.Resource.Key.(addrs.StringKey); ok {
+		view.InstanceKey(addr, string(key))
+	}
+	return addr, diags, true
+}
+
+// importIdentity decodes the given -identity JSON object as the identity of
+// the resource instance at addr, according to the identity schema of its
+// resource type, which must support identity-based import.
+func importIdentity(ctx context.Context, lr *backend.LocalRun, addr addrs.AbsResourceInstance, raw string) (cty.Value, tfdiags.Diagnostics) {
+	var diags tfdiags.Diagnostics
+	schemas, schemaDiags := lr.Core.Schemas(ctx, lr.Config, lr.InputState)
+	diags = diags.Append(schemaDiags)
+	if schemaDiags.HasErrors() {
+		return cty.NilVal, diags
+	}
+
+	resourceType := addr.Resource.Resource.Type
+	rc := lr.Config.DescendentForInstance(addr.Module).Module.ResourceByAddr(addr.Resource.Resource)
+	schema, _ := schemas.ResourceTypeConfig(rc.Provider, addrs.ManagedResourceMode, resourceType)
+	if schema == nil || schema.IdentitySchema == nil {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Resource type does not support import by identity",
+			fmt.Sprintf("The provider %s does not support importing %s resources by identity, so %s must be imported with a string ID instead of -identity.", rc.Provider.ForDisplay(), resourceType, addr),
+		))
+		return cty.NilVal, diags
+	}
+
+	identity, err := ctyjson.Unmarshal([]byte(raw), schema.IdentitySchema.ImpliedType())
+	if err != nil {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Invalid -identity value",
+			fmt.Sprintf("The -identity value does not conform to the identity schema of %s resources: %s.", resourceType, tfdiags.FormatError(err)),
+		))
+		return cty.NilVal, diags
+	}
+	return identity, diags
+}
+
+// readImportFile reads the resources to import from the file given in the
+// -import-file option, in the format given in the -input-format option.
+//
+// In the "text" format each line contains a resource instance address and
+// the ID to import to it, separated by whitespace. Blank lines and lines
+// starting with "#" are ignored. The "json" format is an array of objects
+// with "address" and "id" properties.
+func readImportFile(path, format string) ([]arguments.ImportTarget, tfdiags.Diagnostics) {
+	var diags tfdiags.Diagnostics
+	src, err := os.ReadFile(path)
+	if err != nil {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Failed to read import file",
+			fmt.Sprintf("Could not read the import file %s: %s.", path, err),
+		))
+		return nil, diags
+	}
+
+	if format == "json" {
+		return parseImportFileJSON(path, src)
+	}
+
+	var targets []arguments.ImportTarget
+	for i, line := range strings.Split(string(src), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		sep := strings.IndexFunc(line, unicode.IsSpace)
+		if sep < 0 {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"Invalid import file",
+				fmt.Sprintf("Line %d of %s must contain a resource address and an ID, separated by whitespace.", i+1, path),
+			))
+			continue
+		}
+		targets = append(targets, arguments.ImportTarget{
+			Address: line[:sep],
+			ID:      strings.TrimSpace(line[sep:]),
+		})
+	}
+	return targets, diags
+}
+
+func parseImportFileJSON(path string, src []byte) ([]arguments.ImportTarget, tfdiags.Diagnostics) {
+	var diags tfdiags.Diagnostics
+	var raw []struct {
+		Address string `json:"address"`
+		ID      string `json:"id"`
+	}
+	dec := json.NewDecoder(bytes.NewReader(src))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&raw); err != nil {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Invalid import file",
+			fmt.Sprintf("The import file %s must contain a JSON array of objects with \"address\" and \"id\" properties: %s.", path, err),
+		))
+		return nil, diags
+	}
+
+	targets := make([]arguments.ImportTarget, 0, len(raw))
+	for i, r := range raw {
+		if r.Address == "" || r.ID == "" {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"Invalid import file",
+				fmt.Sprintf("Element %d of the array in %s must have non-empty \"address\" and \"id\" properties.", i, path),
+			))
+			continue
+		}
+		targets = append(targets, arguments.ImportTarget{Address: r.Address, ID: r.ID})
+	}
+	return targets, diags
+}
+
+// importProgressHook records the addresses of the resource instances that
+// have been imported so far, so that a partially-failed import of several
+// resources can report which of them succeeded.
+type importProgressHook struct {
+	tofu.NilHook
+
+	mu       sync.Mutex
+	imported []addrs.AbsResourceInstance
+}
+
+var _ tofu.Hook = (*importProgressHook)(nil)
+
+func (h *importProgressHook) PostImportState(addr addrs.AbsResourceInstance, imported []providers.ImportedResource) (tofu.HookAction, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.imported = append(h.imported, addr)
+	return tofu.HookActionContinue, nil
+}
+
+// Imported returns the addresses recorded so far, sorted.
+func (h *importProgressHook) Imported() []addrs.AbsResourceInstance {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	ret := slices.Clone(h.imported)
+	slices.SortFunc(ret, func(a, b addrs.AbsResourceInstance) int {
+		return strings.Compare(a.String(), b.String())
+	})
+	return ret
+}
+
+// parseImportMoveTo parses the given -move-to address and checks that it is
+// a suitable final address for an object imported to addr: a different
+// instance of a managed resource of the same type that is declared in the
+// configuration.
+func parseImportMoveTo(config *configs.Config, addr addrs.AbsResourceInstance, raw string) (addrs.AbsResourceInstance, tfdiags.Diagnostics) {
+	var diags tfdiags.Diagnostics
+
+	moveTo, moreDiags := addrs.ParseAbsResourceInstanceStr(raw)
+	diags = diags.Append(moreDiags)
+	if moreDiags.HasErrors() {
+		return moveTo, diags
+	}
+
+	switch {
+	case moveTo.Resource.Resource.Mode != addrs.ManagedResourceMode:
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Invalid -move-to address",
+			"A managed resource address is required.",
+		))
+	case moveTo.Resource.Resource.Type != addr.Resource.Resource.Type:
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Invalid -move-to address",
+			fmt.Sprintf("The imported object is of type %q, so it can be moved only to another resource of that type, not to %s.", addr.Resource.Resource.Type, moveTo),
+		))
+	case moveTo.Equal(addr):
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Invalid -move-to address",
+			"The -move-to address is the same as the import address. Omit -move-to to import directly to that address.",
+		))
+	default:
+		moveConfig := config.DescendentForInstance(moveTo.Module)
+		if moveConfig == nil || moveConfig.Module.ResourceByAddr(moveTo.Resource.Resource) == nil {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"Invalid -move-to address",
+				fmt.Sprintf("%s is not defined in the configuration. Please add configuration for this resource before moving an object to it.", moveTo.ContainingResource()),
+			))
+		}
+	}
+	return moveTo, diags
+}
+
+// importInstanceKeyDiags checks that the instance key of addr is of the kind
+// expected for the resource configuration rc: no key for a resource without
+// "count" or "for_each", an integer index for one with "count", and a string
+// key for one with "for_each".
+func importInstanceKeyDiags(addr addrs.AbsResourceInstance, rc *configs.Resource) tfdiags.Diagnostics {
+	var diags tfdiags.Diagnostics
+
+	key := addr.Resource.Key
+	_, isIntKey := key.(addrs.IntKey)
+	_, isStringKey := key.(addrs.StringKey)
+	resourceAddr := addr.ContainingResource()
+	switch {
+	case rc.Count == nil && rc.ForEach == nil && key != addrs.NoKey:
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Unexpected resource instance key",
+			fmt.Sprintf("Because %s does not have \"count\" or \"for_each\" set, it has only a single instance. Remove the instance key to import to %s.", resourceAddr, resourceAddr),
+		))
+	case rc.Count != nil && key == addrs.NoKey:
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Missing resource instance key",
+			fmt.Sprintf("Because %s has \"count\" set, an instance index is required to import to one of its instances, such as %s.", resourceAddr, resourceAddr.Instance(addrs.IntKey(0))),
+		))
+	case rc.ForEach != nil && key == addrs.NoKey:
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Missing resource instance key",
+			fmt.Sprintf("Because %s has \"for_each\" set, an instance key is required to import to one of its instances, such as %s.", resourceAddr, resourceAddr.Instance(addrs.StringKey("example"))),
+		))
+	case rc.Count != nil && !isIntKey:
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Invalid resource instance key",
+			fmt.Sprintf("Because %s has \"count\" set, its instances are identified by integer indices, such as %s.", resourceAddr, resourceAddr.Instance(addrs.IntKey(0))),
+		))
+	case rc.ForEach != nil && !isStringKey:
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Invalid resource instance key",
+			fmt.Sprintf("Because %s has \"for_each\" set, its instances are identified by string keys, such as %s.", resourceAddr, resourceAddr.Instance(addrs.StringKey("example"))),
+		))
+	}
+	return diags
+}
+
+// setImportedDependencies records the given resources as dependencies of the
+// current object of the resource instance at addr in state, in addition to
+// any the object already has, so that the next apply orders operations
+// correctly without first waiting for a refresh to reconcile them.
+func setImportedDependencies(state *states.State, addr addrs.AbsResourceInstance, deps []addrs.ConfigResource) {
+	is := state.ResourceInstance(addr)
+	if is == nil || is.Current == nil {
+		return
+	}
+	for _, dep := range deps {
+		if !slices.ContainsFunc(is.Current.Dependencies, dep.Equal) {
+			is.Current.Dependencies = append(is.Current.Dependencies, dep)
+		}
+	}
+}
+
+// checkImportAgainstPlan returns an error unless the saved plan at path
+// includes an import of the given ID to addr, for the -against-plan option.
+func checkImportAgainstPlan(path string, enc encryption.Encryption, addr addrs.AbsResourceInstance, id string, idSensitive bool) tfdiags.Diagnostics {
+	var diags tfdiags.Diagnostics
+
+	pf, err := planfile.OpenWrapped(path, enc.Plan())
+	if err != nil {
+		return diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Failed to read the saved plan",
+			fmt.Sprintf("Could not read the plan file %s given in -against-plan: %s.", path, err),
+		))
+	}
+	lp, ok := pf.Local()
+	if !ok {
+		return diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Unsupported saved plan",
+			fmt.Sprintf("The plan file %s given in -against-plan is a cloud plan, which does not record the planned imports locally.", path),
+		))
+	}
+	plan, err := lp.ReadPlan()
+	if err != nil {
+		return diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Failed to read the saved plan",
+			fmt.Sprintf("Could not read the plan file %s given in -against-plan: %s.", path, err),
+		))
+	}
+
+	displayID := func(id string) string {
+		if idSensitive {
+			return "(sensitive value)"
+		}
+		return fmt.Sprintf("%q", id)
+	}
+	rc := plan.Changes.ResourceInstance(addr)
+	switch {
+	case rc == nil || rc.Importing == nil:
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Import not in the saved plan",
+			fmt.Sprintf("The plan %s does not import an object to %s, so this import was not reviewed as part of it. Nothing has been imported.", path, addr),
+		))
+	case rc.Importing.ID != id:
+		detail := fmt.Sprintf("The plan %s imports an object to %s with ID %s, not %s. Nothing has been imported.", path, addr, displayID(rc.Importing.ID), displayID(id))
+		if rc.Importing.ID == "" {
+			detail = fmt.Sprintf("The plan %s imports an object to %s by its identity rather than by ID, so it cannot be compared with ID %s. Nothing has been imported.", path, addr, displayID(id))
+		}
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Import does not match the saved plan",
+			detail,
+		))
+	}
+	return diags
+}
+
+// setImportedSchemaVersion replaces the schema version recorded for the
+// object imported to addr, for the -schema-version option.
+//
+// The provider can only upgrade objects from earlier schema versions, so
+// a version newer than the one the provider reported is an error. Any
+// other override is reported with a warning, because it bypasses the
+// version that OpenTofu would normally record.
+func setImportedSchemaVersion(state *states.State, addr addrs.AbsResourceInstance, version uint64) tfdiags.Diagnostics {
+	var diags tfdiags.Diagnostics
+	is := state.ResourceInstance(addr)
+	if is == nil || is.Current == nil {
+		return diags
+	}
+	current := is.Current.SchemaVersion
+	if version > current {
+		return diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Invalid -schema-version value",
+			fmt.Sprintf("The provider reported schema version %d for %s, so it cannot upgrade an object from the newer version %d. The state has not been changed.", current, addr, version),
+		))
+	}
+	is.Current.SchemaVersion = version
+	return diags.Append(tfdiags.Sourceless(
+		tfdiags.Warning,
+		"Schema version overridden",
+		fmt.Sprintf("The object imported to %s has been recorded with schema version %d instead of version %d, as reported by the provider. The provider will upgrade it from version %d during the next operation, which can fail or change the object unexpectedly if the object does not actually conform to that version.", addr, version, current, version),
+	))
+}
+
+// overrideProviderConfigs applies the given -provider-var arguments to the
+// provider configurations in the root module of the configuration in lr.
+//
+// Each argument has the form NAME[.ALIAS].ARGUMENT=VALUE, where VALUE is
+// interpreted in the same way as for -backend-config. The overrides take
+// precedence over the corresponding arguments in the configuration, but
+// only the in-memory configuration is changed, so they apply to this
+// operation alone.
+func overrideProviderConfigs(ctx context.Context, lr *backend.LocalRun, items []flags.RawFlag) tfdiags.Diagnostics {
+	var diags tfdiags.Diagnostics
+
+	schemas, moreDiags := lr.Core.Schemas(ctx, lr.Config, lr.InputState)
+	diags = diags.Append(moreDiags)
+	if moreDiags.HasErrors() {
+		return diags
+	}
+
+	synthVals := make(map[string]map[string]cty.Value)
+	for _, item := range items {
+		eq := strings.Index(item.Value, "=")
+		dot := -1
+		if eq != -1 {
+			dot = strings.LastIndex(item.Value[:eq], ".")
+		}
+		if dot == -1 {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"Invalid provider configuration override",
+				fmt.Sprintf("The -provider-var option requires an argument of the form PROVIDER.ARGUMENT=VALUE, such as aws.region=us-west-2, but got %q.", item.Value),
+			))
+			continue
+		}
+		key, name, rawValue := item.Value[:dot], item.Value[dot+1:eq], item.Value[eq+1:]
+
+		pc := lr.Config.Module.ProviderConfigs[key]
+		if pc == nil {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"Invalid provider configuration override",
+				fmt.Sprintf("The root module has no provider configuration %q to override with %s.", key, item.String()),
+			))
+			continue
+		}
+		schema := schemas.ProviderConfig(lr.Config.ProviderForConfigAddr(pc.Addr()))
+		var attrS *configschema.Attribute
+		if schema != nil {
+			attrS = schema.Attributes[name]
+		}
+		if attrS == nil {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"Invalid provider configuration override",
+				fmt.Sprintf("The provider configuration %q has no argument named %q.", key, name),
+			))
+			continue
+		}
+		value, valueDiags := configValueFromCLI(item.String(), rawValue, attrS.Type)
+		diags = diags.Append(valueDiags)
+		if valueDiags.HasErrors() {
+			continue
+		}
+		if synthVals[key] == nil {
+			synthVals[key] = make(map[string]cty.Value)
+		}
+		synthVals[key][name] = value
+	}
+	if diags.HasErrors() {
+		return diags
+	}
+
+	for key, vals := range synthVals {
+		pc := lr.Config.Module.ProviderConfigs[key]
+		log.Printf("[INFO] import: overriding %d argument(s) of provider configuration %q", len(vals), key)
+		pc.Config = configs.MergeBodies(pc.Config, configs.SynthBody("-provider-var=...", vals))
+	}
+	return diags
+}
+
+// planAfterImport runs a plan operation against the state just written by
+// the import described by importOp, saving the plan to the file given in
+// the -out option, and returns the exit status for the command.
+func (c *ImportCommand) planAfterImport(ctx context.Context, b backend.Enhanced, importOp *backend.Operation, view views.Import, enc encryption.Encryption, args *arguments.Import) int {
+	// The plan operation will acquire its own lock on the state, so we must
+	// release the one we've been holding for the import first.
+	diags := importOp.StateLocker.Unlock()
+	importOp.StateLocker = clistate.NewNoopLocker()
+	if diags.HasErrors() {
+		view.Diagnostics(diags)
+		return 1
+	}
+
+	opReq := c.Operation(ctx, b, view.Backend(), enc)
+	opReq.ConfigDir = args.ConfigPath
+	opReq.Type = backend.OperationTypePlan
+	opReq.PlanMode = plans.NormalMode
+	opReq.PlanRefresh = true
+	opReq.PlanOutPath = args.PlanOutPath
+	opReq.Hooks = view.Hooks()
+	opReq.View = view.Operation()
+
+	var err error
+	opReq.ConfigLoader, err = configload.Initialise(c.configLoader())
+	if err != nil {
+		view.Diagnostics(diags.Append(fmt.Errorf("Failed to initialize config loader: %w", err)))
+		return 1
+	}
+
+	op, diags := c.RunOperation(ctx, b, opReq)
+	view.Diagnostics(diags)
+	if diags.HasErrors() {
+		return 1
+	}
+	return op.Result.ExitStatus()
+}
+
+// checkBackendReachable reads the latest state snapshot for the given
+// workspace from the backend, returning an error if that fails.
+//
+// The state is read again, under the state lock, when the operation starts,
+// so this only serves to report an unreachable or misconfigured backend
+// before anything else happens. Lock acquisition itself is checked at that
+// point, which is still before the provider is contacted.
+func (c *ImportCommand) checkBackendReachable(ctx context.Context, b backend.Backend, workspace string) tfdiags.Diagnostics {
+	var diags tfdiags.Diagnostics
+
+	s, err := b.StateMgr(ctx, workspace)
+	if err == nil {
+		err = s.RefreshState(ctx)
+	}
+	if err != nil {
+		return diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Backend unreachable",
+			fmt.Sprintf("OpenTofu could not read the state for workspace %q from the configured backend, so nothing has been imported. Check the backend configuration and that the backend is reachable.\n\n%s", workspace, err),
+		))
+	}
+	return diags
+}
+
+// ensureWorkspace checks that the named workspace exists in the given
+// backend, creating it first if create is set.
+func (c *ImportCommand) ensureWorkspace(ctx context.Context, b backend.Backend, workspace string, create bool) tfdiags.Diagnostics {
+	var diags tfdiags.Diagnostics
+
+	workspaces, err := b.Workspaces(ctx)
+	if err == backend.ErrWorkspacesNotSupported {
+		if !create {
+			return diags
+		}
+		return diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Workspaces not supported",
+			fmt.Sprintf("The -create-workspace option cannot be used because the configured backend does not support named workspaces, so workspace %q cannot be created.", workspace),
+		))
+	}
+	if err != nil {
+		return diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Error getting existing workspaces",
+			fmt.Sprintf("Failed listing workspaces: %s", err),
+		))
+	}
+	if slices.Contains(workspaces, workspace) {
+		return diags
+	}
+	if !create {
+		return diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Workspace does not exist",
+			fmt.Sprintf("Workspace %q does not exist in the configured backend. Use -create-workspace to create it as part of the import.", workspace),
+		))
+	}
+
+	log.Printf("[INFO] import: creating workspace %q", workspace)
+	if _, err := b.StateMgr(ctx, workspace); err != nil {
+		return diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Error creating workspace",
+			fmt.Sprintf("Failed creating workspace %s: %s", workspace, err),
+		))
+	}
+	return diags
+}
+
+// writeBareImportConfig writes a configuration declaring only the given
+// resource, and a requirement for the given provider if any, to a new
+// temporary directory, returning the path to that directory. The caller is
+// responsible for removing the directory once it is no longer needed.
+func writeBareImportConfig(addr addrs.AbsResourceInstance, providerSource string) (string, tfdiags.Diagnostics) {
+	var diags tfdiags.Diagnostics
+
+	if !addr.Module.IsRoot() || addr.Resource.Key != addrs.NoKey {
+		return "", diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Invalid target resource address",
+			fmt.Sprintf("The -bare option can only import into a single-instance resource in the root module, such as %s.", addr.Resource.Resource),
+		))
+	}
+
+	f := hclwrite.NewEmptyFile()
+	body := f.Body()
+	if providerSource != "" {
+		provider, moreDiags := addrs.ParseProviderSourceString(providerSource)
+		diags = diags.Append(moreDiags)
+		if moreDiags.HasErrors() {
+			return "", diags
+		}
+		// The local name must be the one implied by the resource type, so
+		// that the resource is associated with this provider.
+		requiredProviders := body.AppendNewBlock("terraform", nil).Body().AppendNewBlock("required_providers", nil).Body()
+		requiredProviders.SetAttributeValue(addr.Resource.Resource.ImpliedProvider(), cty.ObjectVal(map[string]cty.Value{
+			"source": cty.StringVal(provider.ForDisplay()),
+		}))
+		body.AppendNewline()
+	}
+	body.AppendNewBlock("resource", []string{addr.Resource.Resource.Type, addr.Resource.Resource.Name})
+
+	dir, err := os.MkdirTemp("", "tofu-import-")
+	if err != nil {
+		return "", diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Error creating temporary configuration",
+			fmt.Sprintf("Failed to create a temporary directory for the -bare configuration: %s.", err),
+		))
+	}
+	if err := os.WriteFile(filepath.Join(dir, "main.tf"), f.Bytes(), 0644); err != nil {
+		os.RemoveAll(dir)
+		return "", diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Error creating temporary configuration",
+			fmt.Sprintf("Failed to write the temporary configuration for -bare: %s.", err),
+		))
+	}
+	return dir, diags
+}
+
+// singleFileImportConfig copies the configuration file at the given path,
+// given to -config in place of a directory, into a new temporary directory of
+// its own so that it can be loaded without the other files alongside it. The
+// caller is responsible for removing the directory once it is no longer
+// needed.
+func singleFileImportConfig(path string) (string, tfdiags.Diagnostics) {
+	var diags tfdiags.Diagnostics
+
+	name := filepath.Base(path)
+	switch {
+	case strings.HasSuffix(name, ".tf"), strings.HasSuffix(name, ".tf.json"),
+		strings.HasSuffix(name, ".tofu"), strings.HasSuffix(name, ".tofu.json"):
+	default:
+		return "", diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Invalid configuration file",
+			fmt.Sprintf("The -config option must be either a directory or a single OpenTofu configuration file (.tf, .tf.json, .tofu or .tofu.json), but %s is neither.", path),
+		))
+	}
+
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return "", diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Error reading configuration file",
+			fmt.Sprintf("Failed to read %s: %s.", path, err),
+		))
+	}
+	dir, err := os.MkdirTemp("", "tofu-import-")
+	if err != nil {
+		return "", diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Error creating temporary configuration",
+			fmt.Sprintf("Failed to create a temporary directory for %s: %s.", path, err),
+		))
+	}
+	if err := os.WriteFile(filepath.Join(dir, name), src, 0644); err != nil {
+		os.RemoveAll(dir)
+		return "", diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Error creating temporary configuration",
+			fmt.Sprintf("Failed to copy %s to a temporary directory: %s.", path, err),
+		))
+	}
+	return dir, diags
+}
+
+// importSnapshot is the JSON document written to the file given in the
+// -snapshot-out option, recording the imported resource instance.
+//
+// The snapshot has no "before" state because the import command refuses to
+// import to an address that is already in the state.
+type importSnapshot struct {
+	Address string `json:"address"`
+
+	// After is the JSON representation of the attributes of the imported
+	// object, with sensitive attributes replaced by null.
+	After json.RawMessage `json:"after"`
+}
+
+// encryptionConfigured returns true if encryption is configured for the
+// given root module, either in the module itself or in the environment.
+func encryptionConfigured(module *configs.Module) bool {
+	return module.Encryption != nil || strings.TrimSpace(os.Getenv(encryptionConfigEnvName)) != ""
+}
+
+// writeImportSnapshot writes an [importSnapshot] for the given object, whose
+// sensitive attributes must already have been redacted, to the given path.
+// The file is not encrypted, so it is readable only by its owner.
+func writeImportSnapshot(path string, addr addrs.AbsResourceInstance, obj *states.ResourceInstanceObjectSrc) error {
+	snap := importSnapshot{
+		Address: addr.String(),
+		After:   obj.AttrsJSON,
+	}
+	src, err := json.MarshalIndent(snap, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, src, 0600)
+}
+
+// hasUnquotedInstanceKey returns true if the given resource address, which
+// failed to parse, seems to have an instance key that is neither a number nor
+// quoted, which is typically because the shell removed the quotes.
+func hasUnquotedInstanceKey(addr string) bool {
+	for {
+		i := strings.IndexByte(addr, '[')
+		if i < 0 || i+1 >= len(addr) {
+			return false
+		}
+		addr = addr[i+1:]
+		if c := addr[0]; c != '"' && (c < '0' || c > '9') {
+			return true
+		}
+	}
+}
+
+// writeImportRollback writes a shell script to the given path that removes
+// the object imported to addr from the state, undoing the import. The
+// workspace and state path are those given on the command line, if any, so
+// that the script affects the same state.
+func writeImportRollback(path string, addr addrs.AbsResourceInstance, workspace, statePath string) error {
+	var buf strings.Builder
+	buf.WriteString("#!/bin/sh\n")
+	fmt.Fprintf(&buf, "# Undoes \"tofu import\" of %s by removing it from the state.\n", addr)
+	buf.WriteString("set -e\n")
+	if workspace != "" {
+		fmt.Fprintf(&buf, "TF_WORKSPACE=%s ", shellQuote(workspace))
+	}
+	buf.WriteString("tofu state rm")
+	if statePath != "" {
+		fmt.Fprintf(&buf, " -state=%s", shellQuote(statePath))
+	}
+	fmt.Fprintf(&buf, " %s\n", shellQuote(addr.String()))
+	return os.WriteFile(path, []byte(buf.String()), 0755)
+}
+
+// shellQuote quotes s as a single word for a POSIX shell.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func (c *ImportCommand) Help() string {
+	helpText := `
+Usage: tofu [global options] import [options] ADDR ID [ADDR ID ...]
+       tofu [global options] import [options] -identity=JSON ADDR
+
+  Import existing infrastructure into your OpenTofu state.
+
+  This will find and import the specified resource into your OpenTofu
+  state, allowing existing infrastructure to come under OpenTofu
+  management without having to be initially created by OpenTofu.
+
+  The ADDR specified is the address to import the resource to. Please
+  see the documentation online for resource addresses. The ID is a
+  resource-specific ID to identify that resource being imported. Please
+  reference the documentation for the resource type you're importing to
+  determine the ID syntax to use. It typically matches directly to the ID
+  that the provider uses.
+
+  To import several resources in one operation, give further pairs of ADDR
+  and ID, or list them in a file given with -import-file. The state is
+  saved only if all of them are imported successfully.
+
+  This command will not modify your infrastructure, but it will make
+  network requests to inspect parts of your infrastructure relevant to
+  the resource being imported.
+
+Options:
+
+  -against-plan=path      Refuse to import unless the saved plan at the given
+                          path includes an import of the same ID to the same
+                          address, so that only reviewed imports are made.
+
+  -bare                   Import using a temporary configuration containing only
+                          an empty block for the target resource, instead of
+                          the configuration in the current directory. The
+                          resource must be a single instance in the root
+                          module, and its provider must already be installed.
+
+  -capabilities           Instead of importing, print a JSON report of the
+                          managed resource types of each provider that the
+                          configuration requires, and of whether each can be
+                          imported by identity. ADDR and ID must be omitted.
+
+  -compact-warnings       If OpenTofu produces any warnings that are not
+                          accompanied by errors, show them in a more compact
+                          form that includes only the summary messages.
+
+  -consolidate-warnings   If OpenTofu produces any warnings, no consolidation
+                          will be performed. All locations, for all warnings
+                          will be listed. Enabled by default.
+
+  -consolidate-errors     If OpenTofu produces any errors, no consolidation
+                          will be performed. All locations, for all errors
+                          will be listed. Disabled by default
+
+  -config=path            Path to a directory of OpenTofu configuration files
+                          to use to configure the provider. Defaults to pwd.
+                          May instead be a single .tf file, in which case only
+                          that file is loaded. If no config files are present,
+                          they must be provided via the input prompts or env
+                          vars.
+
+  -create-workspace       Create the workspace given by -workspace in the
+                          backend if it does not already exist.
+
+  -depends-on=addr        Record the given resource as a dependency of the
+                          imported resource in the state, so that the next
+                          apply orders operations correctly. Can be used
+                          multiple times.
+
+  -dry-run                An alias for -read-only.
+
+  -id-sensitive           Treat the ID as a secret, replacing it with a
+                          redaction marker in all logs and output, and
+                          redacting the imported object's "id" attribute from
+                          -read-only output and -snapshot-out files. The ID
+                          is still passed to the provider as normal.
+
+  -identity=json          Import the resource identified by the given JSON
+                          object instead of by a string ID, for resource types
+                          that support identity-based import. ID must then be
+                          omitted.
+
+  -import-file=path       Also import the resources listed in the given file,
+                          one per line as an address and an ID separated by
+                          whitespace. Blank lines and lines starting with #
+                          are ignored. ADDR and ID may then be omitted.
+
+  -input=false            Disable interactive input prompts.
+
+  -input-format=json      With -import-file, read the file as a JSON array of
+                          objects with "address" and "id" properties instead.
+
+  -lock=false             Don't hold a state lock during the operation. This is
+                          dangerous if others might concurrently run commands
+                          against the same workspace.
+
+  -lock-timeout=0s        Duration to retry a state lock.
+
+  -no-color               If specified, output won't contain any color.
+
+  -move-to=addr           After importing, move the object to this address
+                          instead, in the same operation. The address must
+                          be a free instance of a configured resource of the
+                          same type.
+
+  -out=path               Once the import has succeeded, create a plan against
+                          the new state and save it to the given path, as
+                          "tofu plan -out" would.
+
+  -provider-source=source With -bare, the source address of the provider to
+                          use for the resource, such as "hashicorp/aws".
+                          Defaults to the provider implied by the resource
+                          type.
+
+  -provider-var 'aws.region=us-west-2'
+                          Override an argument of a provider configuration
+                          in the root module, given as NAME[.ALIAS].ARGUMENT,
+                          for this import only. This flag can be set multiple
+                          times.
+
+  -read-only              Show the object that the provider returns for the
+                          given ID, with sensitive attributes redacted,
+                          instead of saving it to the state. Use this to
+                          check that the ID refers to the expected object
+                          before importing it.
+
+  -rollback-out=path      After a successful import, write a shell script to
+                          the given path that removes the imported object from
+                          the state again, undoing the import.
+
+  -schema-version=n       Record the imported object in the state with the
+                          given resource schema version instead of the one
+                          reported by the provider, so that the provider
+                          upgrades it from that version during the next
+                          operation. Use with care when migrating state.
+
+  -snapshot-out=path      Write a JSON file describing the imported resource
+                          instance, with sensitive attributes redacted, for
+                          use as a record of the change. Not allowed when
+                          encryption is configured.
+
+  -var 'foo=bar'          Set a variable in the OpenTofu configuration. This
                           flag can be set multiple times. This is only useful
                           with the "-config" flag.
 
@@ -385,6 +1754,9 @@ Options:
                           a file. If "terraform.tfvars" or any ".auto.tfvars"
                           files are present, they will be automatically loaded.
 
+  -workspace=name         Import into the given workspace instead of the
+                          currently selected one.
+
   -ignore-remote-version  A rare option used for the remote backend only. See
                           the remote backend documentation for more information.
 
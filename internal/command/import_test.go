@@ -6,6 +6,8 @@
 package command
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"log"
 	"os"
@@ -13,13 +15,19 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/google/go-cmp/cmp"
 	"github.com/opentofu/opentofu/internal/command/workdir"
 	"github.com/zclconf/go-cty/cty"
 
+	"github.com/opentofu/opentofu/internal/addrs"
+	"github.com/opentofu/opentofu/internal/backend/local"
 	"github.com/opentofu/opentofu/internal/configs/configschema"
 	"github.com/opentofu/opentofu/internal/copy"
+	"github.com/opentofu/opentofu/internal/plans"
 	"github.com/opentofu/opentofu/internal/providers"
+	"github.com/opentofu/opentofu/internal/states"
 	"github.com/opentofu/opentofu/internal/tfdiags"
+	"github.com/opentofu/opentofu/internal/tofu"
 )
 
 func TestImport(t *testing.T) {
@@ -1051,6 +1059,9 @@ func TestImport_ForEachKeyInTargetResourceAddr(t *testing.T) {
 	if code != 0 {
 		t.Fatalf("import failed; expected success for existing resource: %s", output.Stderr())
 	}
+	if want := `whose instance key is "a"`; !strings.Contains(output.Stdout(), want) {
+		t.Errorf("parsed instance key not echoed\ngot: %s\nwant substring: %s", output.Stdout(), want)
+	}
 	// Non-existent key should fail
 	args = []string{
 		"-state", statePath,
@@ -1257,6 +1268,1617 @@ func TestImport_ForEachKeyInModuleAndResourceAddr(t *testing.T) {
 	}
 }
 
+func TestImport_snapshotOut(t *testing.T) {
+	t.Chdir(testFixturePath("import-provider-implicit"))
+
+	statePath := testTempFile(t)
+	snapshotPath := filepath.Join(t.TempDir(), "snapshot.json")
+
+	p := testImportProvider()
+	view, done := testView(t)
+	c := &ImportCommand{
+		Meta: Meta{
+			WorkingDir:       workdir.NewDir("."),
+			testingOverrides: metaOverridesForProvider(p),
+			View:             view,
+		},
+	}
+
+	args := []string{
+		"-state", statePath,
+		"-snapshot-out", snapshotPath,
+		"test_instance.foo",
+		"bar",
+	}
+	code := c.Run(args)
+	output := done(t)
+	if code != 0 {
+		t.Fatalf("bad: %d\n\n%s", code, output.Stderr())
+	}
+	testStateOutput(t, statePath, testImportStr)
+
+	src, err := os.ReadFile(snapshotPath)
+	if err != nil {
+		t.Fatalf("failed to read snapshot: %s", err)
+	}
+	var got importSnapshot
+	if err := json.Unmarshal(src, &got); err != nil {
+		t.Fatalf("invalid snapshot JSON: %s\n%s", err, src)
+	}
+	if got, want := got.Address, "test_instance.foo"; got != want {
+		t.Errorf("wrong address %q; want %q", got, want)
+	}
+	if got, want := compactJSON(t, got.After), `{"id":"yay"}`; got != want {
+		t.Errorf("wrong after %s; want %s", got, want)
+	}
+	info, err := os.Stat(snapshotPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := info.Mode().Perm(), os.FileMode(0600); got != want {
+		t.Errorf("wrong snapshot file mode %s; want %s", got, want)
+	}
+}
+
+func TestImport_snapshotOutSensitive(t *testing.T) {
+	t.Chdir(testFixturePath("import-provider-implicit"))
+
+	snapshotPath := filepath.Join(t.TempDir(), "snapshot.json")
+
+	p := testImportProvider()
+	p.ImportResourceStateResponse.ImportedResources[0].State = cty.ObjectVal(map[string]cty.Value{
+		"id":       cty.StringVal("yay"),
+		"password": cty.StringVal("hunter2"),
+	})
+	p.GetProviderSchemaResponse.ResourceTypes["test_instance"].Block.Attributes["password"] = &configschema.Attribute{
+		Type:      cty.String,
+		Computed:  true,
+		Sensitive: true,
+	}
+	view, done := testView(t)
+	c := &ImportCommand{
+		Meta: Meta{
+			WorkingDir:       workdir.NewDir("."),
+			testingOverrides: metaOverridesForProvider(p),
+			View:             view,
+		},
+	}
+
+	args := []string{
+		"-state", testTempFile(t),
+		"-snapshot-out", snapshotPath,
+		"test_instance.foo",
+		"bar",
+	}
+	code := c.Run(args)
+	output := done(t)
+	if code != 0 {
+		t.Fatalf("bad: %d\n\n%s", code, output.Stderr())
+	}
+
+	src, err := os.ReadFile(snapshotPath)
+	if err != nil {
+		t.Fatalf("failed to read snapshot: %s", err)
+	}
+	if strings.Contains(string(src), "hunter2") {
+		t.Errorf("sensitive attribute not redacted\n%s", src)
+	}
+}
+
+func TestImport_snapshotOutEncryption(t *testing.T) {
+	t.Chdir(testFixturePath("import-provider-implicit"))
+	t.Setenv(encryptionConfigEnvName, `
+key_provider "pbkdf2" "basic" {
+  passphrase = "a-very-long-passphrase"
+}
+method "aes_gcm" "basic" {
+  keys = key_provider.pbkdf2.basic
+}
+state {
+  method = method.aes_gcm.basic
+}
+`)
+
+	snapshotPath := filepath.Join(t.TempDir(), "snapshot.json")
+
+	p := testImportProvider()
+	view, done := testView(t)
+	c := &ImportCommand{
+		Meta: Meta{
+			WorkingDir:       workdir.NewDir("."),
+			testingOverrides: metaOverridesForProvider(p),
+			View:             view,
+		},
+	}
+
+	args := []string{
+		"-state", testTempFile(t),
+		"-snapshot-out", snapshotPath,
+		"test_instance.foo",
+		"bar",
+	}
+	code := c.Run(args)
+	output := done(t)
+	if code != 1 {
+		t.Fatalf("import succeeded; expected failure\n\n%s", output.Stdout())
+	}
+	if p.ImportResourceStateCalled {
+		t.Error("ImportResourceState was called despite the invalid -snapshot-out option")
+	}
+	if _, err := os.Stat(snapshotPath); !os.IsNotExist(err) {
+		t.Errorf("snapshot was written despite encryption")
+	}
+}
+
+func TestImport_rollbackOut(t *testing.T) {
+	t.Chdir(testFixturePath("import-provider-implicit"))
+
+	statePath := testTempFile(t)
+	rollbackPath := filepath.Join(t.TempDir(), "rollback.sh")
+
+	p := testImportProvider()
+	view, done := testView(t)
+	c := &ImportCommand{
+		Meta: Meta{
+			WorkingDir:       workdir.NewDir("."),
+			testingOverrides: metaOverridesForProvider(p),
+			View:             view,
+		},
+	}
+
+	args := []string{
+		"-state", statePath,
+		"-rollback-out", rollbackPath,
+		"test_instance.foo",
+		"bar",
+	}
+	code := c.Run(args)
+	output := done(t)
+	if code != 0 {
+		t.Fatalf("bad: %d\n\n%s", code, output.Stderr())
+	}
+	testStateOutput(t, statePath, testImportStr)
+
+	src, err := os.ReadFile(rollbackPath)
+	if err != nil {
+		t.Fatalf("failed to read rollback script: %s", err)
+	}
+	want := fmt.Sprintf("tofu state rm -state='%s' 'test_instance.foo'\n", statePath)
+	if !strings.HasSuffix(string(src), want) {
+		t.Errorf("wrong rollback script\ngot:\n%s\nwant suffix:\n%s", src, want)
+	}
+}
+
+func TestWriteImportRollback(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rollback.sh")
+	addr := addrs.RootModuleInstance.ResourceInstance(
+		addrs.ManagedResourceMode, "test_instance", "foo", addrs.StringKey("it's"),
+	)
+	if err := writeImportRollback(path, addr, "staging", ""); err != nil {
+		t.Fatal(err)
+	}
+	src, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `#!/bin/sh
+# Undoes "tofu import" of test_instance.foo["it's"] by removing it from the state.
+set -e
+TF_WORKSPACE='staging' tofu state rm 'test_instance.foo["it'\''s"]'
+`
+	if got := string(src); got != want {
+		t.Errorf("wrong rollback script\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestImport_capabilities(t *testing.T) {
+	t.Chdir(testFixturePath("import-provider-implicit"))
+
+	p := testImportProvider()
+	p.GetProviderSchemaResponse.ResourceTypes["test_identified"] = providers.Schema{
+		Block: &configschema.Block{
+			Attributes: map[string]*configschema.Attribute{
+				"id": {Type: cty.String, Optional: true, Computed: true},
+			},
+		},
+		IdentitySchemaVersion: 2,
+		IdentitySchema: &configschema.Object{
+			Attributes: map[string]*configschema.Attribute{
+				"name": {Type: cty.String, Required: true},
+			},
+		},
+	}
+	view, done := testView(t)
+	c := &ImportCommand{
+		Meta: Meta{
+			WorkingDir:       workdir.NewDir("."),
+			testingOverrides: metaOverridesForProvider(p),
+			View:             view,
+		},
+	}
+
+	statePath := testTempFile(t)
+	code := c.Run([]string{"-state", statePath, "-capabilities"})
+	output := done(t)
+	if code != 0 {
+		t.Fatalf("bad: %d\n\n%s", code, output.Stderr())
+	}
+	if p.ImportResourceStateCalled {
+		t.Error("provider was asked to import with -capabilities")
+	}
+
+	var got importCapabilityReport
+	if err := json.Unmarshal([]byte(output.Stdout()), &got); err != nil {
+		t.Fatalf("invalid report: %s\n%s", err, output.Stdout())
+	}
+	version := int64(2)
+	want := importCapabilityReport{
+		FormatVersion: "1.0",
+		Providers: map[string]providerImportCapabilities{
+			"registry.opentofu.org/hashicorp/test": {
+				ResourceTypes: map[string]resourceTypeImportCapabilities{
+					"test_instance": {},
+					"test_identified": {
+						IdentityImport:        true,
+						IdentitySchemaVersion: &version,
+					},
+				},
+			},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("wrong report\n%s", diff)
+	}
+}
+
+func TestImport_createWorkspace(t *testing.T) {
+	td := t.TempDir()
+	testCopyDir(t, testFixturePath("import-provider-implicit"), td)
+	t.Chdir(td)
+
+	p := testImportProvider()
+	view, done := testView(t)
+	c := &ImportCommand{
+		Meta: Meta{
+			WorkingDir:       workdir.NewDir("."),
+			testingOverrides: metaOverridesForProvider(p),
+			View:             view,
+		},
+	}
+
+	args := []string{
+		"-workspace=staging",
+		"-create-workspace",
+		"test_instance.foo",
+		"bar",
+	}
+	code := c.Run(args)
+	output := done(t)
+	if code != 0 {
+		t.Fatalf("bad: %d\n\n%s", code, output.Stderr())
+	}
+	testStateOutput(t, filepath.Join(local.DefaultWorkspaceDir, "staging", local.DefaultStateFilename), testImportStr)
+
+	// The new workspace is used only for this import, not selected for
+	// later commands.
+	if current, _ := c.WorkspaceOverridden(t.Context()); current != "staging" {
+		t.Errorf("wrong workspace during import %q; want %q", current, "staging")
+	}
+	if _, err := os.Stat(filepath.Join(c.WorkingDir.DataDir(), local.DefaultWorkspaceFile)); !os.IsNotExist(err) {
+		t.Errorf("workspace selection was unexpectedly persisted")
+	}
+}
+
+func TestImport_workspaceMissing(t *testing.T) {
+	td := t.TempDir()
+	testCopyDir(t, testFixturePath("import-provider-implicit"), td)
+	t.Chdir(td)
+
+	p := testImportProvider()
+	view, done := testView(t)
+	c := &ImportCommand{
+		Meta: Meta{
+			WorkingDir:       workdir.NewDir("."),
+			testingOverrides: metaOverridesForProvider(p),
+			View:             view,
+		},
+	}
+
+	args := []string{
+		"-input=false",
+		"-workspace=staging",
+		"test_instance.foo",
+		"bar",
+	}
+	code := c.Run(args)
+	output := done(t)
+	if code != 1 {
+		t.Fatalf("unexpected success: %d\n\n%s", code, output.Stdout())
+	}
+	if _, err := os.Stat(filepath.Join(local.DefaultWorkspaceDir, "staging")); !os.IsNotExist(err) {
+		t.Errorf("workspace was created without -create-workspace")
+	}
+}
+
+func TestImport_bare(t *testing.T) {
+	// The working directory has no configuration at all.
+	t.Chdir(t.TempDir())
+
+	statePath := testTempFile(t)
+
+	p := testImportProvider()
+	view, done := testView(t)
+	c := &ImportCommand{
+		Meta: Meta{
+			WorkingDir:       workdir.NewDir("."),
+			testingOverrides: metaOverridesForProvider(p),
+			View:             view,
+		},
+	}
+
+	args := []string{
+		"-state", statePath,
+		"-bare",
+		"-provider-source=hashicorp/test",
+		"test_instance.foo",
+		"bar",
+	}
+	code := c.Run(args)
+	output := done(t)
+	if code != 0 {
+		t.Fatalf("bad: %d\n\n%s", code, output.Stderr())
+	}
+	if !p.ImportResourceStateCalled {
+		t.Fatal("ImportResourceState should be called")
+	}
+	testStateOutput(t, statePath, testImportStr)
+}
+
+func TestImport_bareInstanceKey(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	p := testImportProvider()
+	view, done := testView(t)
+	c := &ImportCommand{
+		Meta: Meta{
+			WorkingDir:       workdir.NewDir("."),
+			testingOverrides: metaOverridesForProvider(p),
+			View:             view,
+		},
+	}
+
+	args := []string{
+		"-bare",
+		"test_instance.foo[0]",
+		"bar",
+	}
+	code := c.Run(args)
+	output := done(t)
+	if code != 1 {
+		t.Fatalf("unexpected success: %d\n\n%s", code, output.Stdout())
+	}
+	if got, want := output.Stderr(), "The -bare option can only import"; !strings.Contains(got, want) {
+		t.Errorf("missing expected error\ngot: %s\nwant substring: %s", got, want)
+	}
+}
+
+func TestImport_configFile(t *testing.T) {
+	td := t.TempDir()
+	t.Chdir(td)
+
+	// Only main.tf is named by -config, so the invalid file alongside it
+	// must not be loaded.
+	if err := os.WriteFile(filepath.Join(td, "main.tf"), []byte(`resource "test_instance" "foo" {}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(td, "broken.tf"), []byte(`resource "test_instance" {`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	statePath := testTempFile(t)
+
+	p := testImportProvider()
+	view, done := testView(t)
+	c := &ImportCommand{
+		Meta: Meta{
+			WorkingDir:       workdir.NewDir("."),
+			testingOverrides: metaOverridesForProvider(p),
+			View:             view,
+		},
+	}
+
+	args := []string{
+		"-state", statePath,
+		"-config", filepath.Join(td, "main.tf"),
+		"test_instance.foo",
+		"bar",
+	}
+	code := c.Run(args)
+	output := done(t)
+	if code != 0 {
+		t.Fatalf("bad: %d\n\n%s", code, output.Stderr())
+	}
+	if !p.ImportResourceStateCalled {
+		t.Fatal("ImportResourceState should be called")
+	}
+	testStateOutput(t, statePath, testImportStr)
+}
+
+func TestImport_configFileInvalidExtension(t *testing.T) {
+	td := t.TempDir()
+	t.Chdir(td)
+
+	if err := os.WriteFile(filepath.Join(td, "main.txt"), []byte(`resource "test_instance" "foo" {}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	p := testImportProvider()
+	view, done := testView(t)
+	c := &ImportCommand{
+		Meta: Meta{
+			WorkingDir:       workdir.NewDir("."),
+			testingOverrides: metaOverridesForProvider(p),
+			View:             view,
+		},
+	}
+
+	args := []string{
+		"-config", filepath.Join(td, "main.txt"),
+		"test_instance.foo",
+		"bar",
+	}
+	code := c.Run(args)
+	output := done(t)
+	if code != 1 {
+		t.Fatalf("unexpected success: %d\n\n%s", code, output.Stdout())
+	}
+	if got, want := output.Stderr(), "Invalid configuration file"; !strings.Contains(got, want) {
+		t.Errorf("missing expected error\ngot: %s\nwant substring: %s", got, want)
+	}
+}
+
+func TestImport_severalResources(t *testing.T) {
+	td := t.TempDir()
+	t.Chdir(td)
+
+	config := `
+resource "test_instance" "foo" {}
+resource "test_instance" "bar" {}
+resource "test_instance" "baz" {}
+`
+	if err := os.WriteFile(filepath.Join(td, "main.tf"), []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+	importFile := "# imported from the file\n\ntest_instance.baz\tbaz-id\n"
+	if err := os.WriteFile(filepath.Join(td, "imports.txt"), []byte(importFile), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	statePath := testTempFile(t)
+
+	p := testImportProvider()
+	p.ImportResourceStateFn = func(req providers.ImportResourceStateRequest) providers.ImportResourceStateResponse {
+		return providers.ImportResourceStateResponse{
+			ImportedResources: []providers.ImportedResource{
+				{
+					TypeName: "test_instance",
+					State: cty.ObjectVal(map[string]cty.Value{
+						"id": cty.StringVal(req.Target.ID),
+					}),
+				},
+			},
+		}
+	}
+	view, done := testView(t)
+	c := &ImportCommand{
+		Meta: Meta{
+			WorkingDir:       workdir.NewDir("."),
+			testingOverrides: metaOverridesForProvider(p),
+			View:             view,
+		},
+	}
+
+	args := []string{
+		"-state", statePath,
+		"-import-file", "imports.txt",
+		"test_instance.foo", "foo-id",
+		"test_instance.bar", "bar-id",
+	}
+	code := c.Run(args)
+	output := done(t)
+	if code != 0 {
+		t.Fatalf("bad: %d\n\n%s", code, output.Stderr())
+	}
+	testStateOutput(t, statePath, `
+test_instance.bar:
+  ID = bar-id
+  provider = provider["registry.opentofu.org/hashicorp/test"]
+test_instance.baz:
+  ID = baz-id
+  provider = provider["registry.opentofu.org/hashicorp/test"]
+test_instance.foo:
+  ID = foo-id
+  provider = provider["registry.opentofu.org/hashicorp/test"]
+`)
+}
+
+func TestImport_severalResourcesPartialFailure(t *testing.T) {
+	td := t.TempDir()
+	t.Chdir(td)
+
+	config := `
+resource "test_instance" "foo" {}
+resource "test_instance" "bar" {}
+`
+	if err := os.WriteFile(filepath.Join(td, "main.tf"), []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	statePath := testTempFile(t)
+
+	p := testImportProvider()
+	p.ImportResourceStateFn = func(req providers.ImportResourceStateRequest) providers.ImportResourceStateResponse {
+		var resp providers.ImportResourceStateResponse
+		if req.Target.ID == "missing" {
+			resp.Diagnostics = resp.Diagnostics.Append(fmt.Errorf("no object with ID %q", req.Target.ID))
+			return resp
+		}
+		resp.ImportedResources = []providers.ImportedResource{
+			{
+				TypeName: "test_instance",
+				State: cty.ObjectVal(map[string]cty.Value{
+					"id": cty.StringVal(req.Target.ID),
+				}),
+			},
+		}
+		return resp
+	}
+	view, done := testView(t)
+	c := &ImportCommand{
+		Meta: Meta{
+			WorkingDir:       workdir.NewDir("."),
+			testingOverrides: metaOverridesForProvider(p),
+			View:             view,
+		},
+	}
+
+	args := []string{
+		"-state", statePath,
+		"test_instance.foo", "foo-id",
+		"test_instance.bar", "missing",
+	}
+	code := c.Run(args)
+	output := done(t)
+	if code != 1 {
+		t.Fatalf("bad: %d\n\n%s", code, output.All())
+	}
+	got := output.All()
+	for _, want := range []string{
+		`no object with ID "missing"`,
+		"Some resources were imported successfully",
+		"- test_instance.foo",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing expected output %q\ngot: %s", want, got)
+		}
+	}
+	if strings.Contains(got, "- test_instance.bar") {
+		t.Errorf("failed import reported as successful\ngot: %s", got)
+	}
+	if _, err := os.Stat(statePath); !os.IsNotExist(err) {
+		t.Errorf("state was written despite the failed import")
+	}
+}
+
+func TestImport_importFileJSON(t *testing.T) {
+	td := t.TempDir()
+	t.Chdir(td)
+
+	config := `
+resource "test_instance" "foo" {}
+resource "test_instance" "bar" {}
+`
+	if err := os.WriteFile(filepath.Join(td, "main.tf"), []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+	importFile := `[
+  {"address": "test_instance.foo", "id": "foo-id"},
+  {"address": "test_instance.bar", "id": "bar-id"}
+]`
+	if err := os.WriteFile(filepath.Join(td, "imports.json"), []byte(importFile), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	statePath := testTempFile(t)
+
+	p := testImportProvider()
+	p.ImportResourceStateFn = func(req providers.ImportResourceStateRequest) providers.ImportResourceStateResponse {
+		return providers.ImportResourceStateResponse{
+			ImportedResources: []providers.ImportedResource{
+				{
+					TypeName: "test_instance",
+					State: cty.ObjectVal(map[string]cty.Value{
+						"id": cty.StringVal(req.Target.ID),
+					}),
+				},
+			},
+		}
+	}
+	view, done := testView(t)
+	c := &ImportCommand{
+		Meta: Meta{
+			WorkingDir:       workdir.NewDir("."),
+			testingOverrides: metaOverridesForProvider(p),
+			View:             view,
+		},
+	}
+
+	args := []string{
+		"-state", statePath,
+		"-import-file", "imports.json",
+		"-input-format", "json",
+	}
+	code := c.Run(args)
+	output := done(t)
+	if code != 0 {
+		t.Fatalf("bad: %d\n\n%s", code, output.Stderr())
+	}
+	if got, want := output.Stdout(), "2 resources were imported."; !strings.Contains(got, want) {
+		t.Errorf("missing summary\ngot: %s\nwant substring: %s", got, want)
+	}
+	testStateOutput(t, statePath, `
+test_instance.bar:
+  ID = bar-id
+  provider = provider["registry.opentofu.org/hashicorp/test"]
+test_instance.foo:
+  ID = foo-id
+  provider = provider["registry.opentofu.org/hashicorp/test"]
+`)
+}
+
+func TestImport_importFileJSONMissingResourceConfig(t *testing.T) {
+	td := t.TempDir()
+	t.Chdir(td)
+
+	if err := os.WriteFile(filepath.Join(td, "main.tf"), []byte(`resource "test_instance" "foo" {}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	importFile := `[
+  {"address": "test_instance.foo", "id": "foo-id"},
+  {"address": "test_instance.fooo", "id": "fooo-id"}
+]`
+	if err := os.WriteFile(filepath.Join(td, "imports.json"), []byte(importFile), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	p := testImportProvider()
+	view, done := testView(t)
+	c := &ImportCommand{
+		Meta: Meta{
+			WorkingDir:       workdir.NewDir("."),
+			testingOverrides: metaOverridesForProvider(p),
+			View:             view,
+		},
+	}
+
+	args := []string{
+		"-state", testTempFile(t),
+		"-import-file", "imports.json",
+		"-input-format", "json",
+	}
+	code := c.Run(args)
+	output := done(t)
+	if code != 1 {
+		t.Fatalf("import succeeded; expected failure\n\n%s", output.Stdout())
+	}
+	if p.ImportResourceStateCalled {
+		t.Error("ImportResourceState was called despite the invalid address")
+	}
+	if got, want := output.Stderr(), `resource address "test_instance.fooo" does not exist in the configuration`; !strings.Contains(got, want) {
+		t.Errorf("missing expected error\ngot: %s\nwant substring: %s", got, want)
+	}
+}
+
+func TestImport_identity(t *testing.T) {
+	t.Chdir(testFixturePath("import-provider-implicit"))
+
+	statePath := testTempFile(t)
+
+	p := testImportProvider()
+	schema := p.GetProviderSchemaResponse.ResourceTypes["test_instance"]
+	schema.IdentitySchema = &configschema.Object{
+		Attributes: map[string]*configschema.Attribute{
+			"name": {Type: cty.String, Required: true},
+		},
+		Nesting: configschema.NestingSingle,
+	}
+	p.GetProviderSchemaResponse.ResourceTypes["test_instance"] = schema
+	p.ImportResourceStateFn = func(req providers.ImportResourceStateRequest) providers.ImportResourceStateResponse {
+		var resp providers.ImportResourceStateResponse
+		want := cty.ObjectVal(map[string]cty.Value{"name": cty.StringVal("bar")})
+		if req.Target.ID != "" || !req.Target.Identity.RawEquals(want) {
+			resp.Diagnostics = resp.Diagnostics.Append(fmt.Errorf("wrong import target: ID %q, identity %#v", req.Target.ID, req.Target.Identity))
+			return resp
+		}
+		resp.ImportedResources = []providers.ImportedResource{
+			{
+				TypeName: "test_instance",
+				State: cty.ObjectVal(map[string]cty.Value{
+					"id": cty.StringVal("yay"),
+				}),
+			},
+		}
+		return resp
+	}
+	view, done := testView(t)
+	c := &ImportCommand{
+		Meta: Meta{
+			WorkingDir:       workdir.NewDir("."),
+			testingOverrides: metaOverridesForProvider(p),
+			View:             view,
+		},
+	}
+
+	args := []string{
+		"-state", statePath,
+		`-identity={"name":"bar"}`,
+		"test_instance.foo",
+	}
+	code := c.Run(args)
+	output := done(t)
+	if code != 0 {
+		t.Fatalf("bad: %d\n\n%s", code, output.Stderr())
+	}
+	testStateOutput(t, statePath, testImportStr)
+}
+
+func TestImport_identityErrors(t *testing.T) {
+	identitySchema := &configschema.Object{
+		Attributes: map[string]*configschema.Attribute{
+			"name": {Type: cty.String, Required: true},
+		},
+		Nesting: configschema.NestingSingle,
+	}
+	tests := map[string]struct {
+		identitySchema *configschema.Object
+		identity       string
+		want           string
+	}{
+		"not supported": {
+			identity: `{"name":"bar"}`,
+			want:     "does not support importing test_instance resources by identity",
+		},
+		"wrong attribute": {
+			identitySchema: identitySchema,
+			identity:       `{"nmae":"bar"}`,
+			want:           "does not conform to the identity schema of test_instance resources",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Chdir(testFixturePath("import-provider-implicit"))
+
+			p := testImportProvider()
+			schema := p.GetProviderSchemaResponse.ResourceTypes["test_instance"]
+			schema.IdentitySchema = test.identitySchema
+			p.GetProviderSchemaResponse.ResourceTypes["test_instance"] = schema
+			view, done := testView(t)
+			c := &ImportCommand{
+				Meta: Meta{
+					WorkingDir:       workdir.NewDir("."),
+					testingOverrides: metaOverridesForProvider(p),
+					View:             view,
+				},
+			}
+
+			args := []string{
+				"-no-color",
+				"-state", testTempFile(t),
+				"-identity", test.identity,
+				"test_instance.foo",
+			}
+			code := c.Run(args)
+			output := done(t)
+			if code != 1 {
+				t.Fatalf("import succeeded; expected failure\n\n%s", output.Stdout())
+			}
+			if p.ImportResourceStateCalled {
+				t.Error("ImportResourceState was called despite the invalid identity")
+			}
+			if got := strings.ReplaceAll(output.Stderr(), "\n", " "); !strings.Contains(got, test.want) {
+				t.Errorf("missing expected error\ngot: %s\nwant substring: %s", got, test.want)
+			}
+		})
+	}
+}
+
+func TestImport_planOut(t *testing.T) {
+	td := t.TempDir()
+	testCopyDir(t, testFixturePath("import-provider-implicit"), td)
+	t.Chdir(td)
+
+	statePath := testTempFile(t)
+	planPath := filepath.Join(td, "tfplan")
+
+	p := testImportProvider()
+	view, done := testView(t)
+	c := &ImportCommand{
+		Meta: Meta{
+			WorkingDir:       workdir.NewDir("."),
+			testingOverrides: metaOverridesForProvider(p),
+			View:             view,
+		},
+	}
+
+	args := []string{
+		"-state", statePath,
+		"-out", planPath,
+		"test_instance.foo",
+		"bar",
+	}
+	code := c.Run(args)
+	output := done(t)
+	if code != 0 {
+		t.Fatalf("bad: %d\n\n%s", code, output.Stderr())
+	}
+	testStateOutput(t, statePath, testImportStr)
+
+	// The plan must have been created against the state that includes the
+	// newly-imported object.
+	plan := testReadPlan(t, planPath)
+	addr := addrs.Resource{
+		Mode: addrs.ManagedResourceMode,
+		Type: "test_instance",
+		Name: "foo",
+	}.Instance(addrs.NoKey).Absolute(addrs.RootModuleInstance)
+	if plan.PrevRunState.ResourceInstance(addr) == nil {
+		t.Errorf("saved plan's prior state does not include %s", addr)
+	}
+}
+
+func TestImport_dependsOn(t *testing.T) {
+	t.Chdir(testFixturePath("import-depends-on"))
+
+	statePath := testTempFile(t)
+
+	p := testImportProvider()
+	view, done := testView(t)
+	c := &ImportCommand{
+		Meta: Meta{
+			WorkingDir:       workdir.NewDir("."),
+			testingOverrides: metaOverridesForProvider(p),
+			View:             view,
+		},
+	}
+
+	args := []string{
+		"-state", statePath,
+		"-depends-on", "test_instance.bar",
+		"test_instance.foo",
+		"bar",
+	}
+	code := c.Run(args)
+	output := done(t)
+	if code != 0 {
+		t.Fatalf("bad: %d\n\n%s", code, output.Stderr())
+	}
+
+	state := testStateRead(t, statePath)
+	is := state.ResourceInstance(addrs.RootModuleInstance.ResourceInstance(
+		addrs.ManagedResourceMode, "test_instance", "foo", addrs.NoKey,
+	))
+	if is == nil || is.Current == nil {
+		t.Fatal("imported resource instance not found in state")
+	}
+	want := addrs.RootModule.Resource(addrs.ManagedResourceMode, "test_instance", "bar")
+	if deps := is.Current.Dependencies; len(deps) != 1 || !deps[0].Equal(want) {
+		t.Errorf("wrong dependencies %s; want [%s]", deps, want)
+	}
+}
+
+func TestImport_dependsOnMissing(t *testing.T) {
+	t.Chdir(testFixturePath("import-provider"))
+
+	p := testImportProvider()
+	view, done := testView(t)
+	c := &ImportCommand{
+		Meta: Meta{
+			WorkingDir:       workdir.NewDir("."),
+			testingOverrides: metaOverridesForProvider(p),
+			View:             view,
+		},
+	}
+
+	args := []string{
+		"-state", testTempFile(t),
+		"-depends-on", "test_instance.missing",
+		"test_instance.foo",
+		"bar",
+	}
+	code := c.Run(args)
+	output := done(t)
+	if code != 1 {
+		t.Fatalf("unexpected success: %d\n\n%s", code, output.Stdout())
+	}
+	if got, want := output.Stderr(), "test_instance.missing is not defined in the configuration"; !strings.Contains(got, want) {
+		t.Errorf("missing expected error\ngot: %s\nwant: %s", got, want)
+	}
+	if p.ImportResourceStateCalled {
+		t.Error("provider was asked to import despite the invalid dependency")
+	}
+}
+
+func TestImport_moveTo(t *testing.T) {
+	t.Chdir(testFixturePath("import-depends-on"))
+
+	statePath := testTempFile(t)
+
+	p := testImportProvider()
+	view, done := testView(t)
+	c := &ImportCommand{
+		Meta: Meta{
+			WorkingDir:       workdir.NewDir("."),
+			testingOverrides: metaOverridesForProvider(p),
+			View:             view,
+		},
+	}
+
+	args := []string{
+		"-state", statePath,
+		"-move-to", "test_instance.bar",
+		"test_instance.foo",
+		"bar",
+	}
+	code := c.Run(args)
+	output := done(t)
+	if code != 0 {
+		t.Fatalf("bad: %d\n\n%s", code, output.Stderr())
+	}
+
+	state := testStateRead(t, statePath)
+	foo := addrs.RootModuleInstance.ResourceInstance(addrs.ManagedResourceMode, "test_instance", "foo", addrs.NoKey)
+	bar := addrs.RootModuleInstance.ResourceInstance(addrs.ManagedResourceMode, "test_instance", "bar", addrs.NoKey)
+	if state.ResourceInstance(foo) != nil {
+		t.Errorf("%s is still in the state", foo)
+	}
+	if is := state.ResourceInstance(bar); is == nil || is.Current == nil {
+		t.Errorf("%s is not in the state", bar)
+	}
+}
+
+func TestImport_readOnly(t *testing.T) {
+	t.Chdir(testFixturePath("import-provider"))
+
+	statePath := testTempFile(t)
+
+	p := testImportProvider()
+	p.ImportResourceStateResponse.ImportedResources[0].State = cty.ObjectVal(map[string]cty.Value{
+		"id":       cty.StringVal("yay"),
+		"password": cty.StringVal("hunter2"),
+	})
+	p.GetProviderSchemaResponse.ResourceTypes["test_instance"].Block.Attributes["password"] = &configschema.Attribute{
+		Type:      cty.String,
+		Computed:  true,
+		Sensitive: true,
+	}
+	view, done := testView(t)
+	c := &ImportCommand{
+		Meta: Meta{
+			WorkingDir:       workdir.NewDir("."),
+			testingOverrides: metaOverridesForProvider(p),
+			View:             view,
+		},
+	}
+
+	args := []string{
+		"-state", statePath,
+		"-read-only",
+		"test_instance.foo",
+		"bar",
+	}
+	code := c.Run(args)
+	output := done(t)
+	if code != 0 {
+		t.Fatalf("bad: %d\n\n%s", code, output.Stderr())
+	}
+	if !p.ImportResourceStateCalled {
+		t.Fatal("ImportResourceState should be called")
+	}
+
+	stdout := output.Stdout()
+	if !strings.Contains(stdout, `"yay"`) {
+		t.Errorf("imported object not shown\n%s", stdout)
+	}
+	if strings.Contains(stdout, "hunter2") {
+		t.Errorf("sensitive attribute not redacted\n%s", stdout)
+	}
+	if !strings.Contains(stdout, "nothing was persisted") {
+		t.Errorf("missing read-only message\n%s", stdout)
+	}
+	if _, err := os.Stat(statePath); !os.IsNotExist(err) {
+		t.Errorf("state was written to %s despite -read-only", statePath)
+	}
+}
+
+func TestImport_readOnlyFailure(t *testing.T) {
+	t.Chdir(testFixturePath("import-provider"))
+
+	statePath := testTempFile(t)
+
+	p := testImportProvider()
+	// The mock provider ignores the diagnostics of a canned response, so
+	// the error must come from the function instead.
+	p.ImportResourceStateFn = func(_ providers.ImportResourceStateRequest) providers.ImportResourceStateResponse {
+		return providers.ImportResourceStateResponse{
+			Diagnostics: tfdiags.Diagnostics{}.Append(fmt.Errorf("no object with ID %q", "bar")),
+		}
+	}
+	view, done := testView(t)
+	c := &ImportCommand{
+		Meta: Meta{
+			WorkingDir:       workdir.NewDir("."),
+			testingOverrides: metaOverridesForProvider(p),
+			View:             view,
+		},
+	}
+
+	args := []string{
+		"-no-color",
+		"-state", statePath,
+		"-dry-run",
+		"test_instance.foo",
+		"bar",
+	}
+	code := c.Run(args)
+	output := done(t)
+	if code != 1 {
+		t.Fatalf("import succeeded; expected failure\n\n%s", output.Stdout())
+	}
+	if got, want := strings.ReplaceAll(output.Stderr(), "\n", " "), `no object with ID "bar"`; !strings.Contains(got, want) {
+		t.Errorf("missing expected error\ngot: %s\nwant substring: %s", got, want)
+	}
+	if strings.Contains(output.Stdout(), "nothing was persisted") {
+		t.Errorf("read-only result shown despite the failed import\n%s", output.Stdout())
+	}
+	if _, err := os.Stat(statePath); !os.IsNotExist(err) {
+		t.Errorf("state was written to %s despite -read-only", statePath)
+	}
+}
+
+func TestImport_readOnlyIDSensitive(t *testing.T) {
+	t.Chdir(testFixturePath("import-provider"))
+
+	statePath := testTempFile(t)
+
+	p := testImportProvider()
+	view, done := testView(t)
+	c := &ImportCommand{
+		Meta: Meta{
+			WorkingDir:       workdir.NewDir("."),
+			testingOverrides: metaOverridesForProvider(p),
+			View:             view,
+		},
+	}
+
+	args := []string{
+		"-state", statePath,
+		"-read-only",
+		"-id-sensitive",
+		"test_instance.foo",
+		"yay",
+	}
+	code := c.Run(args)
+	output := done(t)
+	if code != 0 {
+		t.Fatalf("bad: %d\n\n%s", code, output.Stderr())
+	}
+	if stdout := output.Stdout(); strings.Contains(stdout, "yay") {
+		t.Errorf("id attribute not redacted\n%s", stdout)
+	}
+}
+
+func TestImport_schemaVersion(t *testing.T) {
+	tests := map[string]struct {
+		version     string
+		wantCode    int
+		wantVersion uint64
+		wantOutput  string
+	}{
+		"older version": {
+			version:     "1",
+			wantVersion: 1,
+			wantOutput:  "Schema version overridden",
+		},
+		"newer version": {
+			version:    "5",
+			wantCode:   1,
+			wantOutput: "cannot upgrade an object from the newer version 5",
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Chdir(testFixturePath("import-provider"))
+
+			statePath := testTempFile(t)
+
+			p := testImportProvider()
+			schema := p.GetProviderSchemaResponse.ResourceTypes["test_instance"]
+			schema.Version = 3
+			p.GetProviderSchemaResponse.ResourceTypes["test_instance"] = schema
+			view, done := testView(t)
+			c := &ImportCommand{
+				Meta: Meta{
+					WorkingDir:       workdir.NewDir("."),
+					testingOverrides: metaOverridesForProvider(p),
+					View:             view,
+				},
+			}
+
+			args := []string{
+				"-state", statePath,
+				"-no-color",
+				"-schema-version", test.version,
+				"test_instance.foo",
+				"bar",
+			}
+			code := c.Run(args)
+			output := done(t)
+			if code != test.wantCode {
+				t.Fatalf("wrong exit code %d; want %d\n\n%s", code, test.wantCode, output.Stderr())
+			}
+			// Warnings are written to stdout, and errors to stderr.
+			if got := strings.ReplaceAll(output.All(), "\n", " "); !strings.Contains(got, test.wantOutput) {
+				t.Errorf("missing %q in output\n%s", test.wantOutput, output.All())
+			}
+			if test.wantCode != 0 {
+				return
+			}
+
+			state := testStateRead(t, statePath)
+			is := state.ResourceInstance(addrs.RootModuleInstance.ResourceInstance(
+				addrs.ManagedResourceMode, "test_instance", "foo", addrs.NoKey,
+			))
+			if is == nil || is.Current == nil {
+				t.Fatal("imported resource instance not found in state")
+			}
+			if got := is.Current.SchemaVersion; got != test.wantVersion {
+				t.Errorf("wrong schema version %d; want %d", got, test.wantVersion)
+			}
+		})
+	}
+}
+
+func TestImport_againstPlan(t *testing.T) {
+	tests := map[string]struct {
+		planAddr string
+		planID   string
+		wantCode int
+		wantErr  string
+	}{
+		"matching import": {
+			planAddr: "foo",
+			planID:   "bar",
+		},
+		"different ID": {
+			planAddr: "foo",
+			planID:   "baz",
+			wantCode: 1,
+			wantErr:  `imports an object to test_instance.foo with ID "baz", not "bar"`,
+		},
+		"different address": {
+			planAddr: "other",
+			planID:   "bar",
+			wantCode: 1,
+			wantErr:  "does not import an object to test_instance.foo",
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, snap := testModuleWithSnapshot(t, "import-provider")
+			val := cty.ObjectVal(map[string]cty.Value{
+				"id": cty.StringVal(test.planID),
+			})
+			valRaw, err := plans.NewDynamicValue(val, val.Type())
+			if err != nil {
+				t.Fatal(err)
+			}
+			plan := testPlan(t)
+			plan.Changes.SyncWrapper().AppendResourceInstanceChange(&plans.ResourceInstanceChangeSrc{
+				Addr: addrs.Resource{
+					Mode: addrs.ManagedResourceMode,
+					Type: "test_instance",
+					Name: test.planAddr,
+				}.Instance(addrs.NoKey).Absolute(addrs.RootModuleInstance),
+				ProviderAddr: addrs.AbsProviderConfig{
+					Provider: addrs.NewDefaultProvider("test"),
+					Module:   addrs.RootModule,
+				},
+				ChangeSrc: plans.ChangeSrc{
+					Action:    plans.NoOp,
+					Before:    valRaw,
+					After:     valRaw,
+					Importing: &plans.ImportingSrc{ID: test.planID},
+				},
+			})
+			planPath := testPlanFile(t, snap, states.NewState(), plan)
+
+			t.Chdir(testFixturePath("import-provider"))
+
+			statePath := testTempFile(t)
+
+			p := testImportProvider()
+			view, done := testView(t)
+			c := &ImportCommand{
+				Meta: Meta{
+					WorkingDir:       workdir.NewDir("."),
+					testingOverrides: metaOverridesForProvider(p),
+					View:             view,
+				},
+			}
+
+			args := []string{
+				"-no-color",
+				"-state", statePath,
+				"-against-plan", planPath,
+				"test_instance.foo",
+				"bar",
+			}
+			code := c.Run(args)
+			output := done(t)
+			if code != test.wantCode {
+				t.Fatalf("wrong exit code %d; want %d\n\n%s", code, test.wantCode, output.Stderr())
+			}
+			if test.wantCode == 0 {
+				testStateOutput(t, statePath, testImportStr)
+				return
+			}
+			if got := strings.ReplaceAll(output.Stderr(), "\n", " "); !strings.Contains(got, test.wantErr) {
+				t.Errorf("missing %q in output\n%s", test.wantErr, output.Stderr())
+			}
+			if p.ImportResourceStateCalled {
+				t.Error("ImportResourceState should not be called")
+			}
+		})
+	}
+}
+
+func TestImport_unreadableState(t *testing.T) {
+	t.Chdir(testFixturePath("import-provider"))
+
+	statePath := testTempFile(t)
+	if err := os.WriteFile(statePath, []byte("not a state file"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	p := testImportProvider()
+	view, done := testView(t)
+	c := &ImportCommand{
+		Meta: Meta{
+			WorkingDir:       workdir.NewDir("."),
+			testingOverrides: metaOverridesForProvider(p),
+			View:             view,
+		},
+	}
+
+	args := []string{
+		"-state", statePath,
+		"test_instance.foo",
+		"bar",
+	}
+	code := c.Run(args)
+	output := done(t)
+	if code != 1 {
+		t.Fatalf("unexpected success\n%s", output.Stdout())
+	}
+	if got, want := output.Stderr(), "Backend unreachable"; !strings.Contains(got, want) {
+		t.Errorf("missing %q in output\n%s", want, got)
+	}
+	if p.ImportResourceStateCalled {
+		t.Error("ImportResourceState should not be called")
+	}
+}
+
+func TestImport_moveToInvalid(t *testing.T) {
+	tests := map[string]struct {
+		moveTo  string
+		wantErr string
+	}{
+		"not configured": {
+			"test_instance.missing",
+			"test_instance.missing is not defined in the configuration",
+		},
+		"different type": {
+			"other_instance.bar",
+			`The imported object is of type "test_instance"`,
+		},
+		"same address": {
+			"test_instance.foo",
+			"The -move-to address is the same as the import address",
+		},
+		"data resource": {
+			"data.test_data_source.bar",
+			"A managed resource address is required",
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Chdir(testFixturePath("import-depends-on"))
+
+			p := testImportProvider()
+			view, done := testView(t)
+			c := &ImportCommand{
+				Meta: Meta{
+					WorkingDir:       workdir.NewDir("."),
+					testingOverrides: metaOverridesForProvider(p),
+					View:             view,
+				},
+			}
+
+			args := []string{
+				"-state", testTempFile(t),
+				"-move-to", test.moveTo,
+				"test_instance.foo",
+				"bar",
+			}
+			code := c.Run(args)
+			output := done(t)
+			if code != 1 {
+				t.Fatalf("unexpected success: %d\n\n%s", code, output.Stdout())
+			}
+			if got := output.Stderr(); !strings.Contains(got, test.wantErr) {
+				t.Errorf("missing expected error\ngot: %s\nwant: %s", got, test.wantErr)
+			}
+			if p.ImportResourceStateCalled {
+				t.Error("provider was asked to import despite the invalid -move-to address")
+			}
+		})
+	}
+}
+
+func TestImport_instanceKeyInvalid(t *testing.T) {
+	tests := map[string]struct {
+		addr    string
+		wantErr string
+	}{
+		"key without count or for_each": {
+			"test_instance.single[0]",
+			"Remove the instance key to import to test_instance.single",
+		},
+		"count without index": {
+			"test_instance.counted",
+			"an instance index is required to import to one of its instances, such as test_instance.counted[0]",
+		},
+		"for_each without key": {
+			"test_instance.each",
+			"an instance key is required to import to one of its instances",
+		},
+		"count with string key": {
+			`test_instance.counted["a"]`,
+			"its instances are identified by integer indices",
+		},
+		"for_each with index": {
+			"test_instance.each[0]",
+			"its instances are identified by string keys",
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Chdir(testFixturePath("import-instance-key"))
+
+			p := testImportProvider()
+			view, done := testView(t)
+			c := &ImportCommand{
+				Meta: Meta{
+					WorkingDir:       workdir.NewDir("."),
+					testingOverrides: metaOverridesForProvider(p),
+					View:             view,
+				},
+			}
+
+			args := []string{
+				"-no-color",
+				"-state", testTempFile(t),
+				test.addr,
+				"bar",
+			}
+			code := c.Run(args)
+			output := done(t)
+			if code != 1 {
+				t.Fatalf("unexpected success: %d\n\n%s", code, output.Stdout())
+			}
+			if got := strings.ReplaceAll(output.Stderr(), "\n", " "); !strings.Contains(got, test.wantErr) {
+				t.Errorf("missing expected error\ngot: %s\nwant: %s", got, test.wantErr)
+			}
+			if p.ImportResourceStateCalled {
+				t.Error("provider was asked to import despite the invalid instance key")
+			}
+		})
+	}
+}
+
+func TestImport_unquotedInstanceKey(t *testing.T) {
+	t.Chdir(testFixturePath("import-instance-key"))
+
+	p := testImportProvider()
+	view, done := testView(t)
+	c := &ImportCommand{
+		Meta: Meta{
+			WorkingDir:       workdir.NewDir("."),
+			testingOverrides: metaOverridesForProvider(p),
+			View:             view,
+		},
+	}
+
+	// This is what the address looks like after a shell has removed the
+	// double quotes from test_instance.each["a/b"].
+	args := []string{
+		"-no-color",
+		"-state", testTempFile(t),
+		"test_instance.each[a/b]",
+		"bar",
+	}
+	code := c.Run(args)
+	output := done(t)
+	if code != 1 {
+		t.Fatalf("unexpected success: %d\n\n%s", code, output.Stdout())
+	}
+	if want := "String instance keys must be quoted"; !strings.Contains(output.Stderr(), want) {
+		t.Errorf("missing expected error\ngot: %s\nwant: %s", output.Stderr(), want)
+	}
+}
+
+func TestHasUnquotedInstanceKey(t *testing.T) {
+	tests := map[string]bool{
+		`test_instance.foo`:              false,
+		`test_instance.foo[0]`:           false,
+		`test_instance.foo["a/b"]`:       false,
+		`module.a["x"].test_instance.b`:  false,
+		`test_instance.foo[a/b]`:         true,
+		`module.a[x].test_instance.b`:    true,
+		`module.a[0].test_instance.b[c]`: true,
+		`test_instance.foo[`:             false,
+	}
+	for addr, want := range tests {
+		if got := hasUnquotedInstanceKey(addr); got != want {
+			t.Errorf("wrong result for %s: got %t, want %t", addr, got, want)
+		}
+	}
+}
+
+func TestImport_providerVar(t *testing.T) {
+	t.Chdir(testFixturePath("import-provider"))
+
+	statePath := testTempFile(t)
+
+	p := testImportProvider()
+	p.GetProviderSchemaResponse.Provider = providers.Schema{
+		Block: &configschema.Block{
+			Attributes: map[string]*configschema.Attribute{
+				"foo": {Type: cty.String, Optional: true},
+			},
+		},
+	}
+	var gotFoo cty.Value
+	p.ConfigureProviderFn = func(req providers.ConfigureProviderRequest) providers.ConfigureProviderResponse {
+		gotFoo = req.Config.GetAttr("foo")
+		return providers.ConfigureProviderResponse{}
+	}
+
+	view, done := testView(t)
+	c := &ImportCommand{
+		Meta: Meta{
+			WorkingDir:       workdir.NewDir("."),
+			testingOverrides: metaOverridesForProvider(p),
+			View:             view,
+		},
+	}
+
+	args := []string{
+		"-state", statePath,
+		"-provider-var", "test.foo=overridden",
+		"test_instance.foo",
+		"bar",
+	}
+	code := c.Run(args)
+	output := done(t)
+	if code != 0 {
+		t.Fatalf("bad: %d\n\n%s", code, output.Stderr())
+	}
+	if want := cty.StringVal("overridden"); !want.RawEquals(gotFoo) {
+		t.Errorf("wrong provider configuration foo %#v; want %#v", gotFoo, want)
+	}
+	testStateOutput(t, statePath, testImportStr)
+}
+
+func TestImport_providerVarInvalid(t *testing.T) {
+	tests := map[string]string{
+		"no argument name":     "test=overridden",
+		"undeclared provider":  "other.foo=overridden",
+		"unsupported argument": "test.nope=overridden",
+		"missing value":        "test.foo",
+	}
+	for name, arg := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Chdir(testFixturePath("import-provider"))
+
+			p := testImportProvider()
+			view, done := testView(t)
+			c := &ImportCommand{
+				Meta: Meta{
+					WorkingDir:       workdir.NewDir("."),
+					testingOverrides: metaOverridesForProvider(p),
+					View:             view,
+				},
+			}
+
+			args := []string{
+				"-state", testTempFile(t),
+				"-provider-var", arg,
+				"test_instance.foo",
+				"bar",
+			}
+			code := c.Run(args)
+			output := done(t)
+			if code != 1 {
+				t.Fatalf("unexpected success: %d\n\n%s", code, output.Stdout())
+			}
+			if got, want := output.Stderr(), "Invalid provider configuration override"; !strings.Contains(got, want) {
+				t.Errorf("missing expected error\ngot: %s\nwant substring: %s", got, want)
+			}
+			if p.ImportResourceStateCalled {
+				t.Error("ImportResourceState should not be called")
+			}
+		})
+	}
+}
+
+// testImportProvider returns a test provider prepared to import a single
+// "test_instance" object with the id "yay".
+// compactJSON returns the given JSON with insignificant whitespace removed.
+func compactJSON(t *testing.T, src []byte) string {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, src); err != nil {
+		t.Fatalf("invalid JSON: %s\n%s", err, src)
+	}
+	return buf.String()
+}
+
+func testImportProvider() *tofu.MockProvider {
+	p := testProvider()
+	p.ImportResourceStateFn = nil
+	p.ImportResourceStateResponse = &providers.ImportResourceStateResponse{
+		ImportedResources: []providers.ImportedResource{
+			{
+				TypeName: "test_instance",
+				State: cty.ObjectVal(map[string]cty.Value{
+					"id": cty.StringVal("yay"),
+				}),
+			},
+		},
+	}
+	p.GetProviderSchemaResponse = &providers.GetProviderSchemaResponse{
+		Provider: providers.Schema{
+			Block: &configschema.Block{
+				Attributes: map[string]*configschema.Attribute{
+					"foo": {Type: cty.String, Optional: true},
+				},
+			},
+		},
+		ResourceTypes: map[string]providers.Schema{
+			"test_instance": {
+				Block: &configschema.Block{
+					Attributes: map[string]*configschema.Attribute{
+						"id": {Type: cty.String, Optional: true, Computed: true},
+					},
+				},
+			},
+		},
+	}
+	return p
+}
+
 const testImportStr = `
 test_instance.foo:
   ID = yay
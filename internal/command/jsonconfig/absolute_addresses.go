@@ -0,0 +1,131 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+// Copyright (c) 2023 HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package jsonconfig
+
+import (
+	"github.com/opentofu/opentofu/internal/addrs"
+)
+
+// absolutizeModule rewrites the module-relative addresses in the given
+// module and in all of its descendant modules to be qualified by the path of
+// the module they appear in, for [MarshalOpts.AbsoluteAddresses]. The prefix
+// is the path of the given module followed by a period, or empty for the
+// root module, in which relative and absolute addresses are the same.
+//
+// Provider configuration keys are not rewritten because they are already
+// qualified by module path.
+func absolutizeModule(m *module, prefix string) {
+	if m == nil {
+		return
+	}
+
+	if prefix != "" {
+		for i := range m.Resources {
+			r := &m.Resources[i]
+			r.Address = prefix + r.Address
+			absolutizeAddrs(r.DependsOn, prefix)
+			absolutizeAddrs(r.ReferencedResources, prefix)
+			absolutizeExpressions(r.CountExpression, prefix)
+			absolutizeExpressions(r.ForEachExpression, prefix)
+			absolutizeExpressions(r.Expressions, prefix)
+			for _, p := range r.Provisioners {
+				absolutizeExpressions(p.Expressions, prefix)
+				absolutizeExpressions(p.Connection, prefix)
+			}
+			absolutizeCheckRules(r.Preconditions, prefix)
+			absolutizeCheckRules(r.Postconditions, prefix)
+			if r.Lifecycle != nil {
+				absolutizeReferences(r.Lifecycle.ReplaceTriggeredBy, prefix)
+			}
+		}
+		for _, o := range m.Outputs {
+			absolutizeAddrs(o.DependsOn, prefix)
+			absolutizeExpressions(o.Expression, prefix)
+			absolutizeCheckRules(o.Preconditions, prefix)
+		}
+		for _, v := range m.Variables {
+			absolutizeCheckRules(v.Validations, prefix)
+		}
+	}
+
+	for name, mc := range m.ModuleCalls {
+		if prefix != "" {
+			absolutizeAddrs(mc.DependsOn, prefix)
+			absolutizeExpressions(mc.CountExpression, prefix)
+			absolutizeExpressions(mc.ForEachExpression, prefix)
+			absolutizeExpressions(mc.Expressions, prefix)
+			for _, refs := range mc.VariableBindings {
+				absolutizeReferences(refs, prefix)
+			}
+			for i := range mc.Instances {
+				mc.Instances[i].Address = prefix + mc.Instances[i].Address
+			}
+		}
+		absolutizeModule(mc.Module, prefix+addrs.ModuleCall{Name: name}.String()+".")
+	}
+}
+
+// absolutizeProviderConfigs rewrites the references in the expressions of
+// the given provider configurations to be qualified by the path of the
+// module each configuration belongs to.
+func absolutizeProviderConfigs(pcs map[string]providerConfig) {
+	for _, pc := range pcs {
+		if pc.ModuleAddress == "" {
+			continue
+		}
+		absolutizeExpressions(pc.Expressions, pc.ModuleAddress+".")
+	}
+}
+
+func absolutizeCheckRules(rules []checkRule, prefix string) {
+	for i := range rules {
+		absolutizeExpressions(rules[i].Condition, prefix)
+		absolutizeExpressions(rules[i].ErrorMessage, prefix)
+	}
+}
+
+// absolutizeExpressions rewrites the references of all of the expressions
+// in v, which can be anything accepted by [walkExpressions]. The references
+// are updated in place, so this also affects any copies of the expressions.
+func absolutizeExpressions(v any, prefix string) {
+	walkExpressions(v, func(expr expression) {
+		absolutizeReferences(expr.References, prefix)
+	})
+}
+
+// absolutizeReferences adds the given prefix to each of the given reference
+// strings that refers to an object declared in a module. References to
+// values that are only meaningful within a particular block, like
+// "count.index" and "self", and to the values in the "path" and "terraform"
+// namespaces are left unchanged.
+func absolutizeReferences(refs []string, prefix string) {
+	for i, refStr := range refs {
+		ref, diags := addrs.ParseRefStr(refStr)
+		if diags.HasErrors() {
+			// Should not happen, because these strings were produced from
+			// valid references in the first place.
+			continue
+		}
+		if ref.Subject == addrs.Self {
+			continue
+		}
+		switch ref.Subject.(type) {
+		case addrs.CountAttr, addrs.ForEachAttr, addrs.PathAttr, addrs.TerraformAttr:
+			continue
+		}
+		refs[i] = prefix + refStr
+	}
+}
+
+// absolutizeAddrs adds the given prefix to each of the given addresses, such
+// as those in "depends_on", leaving any empty entries unchanged.
+func absolutizeAddrs(list []string, prefix string) {
+	for i, addr := range list {
+		if addr != "" {
+			list[i] = prefix + addr
+		}
+	}
+}
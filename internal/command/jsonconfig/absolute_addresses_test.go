@@ -0,0 +1,126 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+// Copyright (c) 2023 HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package jsonconfig
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestAbsolutizeModule(t *testing.T) {
+	child := &module{
+		Resources: []resource{
+			{
+				Address:   "test_thing.a",
+				DependsOn: []string{"test_thing.b"},
+				Expressions: map[string]any{
+					"name": expression{References: []string{"var.name", "count.index", "path.module", "self"}},
+				},
+				ReferencedResources: []string{"test_thing.c"},
+			},
+		},
+		Outputs: map[string]output{
+			"id": {Expression: &expression{References: []string{"module.b.id", "module.b"}}},
+		},
+		ModuleCalls: map[string]moduleCall{
+			"b": {
+				Expressions:      map[string]any{"name": expression{References: []string{"local.name"}}},
+				VariableBindings: map[string][]string{"name": {"local.name"}},
+				Instances:        []moduleCallInstance{{Key: 0, Address: "module.b[0]", Module: "module.a.module.b"}},
+				Module: &module{
+					Resources: []resource{{Address: "test_thing.d"}},
+				},
+			},
+		},
+	}
+	root := &module{
+		Resources: []resource{
+			{
+				Address:     "test_thing.root",
+				Expressions: map[string]any{"name": expression{References: []string{"module.a.id", "module.a"}}},
+			},
+		},
+		ModuleCalls: map[string]moduleCall{
+			"a": {
+				DependsOn: []string{"test_thing.root"},
+				Module:    child,
+			},
+		},
+	}
+
+	absolutizeModule(root, "")
+
+	want := &module{
+		Resources: []resource{
+			{
+				Address:     "test_thing.root",
+				Expressions: map[string]any{"name": expression{References: []string{"module.a.id", "module.a"}}},
+			},
+		},
+		ModuleCalls: map[string]moduleCall{
+			"a": {
+				DependsOn: []string{"test_thing.root"},
+				Module: &module{
+					Resources: []resource{
+						{
+							Address:   "module.a.test_thing.a",
+							DependsOn: []string{"module.a.test_thing.b"},
+							Expressions: map[string]any{
+								"name": expression{References: []string{"module.a.var.name", "count.index", "path.module", "self"}},
+							},
+							ReferencedResources: []string{"module.a.test_thing.c"},
+						},
+					},
+					Outputs: map[string]output{
+						"id": {Expression: &expression{References: []string{"module.a.module.b.id", "module.a.module.b"}}},
+					},
+					ModuleCalls: map[string]moduleCall{
+						"b": {
+							Expressions:      map[string]any{"name": expression{References: []string{"module.a.local.name"}}},
+							VariableBindings: map[string][]string{"name": {"module.a.local.name"}},
+							Instances:        []moduleCallInstance{{Key: 0, Address: "module.a.module.b[0]", Module: "module.a.module.b"}},
+							Module: &module{
+								Resources: []resource{{Address: "module.a.module.b.test_thing.d"}},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	if diff := cmp.Diff(want, root); diff != "" {
+		t.Errorf("wrong result\n%s", diff)
+	}
+}
+
+func TestAbsolutizeProviderConfigs(t *testing.T) {
+	pcs := map[string]providerConfig{
+		"test": {
+			Expressions: map[string]any{"region": expression{References: []string{"var.region"}}},
+		},
+		"module.a:test": {
+			ModuleAddress: "module.a",
+			Expressions:   map[string]any{"region": expression{References: []string{"var.region"}}},
+		},
+	}
+
+	absolutizeProviderConfigs(pcs)
+
+	want := map[string]providerConfig{
+		"test": {
+			Expressions: map[string]any{"region": expression{References: []string{"var.region"}}},
+		},
+		"module.a:test": {
+			ModuleAddress: "module.a",
+			Expressions:   map[string]any{"region": expression{References: []string{"module.a.var.region"}}},
+		},
+	}
+	if diff := cmp.Diff(want, pcs, cmpopts.IgnoreUnexported(providerConfig{})); diff != "" {
+		t.Errorf("wrong result\n%s", diff)
+	}
+}
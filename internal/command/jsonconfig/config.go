@@ -6,17 +6,32 @@
 package jsonconfig
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
+	"maps"
+	"path/filepath"
+	"slices"
 	"sort"
+	"strings"
 
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
 	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+	"github.com/zclconf/go-cty/cty/gocty"
 	ctyjson "github.com/zclconf/go-cty/cty/json"
 
 	"github.com/opentofu/opentofu/internal/addrs"
+	"github.com/opentofu/opentofu/internal/communicator/shared"
 	"github.com/opentofu/opentofu/internal/configs"
 	"github.com/opentofu/opentofu/internal/configs/configschema"
+	"github.com/opentofu/opentofu/internal/experiments"
 	"github.com/opentofu/opentofu/internal/getproviders"
+	"github.com/opentofu/opentofu/internal/lang"
 	"github.com/opentofu/opentofu/internal/tofu"
 )
 
@@ -24,6 +39,38 @@ import (
 type config struct {
 	ProviderConfigs map[string]providerConfig `json:"provider_config,omitempty"`
 	RootModule      module                    `json:"root_module,omitempty"`
+
+	// RecursiveModuleSources is an advisory list of the addresses of module
+	// calls whose source is the same as that of one of their ancestors.
+	RecursiveModuleSources []string `json:"recursive_module_sources,omitempty"`
+
+	// ProviderConstraintConflicts is an advisory list of the providers for
+	// which the version constraints declared in different modules have no
+	// version in common.
+	ProviderConstraintConflicts []providerConstraintConflict `json:"provider_constraint_conflicts,omitempty"`
+
+	// UnresolvedProviderReferences is an advisory list of the resources
+	// whose "provider" argument refers to an alternate provider
+	// configuration that isn't available in their module.
+	UnresolvedProviderReferences []unresolvedProviderReference `json:"unresolved_provider_references,omitempty"`
+
+	// FunctionsUsed is the sorted list of distinct names of the functions
+	// called in any of the expressions in the configuration. This is
+	// populated only when [MarshalOpts.FunctionsUsed] is set.
+	FunctionsUsed []string `json:"functions_used,omitempty"`
+
+	// SensitivitySummary collects the sensitive variables, outputs,
+	// resource arguments, and provisioner connections from throughout the
+	// configuration. This is populated only when
+	// [MarshalOpts.SensitivitySummary] is set.
+	SensitivitySummary *sensitivitySummary `json:"sensitivity_summary,omitempty"`
+
+	// UnusedProviderConfigs is the sorted list of the keys in
+	// ProviderConfigs of the provider configuration blocks that are not
+	// used by any resource, passed to any module call, or needed for their
+	// provider-defined functions. This is populated only when
+	// [MarshalOpts.UnusedProviderConfigs] is set.
+	UnusedProviderConfigs []string `json:"unused_provider_configs,omitempty"`
 }
 
 // ProviderConfig describes all of the provider configurations throughout the
@@ -37,7 +84,44 @@ type providerConfig struct {
 	VersionConstraint string         `json:"version_constraint,omitempty"`
 	ModuleAddress     string         `json:"module_address,omitempty"`
 	Expressions       map[string]any `json:"expressions,omitempty"`
-	parentKey         string
+
+	// RequiredForFunctions is set for provider requirements that have no
+	// configuration block and are not used by any resource or module call in
+	// their module, but whose provider-defined functions are called there.
+	RequiredForFunctions bool `json:"required_for_functions,omitempty"`
+
+	// RequirementOnly is set for providers that are declared in the
+	// required_providers block of their module but have no configuration
+	// block there. Such a provider either uses a configuration passed from
+	// the parent module or, in the root module, an empty configuration.
+	RequirementOnly bool `json:"requirement_only,omitempty"`
+
+	// EmptyConfig is set for provider configuration blocks that set no
+	// arguments, which therefore rely on the provider's defaults and on
+	// ambient configuration such as environment variables. This is not
+	// populated in single-module mode, or when the provider's schema is
+	// not available.
+	EmptyConfig bool `json:"empty_config,omitempty"`
+
+	// ConfigFingerprint is a hash of the references and non-sensitive
+	// constant values in Expressions, which is the same for configuration
+	// blocks with equivalent arguments in different modules. This is
+	// populated only for configuration blocks, and only when
+	// [MarshalOpts.ProviderConfigFingerprints] is set.
+	ConfigFingerprint string `json:"config_fingerprint,omitempty"`
+
+	// InheritanceDepth is the greatest number of module levels that this
+	// configuration is passed or inherited through before reaching a
+	// resource that uses it, or nil if no resource uses it. This is
+	// populated only when [MarshalOpts.ProviderInheritanceDepth] is set.
+	InheritanceDepth *int `json:"inheritance_depth,omitempty"`
+
+	parentKey string
+
+	// resolutionPath is the chain of keys from the configuration this one
+	// is passed or inherited from up to parentKey, as found by
+	// [sourceProviderKeyPath].
+	resolutionPath []string
 }
 
 type module struct {
@@ -47,6 +131,44 @@ type module struct {
 	Resources   []resource            `json:"resources,omitempty"`
 	ModuleCalls map[string]moduleCall `json:"module_calls,omitempty"`
 	Variables   variables             `json:"variables,omitempty"`
+
+	// Experiments lists the keywords of the language experiments that the
+	// module opts into, in lexical order. Experiments are module-scoped, so
+	// this describes only this module and not its descendants.
+	//
+	// This is populated from [configs.Module.ActiveExperiments], which is
+	// always empty while there are no current language experiments, and so
+	// this is currently always omitted.
+	Experiments []string `json:"experiments,omitempty"`
+
+	// Summary counts the objects declared directly in this module. This is
+	// populated only when [MarshalOpts.ModuleSummary] is set.
+	Summary *moduleSummary `json:"summary,omitempty"`
+
+	// Depth and Path are populated only when [MarshalOpts.ModuleInfo] is
+	// set, with Depth counting the module calls between the root module and
+	// this one. Path is empty for the root module.
+	Depth *int   `json:"depth,omitempty"`
+	Path  string `json:"path,omitempty"`
+
+	// ContentHash is populated only when [MarshalOpts.ContentHashes] or
+	// [MarshalOpts.BaseContentHashes] is set, as described there. Unchanged
+	// is set when the module's content hash matches the one given in
+	// [MarshalOpts.BaseContentHashes], in which case the module is
+	// represented only by its hash and its module calls.
+	ContentHash string `json:"content_hash,omitempty"`
+	Unchanged   bool   `json:"unchanged,omitempty"`
+}
+
+// moduleSummary counts the objects declared in a module, excluding those in
+// its child modules.
+type moduleSummary struct {
+	ManagedResources   int `json:"managed_resources"`
+	DataResources      int `json:"data_resources"`
+	EphemeralResources int `json:"ephemeral_resources"`
+	Outputs            int `json:"outputs"`
+	Variables          int `json:"variables"`
+	ModuleCalls        int `json:"module_calls"`
 }
 
 type moduleCall struct {
@@ -54,9 +176,48 @@ type moduleCall struct {
 	Expressions       map[string]any `json:"expressions,omitempty"`
 	CountExpression   *expression    `json:"count_expression,omitempty"`
 	ForEachExpression *expression    `json:"for_each_expression,omitempty"`
+	ExpansionMode     string         `json:"expansion_mode,omitempty"`
 	Module            *module        `json:"module,omitempty"`
 	VersionConstraint string         `json:"version_constraint,omitempty"`
 	DependsOn         []string       `json:"depends_on,omitempty"`
+
+	// ProviderInheritance is "explicit" if the module call has a "providers"
+	// argument, or "inherited" if the child module instead inherits the
+	// default provider configurations from its parent.
+	ProviderInheritance string `json:"provider_inheritance,omitempty"`
+
+	// ProviderConfigKeys maps the name of each provider configuration in the
+	// child module that the "providers" argument passes, such as
+	// "aws.west", to the key of the provider configuration it resolves to
+	// in the top-level "provider_config" object.
+	ProviderConfigKeys map[string]string `json:"provider_config_keys,omitempty"`
+
+	// VariableBindings maps the name of each child module input variable
+	// whose argument refers to other objects to the references in that
+	// argument, taken from Expressions.
+	VariableBindings map[string][]string `json:"variable_bindings,omitempty"`
+
+	// Instances lists the instances of a module call that uses count or
+	// for_each with a constant value, all of which share the content
+	// described by Module. This is populated only when
+	// [MarshalOpts.ModuleCallInstances] is set, and omitted when the
+	// instance keys can't be determined without evaluating other objects.
+	Instances []moduleCallInstance `json:"instances,omitempty"`
+}
+
+// moduleCallInstance describes one instance of a module call.
+type moduleCallInstance struct {
+	// Key is the instance key, which is a number for count or a string for
+	// for_each.
+	Key any `json:"key"`
+
+	// Address is the address of the instance relative to the module that
+	// contains the call, such as "module.a[0]".
+	Address string `json:"address"`
+
+	// Module is the path of the module whose content applies to this
+	// instance, such as "module.a", which is the same for every instance.
+	Module string `json:"module"`
 }
 
 // variables is the JSON representation of the variables provided to the current
@@ -71,6 +232,21 @@ type variable struct {
 	Sensitive   bool            `json:"sensitive,omitempty"`
 	Ephemeral   bool            `json:"ephemeral,omitempty"`
 	Deprecated  string          `json:"deprecated,omitempty"`
+
+	// Nullable is the value of the variable's "nullable" argument, or nil
+	// if it isn't set, in which case the variable is nullable.
+	Nullable *bool `json:"nullable,omitempty"`
+
+	// TypeKind is "primitive", "collection", "structural", or "any",
+	// classifying the variable's type constraint, and TypeDepth is how many
+	// levels of collection and structural types the constraint nests. These
+	// are populated only when [MarshalOpts.TypeInfo] is set.
+	TypeKind  string `json:"type_kind,omitempty"`
+	TypeDepth *int   `json:"type_depth,omitempty"`
+
+	// Validations describes the variable's "validation" blocks, in the
+	// order they are declared.
+	Validations []checkRule `json:"validations,omitempty"`
 }
 
 // Resource is the representation of a resource in the config
@@ -92,8 +268,19 @@ type resource struct {
 	// the ProviderConfigKey will not match a key in the ProviderConfigs map.
 	ProviderConfigKey string `json:"provider_config_key,omitempty"`
 
+	// ConfigKey identifies the resource's declaration, as described for
+	// [MarshalOpts.ConfigKeys]. This is populated only when that option is
+	// set.
+	ConfigKey string `json:"config_key,omitempty"`
+
+	// ProviderInferred is set if the resource has no "provider" argument,
+	// so that its provider configuration was inferred from the prefix of
+	// its type. This is populated only when [MarshalOpts.ProviderInferred]
+	// is set.
+	ProviderInferred bool `json:"provider_inferred,omitempty"`
+
 	// Provisioners is an optional field which describes any provisioners.
-	// Connection info will not be included here.
+	// Sensitive connection arguments will not be included here.
 	Provisioners []provisioner `json:"provisioners,omitempty"`
 
 	// Expressions" describes the resource-type-specific  content of the
@@ -110,7 +297,74 @@ type resource struct {
 	CountExpression   *expression `json:"count_expression,omitempty"`
 	ForEachExpression *expression `json:"for_each_expression,omitempty"`
 
+	// ExpansionMode is "count" or "for_each" if the corresponding
+	// meta-argument is set, or "single" otherwise.
+	ExpansionMode string `json:"expansion_mode,omitempty"`
+
 	DependsOn []string `json:"depends_on,omitempty"`
+
+	// IgnoreChanges describes the "ignore_changes" argument of the
+	// resource's lifecycle block in detail, if it is set. This is populated
+	// only for managed resources.
+	IgnoreChanges *ignoreChanges `json:"ignore_changes,omitempty"`
+
+	// Lifecycle describes the arguments of the resource's lifecycle block,
+	// if any are set. This is populated only for managed resources.
+	Lifecycle *lifecycle `json:"lifecycle,omitempty"`
+
+	// Preconditions and Postconditions describe the "precondition" and
+	// "postcondition" blocks of the resource's lifecycle block, in the order
+	// they are declared.
+	Preconditions  []checkRule `json:"preconditions,omitempty"`
+	Postconditions []checkRule `json:"postconditions,omitempty"`
+
+	// ReferencedResources lists the distinct addresses of the resources
+	// referred to by any of the expressions in this resource's
+	// configuration, representing its implicit dependencies. The addresses
+	// are relative to the module containing the resource, like references.
+	ReferencedResources []string `json:"referenced_resources,omitempty"`
+
+	// UsesDeprecatedAttributes lists the names of the top-level arguments set
+	// in this resource's configuration that the provider schema marks as
+	// deprecated.
+	UsesDeprecatedAttributes []string `json:"uses_deprecated_attributes,omitempty"`
+
+	// ModuleCallPath is the sequence of module call names leading from the
+	// root module to the module containing this resource, which is empty
+	// for resources in the root module. This is populated only when
+	// [MarshalOpts.ModuleInfo] is set.
+	ModuleCallPath []string `json:"module_call_path,omitempty"`
+
+	// EffectiveCreateBeforeDestroy is whether OpenTofu will use the
+	// create-before-destroy order when replacing instances of this managed
+	// resource, either because it is declared in the resource's lifecycle
+	// block or because a resource that depends on it declares it. This is
+	// populated only when [MarshalOpts.EffectiveCreateBeforeDestroy] is set.
+	EffectiveCreateBeforeDestroy *bool `json:"effective_create_before_destroy,omitempty"`
+
+	// Range is the source range of the whole resource block, from the start
+	// of its header to its closing brace. This is populated only when
+	// [MarshalOpts.SourceInfo] is set, and omitted for resources that are
+	// not declared in a configuration file.
+	Range *sourceRange `json:"range,omitempty"`
+
+	// ProviderConfig is the provider configuration that ProviderConfigKey
+	// refers to. This is populated only when
+	// [MarshalOpts.InlineProviderConfigs] is set.
+	ProviderConfig *providerConfig `json:"provider_config,omitempty"`
+
+	// ProviderResolutionPath is the chain of provider config keys followed
+	// from the one the resource's module refers to, through each module
+	// call it was passed or inherited across, to the key of the
+	// configuration that actually defines it. This is populated only when
+	// [MarshalOpts.ProviderResolutionPath] is set.
+	ProviderResolutionPath []string `json:"provider_resolution_path,omitempty"`
+
+	// FullyConstant is set if every expression in Expressions has a constant
+	// value, so that the resource's configuration doesn't depend on any
+	// other object. This is populated only when [MarshalOpts.FullyConstant]
+	// is set.
+	FullyConstant bool `json:"fully_constant,omitempty"`
 }
 
 type output struct {
@@ -120,16 +374,369 @@ type output struct {
 	Expression  *expression `json:"expression,omitempty"`
 	DependsOn   []string    `json:"depends_on,omitempty"`
 	Description string      `json:"description,omitempty"`
+
+	Preconditions []checkRule `json:"preconditions,omitempty"`
+
+	// ForwardsSensitiveOutput is set if the output's expression refers to a
+	// sensitive output value of a child module, or to a whole child module
+	// that has one, in which case the output must also be declared as
+	// sensitive.
+	ForwardsSensitiveOutput bool `json:"forwards_sensitive_output,omitempty"`
+}
+
+// lifecycle is the JSON representation of the arguments of a resource's
+// lifecycle block other than the custom condition blocks.
+type lifecycle struct {
+	// CreateBeforeDestroy is the value of "create_before_destroy", or nil if
+	// it isn't set.
+	CreateBeforeDestroy *bool `json:"create_before_destroy,omitempty"`
+
+	// PreventDestroy is the expression given for "prevent_destroy", or nil
+	// if it isn't set. Its value is always constant in valid configuration.
+	PreventDestroy *expression `json:"prevent_destroy,omitempty"`
+
+	// ReplaceTriggeredBy lists the references given in
+	// "replace_triggered_by", in the order they are declared.
+	ReplaceTriggeredBy []string `json:"replace_triggered_by,omitempty"`
+
+	// IgnoreChanges lists the attributes given in "ignore_changes" as
+	// references, such as tags["Name"], in the order they are declared, or
+	// is the single string "all" for the keyword all. The resource's
+	// top-level "ignore_changes" describes the same argument in more detail.
+	IgnoreChanges []string `json:"ignore_changes,omitempty"`
+}
+
+// ignoreChanges is the JSON representation of the "ignore_changes" argument
+// of a resource's lifecycle block.
+type ignoreChanges struct {
+	// All is set if the argument is the keyword "all", in which case
+	// Attributes is empty.
+	All bool `json:"all,omitempty"`
+
+	// Attributes describes each of the attributes whose changes are ignored,
+	// in the order they are listed.
+	Attributes []ignoredAttribute `json:"attributes,omitempty"`
+}
+
+// ignoredAttribute describes one attribute listed in "ignore_changes".
+type ignoredAttribute struct {
+	// Attribute is the attribute in the configuration language syntax, such as
+	// tags["Name"].
+	Attribute string `json:"attribute"`
+
+	// Path is the sequence of steps to the attribute from the top level of
+	// the resource's configuration, in which attribute names are strings
+	// and index keys are strings or numbers, as for the attribute paths in
+	// a plan's "relevant_attributes".
+	Path []json.RawMessage `json:"path"`
+}
+
+// checkRule is the JSON representation of a custom condition, such as a
+// precondition block.
+type checkRule struct {
+	Condition    expression `json:"condition"`
+	ErrorMessage expression `json:"error_message"`
+
+	// ErrorMessageText is the error message itself when ErrorMessage is a
+	// constant string. This is populated only for variable validations.
+	ErrorMessageText string `json:"error_message_text,omitempty"`
 }
 
 type provisioner struct {
 	Type        string         `json:"type,omitempty"`
 	Expressions map[string]any `json:"expressions,omitempty"`
+
+	// Connection describes the expressions in the "connection" block that
+	// applies to the provisioner, either its own or the one in its resource,
+	// excluding any arguments that are marked as sensitive in the connection
+	// schema, such as passwords and private keys.
+	Connection expressions `json:"connection,omitempty"`
+}
+
+// orderedConfig is a variant of [config] used when [MarshalOpts.Ordered] is
+// set, which represents the provider configurations as an array sorted by
+// key instead of as an object.
+type orderedConfig struct {
+	ProviderConfigs []keyedProviderConfig `json:"provider_config,omitempty"`
+	RootModule      orderedModule         `json:"root_module,omitempty"`
+
+	RecursiveModuleSources       []string                      `json:"recursive_module_sources,omitempty"`
+	ProviderConstraintConflicts  []providerConstraintConflict  `json:"provider_constraint_conflicts,omitempty"`
+	UnresolvedProviderReferences []unresolvedProviderReference `json:"unresolved_provider_references,omitempty"`
+	FunctionsUsed                []string                      `json:"functions_used,omitempty"`
+	SensitivitySummary           *sensitivitySummary           `json:"sensitivity_summary,omitempty"`
+	UnusedProviderConfigs        []string                      `json:"unused_provider_configs,omitempty"`
+}
+
+// keyedProviderConfig is a [providerConfig] along with the key it would
+// have in the "provider_config" object in the default representation.
+type keyedProviderConfig struct {
+	Key string `json:"key"`
+	providerConfig
+}
+
+// orderedModule is a variant of [module] used when [MarshalOpts.Ordered] is
+// set, which represents the outputs, module calls, and variables as arrays
+// sorted by name instead of as objects.
+type orderedModule struct {
+	Outputs     []keyedOutput     `json:"outputs,omitempty"`
+	Resources   []resource        `json:"resources,omitempty"`
+	ModuleCalls []keyedModuleCall `json:"module_calls,omitempty"`
+	Variables   []keyedVariable   `json:"variables,omitempty"`
+	Experiments []string          `json:"experiments,omitempty"`
+	Summary     *moduleSummary    `json:"summary,omitempty"`
+
+	Depth       *int   `json:"depth,omitempty"`
+	Path        string `json:"path,omitempty"`
+	ContentHash string `json:"content_hash,omitempty"`
+	Unchanged   bool   `json:"unchanged,omitempty"`
+}
+
+type keyedOutput struct {
+	Key string `json:"key"`
+	output
+}
+
+type keyedVariable struct {
+	Key string `json:"key"`
+	*variable
+}
+
+// keyedModuleCall is a [moduleCall] along with its name, in which the
+// child module is also represented as an [orderedModule].
+type keyedModuleCall struct {
+	Key string `json:"key"`
+	moduleCall
+	Module *orderedModule `json:"module,omitempty"`
+}
+
+// MarshalOpts represents optional behaviors of [MarshalWithOpts].
+//
+// The zero value of MarshalOpts produces the same result as [Marshal].
+type MarshalOpts struct {
+	// Ordered causes collections that are normally represented as JSON
+	// objects to instead be represented as arrays sorted by what would
+	// otherwise be their keys, for consumers that want a diff-friendly
+	// representation.
+	//
+	// This affects "provider_config", and the "outputs", "module_calls", and
+	// "variables" of each module, where each element has an additional
+	// "key" property giving its key.
+	Ordered bool
+
+	// SourceInfo causes the result to include information about where in
+	// the configuration source each object was declared, such as the
+	// "range" property of expressions.
+	SourceInfo bool
+
+	// ModuleInfo causes each module to include its "depth" below the root
+	// module and its full module "path", such as "module.a.module.b", and
+	// each resource to include the "module_call_path" of call names leading
+	// to its module.
+	ModuleInfo bool
+
+	// SensitiveReferences causes each expression to include a
+	// "references_sensitive" property when it refers to a sensitive input
+	// variable or to a resource attribute that its schema marks as sensitive.
+	SensitiveReferences bool
+
+	// SortedReferences causes the "references" property of each expression
+	// to be sorted lexically with duplicates removed, so that two sets of
+	// references can be compared for equality directly.
+	SortedReferences bool
+
+	// EffectiveCreateBeforeDestroy causes each managed resource to include
+	// an "effective_create_before_destroy" property, taking into account
+	// that create_before_destroy propagates to the dependencies of the
+	// resource that declares it.
+	//
+	// This considers only dependencies between resources in the same module,
+	// as described by "depends_on" and "referenced_resources", so it can
+	// miss propagation through module calls and outputs.
+	EffectiveCreateBeforeDestroy bool
+
+	// ContentHashes causes each module to include a "content_hash" property
+	// summarizing its content, excluding the content of its child modules,
+	// so that consumers can detect which modules have changed between two
+	// results. Use [ModuleContentHashes] to collect the hashes from a result.
+	ContentHashes bool
+
+	// BaseContentHashes, if not nil, enables ContentHashes and also causes
+	// each module whose content hash matches the one recorded for its path
+	// in this map, as returned by [ModuleContentHashes] for an earlier
+	// result, to be replaced by a stub with "unchanged" set and only the
+	// content hash and the module calls, so that the result describes only
+	// the modules that have changed.
+	BaseContentHashes map[string]string
+
+	// TemplateInfo causes each expression that is a string template with
+	// more than one part, such as a heredoc with interpolations, to include
+	// a "template" property distinguishing it from a plain reference or
+	// constant.
+	TemplateInfo bool
+
+	// InlineProviderConfigs causes each resource to include, as
+	// "provider_config", a copy of the entry in the top-level
+	// "provider_config" object that its "provider_config_key" refers to, so
+	// that consumers can use it without a separate lookup. The top-level
+	// object is still included.
+	InlineProviderConfigs bool
+
+	// FunctionsUsed causes the result to include a top-level
+	// "functions_used" property listing the names of all of the functions
+	// called in the expressions that are included in the result, across all
+	// modules. Expressions are not included in single-module mode, so the
+	// list is always empty in that case.
+	FunctionsUsed bool
+
+	// TypeInfo causes each input variable to include a "type_kind"
+	// classifying its type constraint and a "type_depth" giving how deeply
+	// nested the constraint is, to help with checking module interfaces.
+	TypeInfo bool
+
+	// DefaultsPreview causes each expression that is not constant, but whose
+	// value can be determined from only the default values of input
+	// variables and the constant local values in its module, to include that
+	// value as "value_with_defaults". Input variables that have no default or
+	// that are sensitive are treated as unknown. The value is a preview, so
+	// it is reported separately from "constant_value".
+	DefaultsPreview bool
+
+	// ProviderResolutionPath causes each resource to include a
+	// "provider_resolution_path" property tracing how its
+	// "provider_config_key" was resolved through the module calls that pass
+	// or inherit the provider configuration.
+	ProviderResolutionPath bool
+
+	// ProviderInheritanceDepth causes each provider configuration that is
+	// used by at least one resource to include an "inheritance_depth"
+	// property counting the module levels it is passed or inherited through
+	// before reaching the deepest of those resources, so that
+	// configurations passed through many levels can be flagged.
+	ProviderInheritanceDepth bool
+
+	// ProviderConfigFingerprints causes each provider configuration block to
+	// include a "config_fingerprint" property, so that consumers can find
+	// equivalent configurations in different modules that could instead be
+	// passed from a common ancestor without comparing their expressions.
+	// Expressions are not included in single-module mode, so neither are
+	// fingerprints.
+	ProviderConfigFingerprints bool
+
+	// ModuleCallInstances causes each module call that uses count or
+	// for_each with a constant value to include an "instances" property
+	// listing the key and address of each of its instances, so that
+	// consumers can have an inventory of instances rather than only the
+	// module call. Module calls whose instance keys depend on other objects
+	// are represented only as a single module call, as usual.
+	ModuleCallInstances bool
+
+	// FullyConstant causes each resource whose configuration arguments all
+	// have constant values to include "fully_constant" set to true.
+	// Expressions are not included in single-module mode, so neither is
+	// this property.
+	FullyConstant bool
+
+	// UnusedProviderConfigs causes the result to include a top-level
+	// "unused_provider_configs" property listing the keys of the provider
+	// configuration blocks that no resource uses, either directly or
+	// through a module call, and whose provider-defined functions are not
+	// called, so that they can be removed.
+	UnusedProviderConfigs bool
+
+	// SensitivitySummary causes the result to include a top-level
+	// "sensitivity_summary" property listing the sensitive input variables
+	// and output values, the resources that set arguments their schema
+	// marks as sensitive, and the provisioners that have connection blocks,
+	// so that they can be reviewed without searching the whole result.
+	SensitivitySummary bool
+
+	// ModuleSummary causes each module to include a "summary" property
+	// counting the managed, data, and ephemeral resources, the outputs, the
+	// input variables, and the module calls declared directly in it, to give
+	// a quick profile of its size.
+	ModuleSummary bool
+
+	// ConfigKeys causes each resource to include a "config_key" property,
+	// an opaque hash of its module path, mode, type and name and of the name
+	// of the file that declares it, so that consumers tracking resources
+	// over time have a fixed-length key for each declaration to combine
+	// with the "moved" blocks of the configuration. Moving a declaration
+	// within its file doesn't change its key.
+	ConfigKeys bool
+
+	// ProviderInferred causes each resource that has no "provider" argument
+	// to include "provider_inferred" set to true, so that resources relying
+	// on the provider configuration implied by their type can be told apart
+	// from those that select it explicitly.
+	ProviderInferred bool
+
+	// AbsoluteAddresses causes the addresses in each module other than the
+	// root module, which are normally relative to that module, to instead
+	// be qualified by its path, like "module.a.var.region", so that
+	// consumers combining modules into one graph need not resolve them.
+	// This affects resource addresses, references, "depends_on",
+	// "referenced_resources", "replace_triggered_by", "variable_bindings",
+	// and module call instance addresses. Provider configuration keys are already qualified by
+	// module path. References to "count", "each", "self", "path" and
+	// "terraform" are left unchanged, because they are not addresses of
+	// objects.
+	AbsoluteAddresses bool
+
+	// Sources, if not nil, maps the names of configuration source files to
+	// their parsed content, as returned by the Sources method of the
+	// configuration loader, and causes each expression declared in one of
+	// those files to include its literal source code as "source_text".
+	Sources map[string]*hcl.File
+
+	// redactProviderSecrets causes the constant values of sensitive
+	// arguments in provider configuration blocks to be omitted, for
+	// [MarshalProviderConfigs].
+	redactProviderSecrets bool
+
+	// sensitiveSources is the set of sensitive objects in the module whose
+	// expressions are currently being marshalled, populated by marshalModule
+	// when SensitiveReferences is set.
+	sensitiveSources map[string]struct{}
+
+	// defaultsContext is the evaluation context used for DefaultsPreview in
+	// the module whose expressions are currently being marshalled,
+	// populated by marshalModule.
+	defaultsContext *hcl.EvalContext
+
+	// functionsUsed collects the names of the functions called in each
+	// expression that is marshalled when FunctionsUsed is set.
+	functionsUsed map[string]struct{}
 }
 
 // Marshal returns the json encoding of tofu configuration.
 func Marshal(c *configs.Config, schemas *tofu.Schemas) ([]byte, error) {
-	return marshal(c, schemas)
+	return marshal(c, schemas, MarshalOpts{})
+}
+
+// MarshalWithOpts is a variant of [Marshal] that allows customizing the
+// result using the given options.
+func MarshalWithOpts(c *configs.Config, schemas *tofu.Schemas, opts MarshalOpts) ([]byte, error) {
+	return marshal(c, schemas, opts)
+}
+
+// MarshalProviderConfigs returns the json encoding of only the provider
+// configurations throughout the given configuration, as the
+// "provider_config" property of an otherwise-empty object, for auditing them
+// without marshalling the rest of the configuration.
+//
+// The entries are the same as in the result of [Marshal], except that the
+// constant values of sensitive arguments are replaced by a
+// "constant_redacted" flag.
+func MarshalProviderConfigs(c *configs.Config, schemas *tofu.Schemas) ([]byte, error) {
+	pcs := make(map[string]providerConfig)
+	marshalProviderConfigs(c, schemas, pcs, MarshalOpts{redactProviderSecrets: true})
+
+	deletePassedProviderConfigs(pcs)
+
+	return json.Marshal(struct {
+		ProviderConfigs map[string]providerConfig `json:"provider_config,omitempty"`
+	}{pcs})
 }
 
 // marshal is the shared implementation of both [Marshal] and
@@ -141,35 +748,240 @@ func Marshal(c *configs.Config, schemas *tofu.Schemas) ([]byte, error) {
 // [inSingleModuleMode], and not by directly testing if schemas are nil,
 // so that it's easier for future maintainers to learn about this special
 // treatment through the centralized doc comment.
-func marshal(c *configs.Config, schemas *tofu.Schemas) ([]byte, error) {
+func marshal(c *configs.Config, schemas *tofu.Schemas, opts MarshalOpts) ([]byte, error) {
+	output, err := marshalConfig(c, schemas, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.Ordered {
+		return json.Marshal(orderConfig(output))
+	}
+
+	ret, err := json.Marshal(output)
+	return ret, err
+}
+
+// marshalConfig builds the representation of the given configuration that
+// [marshal] encodes, with the same conventions for single module mode.
+func marshalConfig(c *configs.Config, schemas *tofu.Schemas, opts MarshalOpts) (config, error) {
 	var output config
 
+	if opts.FunctionsUsed {
+		opts.functionsUsed = make(map[string]struct{})
+	}
+
 	pcs := make(map[string]providerConfig)
-	marshalProviderConfigs(c, schemas, pcs)
+	marshalProviderConfigs(c, schemas, pcs, opts)
 
-	rootModule, err := marshalModule(c, schemas, "")
+	rootModule, err := marshalModule(c, schemas, "", opts)
 	if err != nil {
-		return nil, err
+		return config{}, err
 	}
 	output.RootModule = rootModule
 
+	if opts.ProviderResolutionPath {
+		setProviderResolutionPaths(&rootModule, pcs)
+	}
+	if opts.ProviderInheritanceDepth {
+		setProviderInheritanceDepths(rootModule, pcs)
+	}
 	normalizeModuleProviderKeys(&rootModule, pcs)
+	setModuleCallProviderConfigKeys(c, &rootModule, pcs)
+	if opts.UnusedProviderConfigs {
+		output.UnusedProviderConfigs = unusedProviderConfigs(c, schemas, rootModule, pcs)
+	}
+
+	deletePassedProviderConfigs(pcs)
+	output.ProviderConfigs = pcs
+
+	output.RecursiveModuleSources = recursiveModuleSources(c)
+	output.ProviderConstraintConflicts = providerConstraintConflicts(c)
+	output.UnresolvedProviderReferences = unresolvedProviderReferences(c)
+	if len(opts.functionsUsed) > 0 {
+		output.FunctionsUsed = slices.Sorted(maps.Keys(opts.functionsUsed))
+	}
+	if opts.SensitivitySummary {
+		output.SensitivitySummary = sensitivitySummaryFor(c, schemas)
+	}
+
+	if opts.ContentHashes || opts.BaseContentHashes != nil {
+		if err := setContentHashes(&output.RootModule, opts.BaseContentHashes); err != nil {
+			return config{}, err
+		}
+	}
+
+	if opts.AbsoluteAddresses {
+		absolutizeModule(&output.RootModule, "")
+		absolutizeProviderConfigs(pcs)
+	}
+
+	if opts.InlineProviderConfigs {
+		inlineProviderConfigs(&output.RootModule, pcs)
+	}
+
+	return output, nil
+}
+
+// recursiveModuleSources returns the sorted addresses of the module calls
+// in the given configuration whose source is the same as that of one of
+// their ancestor modules, or nil if there are none.
+//
+// OpenTofu rejects truly cyclic module trees while loading configuration,
+// so this finds only calls that reuse an ancestor's source further down the
+// tree, such as a different version of the same registry module.
+func recursiveModuleSources(c *configs.Config) []string {
+	var ret []string
+	var walk func(c *configs.Config, ancestors map[string]struct{})
+	walk = func(c *configs.Config, ancestors map[string]struct{}) {
+		for _, child := range c.Children {
+			if child == nil || child.Module == nil {
+				continue
+			}
+			src := moduleSourceKey(child)
+			if src == "" {
+				walk(child, ancestors)
+				continue
+			}
+			if _, exists := ancestors[src]; exists {
+				ret = append(ret, child.Path.String())
+				// There's no need to descend any further, since everything
+				// below here would be reported for the same reason.
+				continue
+			}
+			ancestors[src] = struct{}{}
+			walk(child, ancestors)
+			delete(ancestors, src)
+		}
+	}
+	walk(c, make(map[string]struct{}))
+	sort.Strings(ret)
+	return ret
+}
 
-	for name, pc := range pcs {
+// moduleSourceKey returns a string identifying where the given module's
+// source was obtained from, resolving local paths relative to the directory
+// the module was actually loaded from.
+//
+// The result is empty if the module has no source address, which is the case
+// only in synthetic configurations.
+func moduleSourceKey(c *configs.Config) string {
+	if c.SourceAddr == nil {
+		return ""
+	}
+	if _, ok := c.SourceAddr.(addrs.ModuleSourceLocal); ok {
+		return filepath.Clean(c.Module.SourceDir)
+	}
+	return c.SourceAddr.String()
+}
+
+// validate checks invariants of the given configuration representation that
+// the rest of this package relies on but does not directly enforce, returning
+// an error describing the first violation found, if any. The provider key
+// flattening is subtle, so the tests use this to catch regressions in it.
+//
+// Currently this checks that every resource's provider_config_key, and each
+// of the provider_config_keys of module calls, refers to one of the entries
+// in provider_config, which means that the provider keys were correctly
+// flattened to the configuration that is actually in effect.
+func validate(c config) error {
+	return validateModuleProviderKeys(c.RootModule, c.ProviderConfigs)
+}
+
+func validateModuleProviderKeys(m module, pcs map[string]providerConfig) error {
+	for _, r := range m.Resources {
+		pc, exists := pcs[r.ProviderConfigKey]
+		if !exists {
+			return fmt.Errorf("resource %s has provider_config_key %q, which does not match any provider configuration", r.Address, r.ProviderConfigKey)
+		}
 		if pc.parentKey != "" {
-			delete(pcs, name)
+			return fmt.Errorf("resource %s has provider_config_key %q, which refers to a configuration passed from %q", r.Address, r.ProviderConfigKey, pc.parentKey)
 		}
 	}
-	output.ProviderConfigs = pcs
 
-	ret, err := json.Marshal(output)
-	return ret, err
+	names := make([]string, 0, len(m.ModuleCalls))
+	for name := range m.ModuleCalls {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		mc := m.ModuleCalls[name]
+		for _, childName := range slices.Sorted(maps.Keys(mc.ProviderConfigKeys)) {
+			key := mc.ProviderConfigKeys[childName]
+			if _, exists := pcs[key]; !exists {
+				return fmt.Errorf("module call %s passes %s from provider_config_key %q, which does not match any provider configuration", name, childName, key)
+			}
+		}
+		if mc.Module == nil {
+			continue
+		}
+		if err := validateModuleProviderKeys(*mc.Module, pcs); err != nil {
+			return fmt.Errorf("module call %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// orderModule converts the given module into its [orderedModule]
+// equivalent, including the modules of its module calls.
+func orderModule(m module) orderedModule {
+	ret := orderedModule{
+		Resources:   m.Resources,
+		Experiments: m.Experiments,
+		Summary:     m.Summary,
+		Depth:       m.Depth,
+		Path:        m.Path,
+		ContentHash: m.ContentHash,
+		Unchanged:   m.Unchanged,
+	}
+	for _, name := range slices.Sorted(maps.Keys(m.Outputs)) {
+		ret.Outputs = append(ret.Outputs, keyedOutput{Key: name, output: m.Outputs[name]})
+	}
+	for _, name := range slices.Sorted(maps.Keys(m.ModuleCalls)) {
+		mc := keyedModuleCall{Key: name, moduleCall: m.ModuleCalls[name]}
+		if mc.moduleCall.Module != nil {
+			child := orderModule(*mc.moduleCall.Module)
+			mc.Module = &child
+		}
+		ret.ModuleCalls = append(ret.ModuleCalls, mc)
+	}
+	for _, name := range slices.Sorted(maps.Keys(m.Variables)) {
+		ret.Variables = append(ret.Variables, keyedVariable{Key: name, variable: m.Variables[name]})
+	}
+	return ret
+}
+
+// orderConfig converts the given config into its [orderedConfig] equivalent.
+func orderConfig(c config) orderedConfig {
+	ret := orderedConfig{
+		RootModule:                   orderModule(c.RootModule),
+		RecursiveModuleSources:       c.RecursiveModuleSources,
+		ProviderConstraintConflicts:  c.ProviderConstraintConflicts,
+		UnresolvedProviderReferences: c.UnresolvedProviderReferences,
+		FunctionsUsed:                c.FunctionsUsed,
+		SensitivitySummary:           c.SensitivitySummary,
+		UnusedProviderConfigs:        c.UnusedProviderConfigs,
+	}
+	if len(c.ProviderConfigs) > 0 {
+		ret.ProviderConfigs = make([]keyedProviderConfig, 0, len(c.ProviderConfigs))
+		for k, pc := range c.ProviderConfigs {
+			ret.ProviderConfigs = append(ret.ProviderConfigs, keyedProviderConfig{
+				Key:            k,
+				providerConfig: pc,
+			})
+		}
+		sort.Slice(ret.ProviderConfigs, func(i, j int) bool {
+			return ret.ProviderConfigs[i].Key < ret.ProviderConfigs[j].Key
+		})
+	}
+	return ret
 }
 
 func marshalProviderConfigs(
 	c *configs.Config,
 	schemas *tofu.Schemas,
 	m map[string]providerConfig,
+	opts MarshalOpts,
 ) {
 	if c == nil {
 		return
@@ -193,7 +1005,25 @@ func marshalProviderConfigs(
 			FullName:      providerFqn.String(),
 			Alias:         pc.Alias,
 			ModuleAddress: c.Path.String(),
-			Expressions:   marshalExpressions(pc.Config, schema),
+			Expressions:   marshalExpressions(pc.Config, schema, opts),
+		}
+
+		if schema != nil && len(p.Expressions) == 0 {
+			p.EmptyConfig = true
+		}
+
+		if opts.redactProviderSecrets {
+			p.Expressions = redactSensitiveConstants(p.Expressions, schema)
+		}
+
+		if opts.ProviderConfigFingerprints && !inSingleModuleMode(schemas) {
+			// A failure here would be a bug in the fingerprint input, and
+			// a missing fingerprint is still better than no result at all.
+			if fp, err := providerConfigFingerprint(p.Expressions, schema); err == nil {
+				p.ConfigFingerprint = fp
+			} else {
+				log.Printf("[WARN] jsonconfig: failed to fingerprint provider configuration %q: %s", k, err)
+			}
 		}
 
 		// Store the fully resolved provider version constraint, rather than
@@ -210,6 +1040,17 @@ func marshalProviderConfigs(
 		m[key] = p
 	}
 
+	// Required providers that are only needed for their provider-defined
+	// functions are flagged as such below, so that consumers can tell that
+	// they must be installed even though nothing else refers to them.
+	funcConfigs := providerFunctionConfigs(c, schemas)
+	usedConfigs := usedProviderConfigs(c)
+	requiredForFunctions := func(localAddr string) bool {
+		_, calledFunc := funcConfigs[localAddr]
+		_, used := usedConfigs[localAddr]
+		return calledFunc && !used
+	}
+
 	// Ensure that any required providers with no associated configuration
 	// block are included in the set.
 	for k, pr := range c.Module.ProviderRequirements.RequiredProviders {
@@ -225,9 +1066,11 @@ func marshalProviderConfigs(
 			// fill here are the local name, FQN, module address, and version
 			// constraints.
 			p := providerConfig{
-				Name:          pr.Name,
-				FullName:      pr.Type.String(),
-				ModuleAddress: c.Path.String(),
+				Name:                 pr.Name,
+				FullName:             pr.Type.String(),
+				ModuleAddress:        c.Path.String(),
+				RequiredForFunctions: requiredForFunctions(alias.StringCompact()),
+				RequirementOnly:      true,
 			}
 
 			if vc, ok := reqs[pr.Type]; ok {
@@ -248,9 +1091,11 @@ func marshalProviderConfigs(
 		// fill here are the local name, module address, and version
 		// constraints.
 		p := providerConfig{
-			Name:          pr.Name,
-			FullName:      pr.Type.String(),
-			ModuleAddress: c.Path.String(),
+			Name:                 pr.Name,
+			FullName:             pr.Type.String(),
+			ModuleAddress:        c.Path.String(),
+			RequiredForFunctions: requiredForFunctions(k),
+			RequirementOnly:      true,
 		}
 
 		if vc, ok := reqs[pr.Type]; ok {
@@ -259,6 +1104,7 @@ func marshalProviderConfigs(
 
 		if c.Parent != nil {
 			parentKey := opaqueProviderKey(pr.Name, c.Parent.Path.String())
+			p.resolutionPath = sourceProviderKeyPath(parentKey, p.FullName, m)
 			p.parentKey = findSourceProviderKey(parentKey, p.FullName, m)
 		}
 
@@ -284,6 +1130,7 @@ func marshalProviderConfigs(
 		// In child modules, providers defined in the parent module can be implicitly used.
 		if c.Parent != nil {
 			parentKey := opaqueProviderKey(req.Type, c.Parent.Path.String())
+			p.resolutionPath = sourceProviderKeyPath(parentKey, p.FullName, m)
 			p.parentKey = findSourceProviderKey(parentKey, p.FullName, m)
 		}
 
@@ -300,8 +1147,10 @@ func marshalProviderConfigs(
 		return
 	}
 
-	// Must also visit our child modules, recursively.
-	for name, mc := range c.Module.ModuleCalls {
+	// Must also visit our child modules, recursively. We visit them in a
+	// consistent order so that the result doesn't depend on map iteration.
+	for _, name := range slices.Sorted(maps.Keys(c.Module.ModuleCalls)) {
+		mc := c.Module.ModuleCalls[name]
 		// Keys in c.Children are guaranteed to match those in c.Module.ModuleCalls
 		cc := c.Children[name]
 
@@ -325,6 +1174,7 @@ func marshalProviderConfigs(
 
 			key := opaqueProviderKey(moduleProviderName, cc.Path.String())
 			parentKey := opaqueProviderKey(parentProviderName, cc.Parent.Path.String())
+			p.resolutionPath = sourceProviderKeyPath(parentKey, p.FullName, m)
 			p.parentKey = findSourceProviderKey(parentKey, p.FullName, m)
 
 			m[key] = p
@@ -333,31 +1183,198 @@ func marshalProviderConfigs(
 		// Finally, marshal any other provider configs within the called module.
 		// It is safe to do this last because it is invalid to configure a
 		// provider which has passed provider configs in the module call.
-		marshalProviderConfigs(cc, schemas, m)
+		marshalProviderConfigs(cc, schemas, m, opts)
+	}
+}
+
+// providerFunctionConfigs returns the set of local provider configuration
+// addresses, in their compact string form, whose provider-defined functions
+// are called from the given module.
+//
+// The result is always empty in single-module mode, because we cannot
+// analyze resource configuration bodies without their schemas.
+func providerFunctionConfigs(c *configs.Config, schemas *tofu.Schemas) map[string]struct{} {
+	ret := make(map[string]struct{})
+	if inSingleModuleMode(schemas) {
+		return ret
+	}
+
+	var refs []*addrs.Reference
+	addExpr := func(expr hcl.Expression) {
+		exprRefs, _ := lang.ProviderFunctionsInExpr(addrs.ParseRef, expr)
+		refs = append(refs, exprRefs...)
+	}
+	addBody := func(body hcl.Body, schema *configschema.Block) {
+		if schema == nil {
+			return
+		}
+		bodyRefs, _ := lang.ReferencesInBlock(addrs.ParseRef, body, schema)
+		refs = append(refs, bodyRefs...)
+	}
+
+	for _, rs := range []map[string]*configs.Resource{c.Module.ManagedResources, c.Module.DataResources, c.Module.EphemeralResources} {
+		for _, r := range rs {
+			addExpr(r.Count)
+			addExpr(r.ForEach)
+			if schema, _ := schemas.ResourceTypeConfig(r.Provider, r.Mode, r.Type); schema != nil {
+				addBody(r.Config, schema.Block)
+			}
+		}
+	}
+	for _, pc := range c.Module.ProviderConfigs {
+		// Modules built directly rather than by the config loader, as in
+		// tests, may have no provider requirements at all.
+		provider := addrs.ImpliedProviderForUnqualifiedType(pc.Name)
+		if c.Module.ProviderRequirements != nil {
+			provider = c.ProviderForConfigAddr(addrs.LocalProviderConfig{LocalName: pc.Name})
+		}
+		addBody(pc.Config, schemas.ProviderConfig(provider))
+	}
+	for _, mc := range c.Module.ModuleCalls {
+		addExpr(mc.Count)
+		addExpr(mc.ForEach)
+		if mc.Config == nil {
+			continue
+		}
+		// Module call arguments are always attributes, so we can analyze
+		// them without a schema. Any errors will already have been reported
+		// when the configuration was loaded.
+		attrs, _ := mc.Config.JustAttributes()
+		for _, attr := range attrs {
+			addExpr(attr.Expr)
+		}
+	}
+	for _, l := range c.Module.Locals {
+		addExpr(l.Expr)
+	}
+	for _, o := range c.Module.Outputs {
+		addExpr(o.Expr)
+	}
+
+	for _, ref := range refs {
+		if pf, ok := ref.Subject.(addrs.ProviderFunction); ok {
+			localAddr := addrs.LocalProviderConfig{LocalName: pf.ProviderName, Alias: pf.ProviderAlias}
+			ret[localAddr.StringCompact()] = struct{}{}
+		}
+	}
+	return ret
+}
+
+// usedProviderConfigs returns the set of local provider configuration
+// addresses, in their compact string form, that are used by resources in the
+// given module or passed explicitly to its module calls.
+func usedProviderConfigs(c *configs.Config) map[string]struct{} {
+	ret := make(map[string]struct{})
+	for _, rs := range []map[string]*configs.Resource{c.Module.ManagedResources, c.Module.DataResources, c.Module.EphemeralResources} {
+		for _, r := range rs {
+			ret[r.ProviderConfigAddr().StringCompact()] = struct{}{}
+		}
+	}
+	for _, mc := range c.Module.ModuleCalls {
+		for _, ppc := range mc.Providers {
+			ret[ppc.InParent.String()] = struct{}{}
+		}
 	}
+	return ret
 }
 
-func marshalModule(c *configs.Config, schemas *tofu.Schemas, addr string) (module, error) {
+// unusedProviderConfigs returns the sorted keys of the provider
+// configuration blocks in the given configuration that are not referred to
+// by the normalized provider_config_key of any resource in m, by any of the
+// entries of pcs for provider configurations that are passed or inherited
+// from them, or by calls to their provider-defined functions.
+//
+// This must be called after [normalizeModuleProviderKeys] and before the
+// entries for passed and inherited configurations are removed from pcs.
+func unusedProviderConfigs(c *configs.Config, schemas *tofu.Schemas, m module, pcs map[string]providerConfig) []string {
+	used := make(map[string]struct{})
+	var walkModule func(m module)
+	walkModule = func(m module) {
+		for _, r := range m.Resources {
+			used[r.ProviderConfigKey] = struct{}{}
+		}
+		for _, mc := range m.ModuleCalls {
+			if mc.Module != nil {
+				walkModule(*mc.Module)
+			}
+		}
+	}
+	walkModule(m)
+	for _, pc := range pcs {
+		if pc.parentKey != "" {
+			used[pc.parentKey] = struct{}{}
+		}
+	}
+
+	var ret []string
+	var walkConfig func(c *configs.Config)
+	walkConfig = func(c *configs.Config) {
+		if c == nil || c.Module == nil {
+			return
+		}
+		funcConfigs := providerFunctionConfigs(c, schemas)
+		for k := range c.Module.ProviderConfigs {
+			if _, ok := funcConfigs[k]; ok {
+				continue
+			}
+			key := opaqueProviderKey(k, c.Path.String())
+			if _, ok := used[key]; !ok {
+				ret = append(ret, key)
+			}
+		}
+		for _, child := range c.Children {
+			walkConfig(child)
+		}
+	}
+	walkConfig(c)
+
+	sort.Strings(ret)
+	return ret
+}
+
+func marshalModule(c *configs.Config, schemas *tofu.Schemas, addr string, opts MarshalOpts) (module, error) {
 	var module module
 	var rs []resource
 
-	managedResources, err := marshalResources(c.Module.ManagedResources, schemas, addr)
+	if opts.SensitiveReferences && !inSingleModuleMode(schemas) {
+		opts.sensitiveSources = sensitiveSources(c, schemas)
+	}
+	if opts.DefaultsPreview {
+		opts.defaultsContext = defaultsEvalContext(c.Module)
+	}
+
+	managedResources, err := marshalResources(c.Module.ManagedResources, schemas, addr, opts)
 	if err != nil {
 		return module, err
 	}
-	dataResources, err := marshalResources(c.Module.DataResources, schemas, addr)
+	dataResources, err := marshalResources(c.Module.DataResources, schemas, addr, opts)
 	if err != nil {
 		return module, err
 	}
-	ephemeralResources, err := marshalResources(c.Module.EphemeralResources, schemas, addr)
+	ephemeralResources, err := marshalResources(c.Module.EphemeralResources, schemas, addr, opts)
 	if err != nil {
 		return module, err
 	}
 
+	if opts.EffectiveCreateBeforeDestroy {
+		setEffectiveCreateBeforeDestroy(managedResources, c.Module.ManagedResources)
+	}
+
 	rs = append(managedResources, dataResources...)
 	rs = append(rs, ephemeralResources...)
 	module.Resources = rs
 
+	if opts.ModuleInfo {
+		depth := c.Depth()
+		module.Depth = &depth
+		module.Path = c.Path.String()
+		if !c.Path.IsRoot() {
+			for i := range module.Resources {
+				module.Resources[i].ModuleCallPath = slices.Clone([]string(c.Path))
+			}
+		}
+	}
+
 	outputs := make(map[string]output)
 	for _, v := range c.Module.Outputs {
 		o := output{
@@ -366,12 +1383,14 @@ func marshalModule(c *configs.Config, schemas *tofu.Schemas, addr string) (modul
 			Deprecated: v.Deprecated,
 		}
 		if !inSingleModuleMode(schemas) {
-			expr := marshalExpression(v.Expr)
+			expr := marshalExpression(v.Expr, opts)
 			o.Expression = &expr
+			o.Preconditions = marshalCheckRules(v.Preconditions, opts)
 		}
 		if v.Description != "" {
 			o.Description = v.Description
 		}
+		o.ForwardsSensitiveOutput = forwardsSensitiveOutput(c, v.Expr)
 		if len(v.DependsOn) > 0 {
 			dependencies := make([]string, len(v.DependsOn))
 			for i, d := range v.DependsOn {
@@ -390,7 +1409,12 @@ func marshalModule(c *configs.Config, schemas *tofu.Schemas, addr string) (modul
 	}
 	module.Outputs = outputs
 
-	module.ModuleCalls = marshalModuleCalls(c, schemas)
+	module.ModuleCalls, err = marshalModuleCalls(c, schemas, opts)
+	if err != nil {
+		return module, err
+	}
+
+	module.Experiments = moduleExperiments(c.Module.ActiveExperiments)
 
 	if len(c.Module.Variables) > 0 {
 		vars := make(variables, len(c.Module.Variables))
@@ -420,46 +1444,226 @@ func marshalModule(c *configs.Config, schemas *tofu.Schemas, addr string) (modul
 				}
 			}
 
-			var defaultValJSON []byte
-			var required bool
-			if v.Default == cty.NilVal {
-				defaultValJSON = nil
-				required = true
-			} else {
-				defaultValJSON, err = ctyjson.Marshal(v.Default, v.Default.Type())
-				required = false
-				if err != nil {
-					return module, err
-				}
+			defaultValJSON, err := marshalVariableDefault(v)
+			if err != nil {
+				return module, err
 			}
 			vars[k] = &variable{
 				Type:        typeJSON,
 				Default:     defaultValJSON,
-				Required:    required,
+				Required:    v.Default == cty.NilVal,
 				Description: v.Description,
 				Sensitive:   v.Sensitive,
 				Ephemeral:   v.Ephemeral,
 				Deprecated:  v.Deprecated,
 			}
+			if v.NullableSet {
+				nullable := v.Nullable
+				vars[k].Nullable = &nullable
+			}
+			if !inSingleModuleMode(schemas) {
+				vars[k].Validations = marshalVariableValidations(v.Validations, opts)
+			}
+			if opts.TypeInfo {
+				depth := typeDepth(typeConstraint)
+				vars[k].TypeKind = typeKind(typeConstraint)
+				vars[k].TypeDepth = &depth
+			}
 		}
 		module.Variables = vars
 	}
 
+	if opts.ModuleSummary {
+		module.Summary = &moduleSummary{
+			ManagedResources:   len(managedResources),
+			DataResources:      len(dataResources),
+			EphemeralResources: len(ephemeralResources),
+			Outputs:            len(module.Outputs),
+			Variables:          len(module.Variables),
+			ModuleCalls:        len(module.ModuleCalls),
+		}
+	}
+
 	return module, nil
 }
 
-func marshalModuleCalls(c *configs.Config, schemas *tofu.Schemas) map[string]moduleCall {
+// typeKind returns the "type_kind" of a variable with the given type
+// constraint.
+func typeKind(ty cty.Type) string {
+	switch {
+	case ty.Equals(cty.DynamicPseudoType):
+		return "any"
+	case ty.IsPrimitiveType():
+		return "primitive"
+	case ty.IsCollectionType():
+		return "collection"
+	case ty.IsObjectType() || ty.IsTupleType():
+		return "structural"
+	default:
+		return "any"
+	}
+}
+
+// typeDepth returns the number of levels of collection and structural types
+// nested in the given type, which is zero for primitive types and "any".
+func typeDepth(ty cty.Type) int {
+	var inner []cty.Type
+	switch {
+	case ty.IsCollectionType():
+		inner = []cty.Type{ty.ElementType()}
+	case ty.IsObjectType():
+		for _, aty := range ty.AttributeTypes() {
+			inner = append(inner, aty)
+		}
+	case ty.IsTupleType():
+		inner = ty.TupleElementTypes()
+	default:
+		return 0
+	}
+	deepest := 0
+	for _, ity := range inner {
+		deepest = max(deepest, typeDepth(ity))
+	}
+	return deepest + 1
+}
+
+// sensitiveSources returns the set of objects in the given module that
+// expressions might refer to in order to obtain a sensitive value, using the
+// same syntax as the references in [expression].
+//
+// This includes the sensitive input variables, and both the sensitive
+// top-level attributes of each resource and the resource itself, since a
+// reference to the whole object also exposes those attributes.
+func sensitiveSources(c *configs.Config, schemas *tofu.Schemas) map[string]struct{} {
+	ret := make(map[string]struct{})
+	for _, v := range c.Module.Variables {
+		if v.Sensitive {
+			ret[addrs.InputVariable{Name: v.Name}.String()] = struct{}{}
+		}
+	}
+
+	for _, rcs := range []map[string]*configs.Resource{
+		c.Module.ManagedResources,
+		c.Module.DataResources,
+		c.Module.EphemeralResources,
+	} {
+		for _, r := range rcs {
+			schema, _ := schemas.ResourceTypeConfig(r.Provider, r.Mode, r.Type)
+			if schema == nil || schema.Block == nil {
+				continue
+			}
+			addr := r.Addr().String()
+			for name, attr := range schema.Block.Attributes {
+				if attr.Sensitive {
+					ret[addr+"."+name] = struct{}{}
+					ret[addr] = struct{}{}
+				}
+			}
+		}
+	}
+	return ret
+}
+
+// defaultsEvalContext returns an evaluation context in which the input
+// variables of the given module have their default values and its local
+// values are known if they are constant. Everything else is unknown.
+func defaultsEvalContext(mod *configs.Module) *hcl.EvalContext {
+	vars := make(map[string]cty.Value, len(mod.Variables))
+	for name, v := range mod.Variables {
+		if v.Default == cty.NilVal || v.Sensitive {
+			vars[name] = cty.DynamicVal
+			continue
+		}
+		vars[name] = v.Default
+	}
+	locals := make(map[string]cty.Value, len(mod.Locals))
+	for name, l := range mod.Locals {
+		val, diags := l.Expr.Value(nil)
+		if diags.HasErrors() || val == cty.NilVal {
+			val = cty.DynamicVal
+		}
+		locals[name] = val
+	}
+	return &hcl.EvalContext{
+		Variables: map[string]cty.Value{
+			"var":   cty.ObjectVal(vars),
+			"local": cty.ObjectVal(locals),
+		},
+	}
+}
+
+func marshalCheckRules(rules []*configs.CheckRule, opts MarshalOpts) []checkRule {
+	if len(rules) == 0 {
+		return nil
+	}
+	ret := make([]checkRule, len(rules))
+	for i, rule := range rules {
+		ret[i] = checkRule{
+			Condition:    marshalExpression(rule.Condition, opts),
+			ErrorMessage: marshalExpression(rule.ErrorMessage, opts),
+		}
+	}
+	return ret
+}
+
+// marshalVariableValidations is like [marshalCheckRules], but also includes
+// the text of each error message that is a constant string, so that
+// consumers such as documentation generators can show it directly.
+func marshalVariableValidations(rules []*configs.CheckRule, opts MarshalOpts) []checkRule {
+	ret := marshalCheckRules(rules, opts)
+	for i := range ret {
+		var text string
+		if err := json.Unmarshal(ret[i].ErrorMessage.ConstantValue, &text); err == nil {
+			ret[i].ErrorMessageText = text
+		}
+	}
+	return ret
+}
+
+// RootVariableDefaults returns the JSON encoding of the default value of each
+// input variable declared in the root module of the given configuration,
+// using the same representation as the "default" property of variables in
+// the result of [Marshal].
+//
+// Variables that have no default value, and are therefore required, are
+// included in the result with a nil value.
+func RootVariableDefaults(c *configs.Config) (map[string]json.RawMessage, error) {
+	ret := make(map[string]json.RawMessage, len(c.Module.Variables))
+	for name, v := range c.Module.Variables {
+		defaultValJSON, err := marshalVariableDefault(v)
+		if err != nil {
+			return nil, fmt.Errorf("variable %q: %w", name, err)
+		}
+		ret[name] = defaultValJSON
+	}
+	return ret, nil
+}
+
+// marshalVariableDefault returns the JSON encoding of the default value of
+// the given variable, or nil if the variable has no default value.
+func marshalVariableDefault(v *configs.Variable) (json.RawMessage, error) {
+	if v.Default == cty.NilVal {
+		return nil, nil
+	}
+	return ctyjson.Marshal(v.Default, v.Default.Type())
+}
+
+func marshalModuleCalls(c *configs.Config, schemas *tofu.Schemas, opts MarshalOpts) (map[string]moduleCall, error) {
 	ret := make(map[string]moduleCall)
 
 	for name, mc := range c.Module.ModuleCalls {
 		mcConfig := c.Children[name]
-		ret[name] = marshalModuleCall(mcConfig, mc, schemas)
+		call, err := marshalModuleCall(mcConfig, mc, schemas, opts)
+		if err != nil {
+			return ret, err
+		}
+		ret[name] = call
 	}
 
-	return ret
+	return ret, nil
 }
 
-func marshalModuleCall(c *configs.Config, mc *configs.ModuleCall, schemas *tofu.Schemas) moduleCall {
+func marshalModuleCall(c *configs.Config, mc *configs.ModuleCall, schemas *tofu.Schemas, opts MarshalOpts) (moduleCall, error) {
 	// Note that "c" is always nil when in single module mode!
 	// Refer to the docs on [inSingleModuleMode] to learn about how that
 	// special situation works.
@@ -471,22 +1675,24 @@ func marshalModuleCall(c *configs.Config, mc *configs.ModuleCall, schemas *tofu.
 		// a (admittedly minor) breaking change to start normalizing them
 		// now, in case consumers of this data are expecting a particular
 		// non-normalized syntax.
-		Source:            mc.SourceAddrRaw,
-		VersionConstraint: mc.Version.Required.String(),
+		Source:              mc.SourceAddrRaw,
+		VersionConstraint:   mc.Version.Required.String(),
+		ProviderInheritance: "inherited",
+		ExpansionMode:       expansionMode(mc.Count, mc.ForEach),
+	}
+	if len(mc.Providers) > 0 {
+		ret.ProviderInheritance = "explicit"
 	}
 
 	if !inSingleModuleMode(schemas) {
 		// The expression-related properties are not available in single-module
 		// mode.
-		cExp := marshalExpression(mc.Count)
-		if !cExp.Empty() {
-			ret.CountExpression = &cExp
-		} else {
-			fExp := marshalExpression(mc.ForEach)
-			if !fExp.Empty() {
-				ret.ForEachExpression = &fExp
-			}
+		cExp, fExp, err := marshalRepetitionExpressions(mc.Count, mc.ForEach, opts)
+		if err != nil {
+			return ret, fmt.Errorf("module call %s: %w", mc.Name, err)
 		}
+		ret.CountExpression = cExp
+		ret.ForEachExpression = fExp
 		schema := &configschema.Block{}
 		schema.Attributes = make(map[string]*configschema.Attribute)
 		for _, variable := range c.Module.Variables {
@@ -494,11 +1700,18 @@ func marshalModuleCall(c *configs.Config, mc *configs.ModuleCall, schemas *tofu.
 				Required: variable.Default == cty.NilVal,
 			}
 		}
-		ret.Expressions = marshalExpressions(mc.Config, schema)
+		ret.Expressions = marshalExpressions(mc.Config, schema, opts)
+		ret.VariableBindings = variableBindings(ret.Expressions)
+		if opts.ModuleCallInstances {
+			ret.Instances = moduleCallInstances(mc, c.Path.String())
+		}
 
 		// The "module" property, describing the content of the child module,
 		// is not available in single-module mode.
-		module, _ := marshalModule(c, schemas, c.Path.String())
+		module, err := marshalModule(c, schemas, c.Path.String(), opts)
+		if err != nil {
+			return ret, fmt.Errorf("module call %s: %w", mc.Name, err)
+		}
 		ret.Module = &module
 	}
 
@@ -516,10 +1729,10 @@ func marshalModuleCall(c *configs.Config, mc *configs.ModuleCall, schemas *tofu.
 		ret.DependsOn = dependencies
 	}
 
-	return ret
+	return ret, nil
 }
 
-func marshalResources(resources map[string]*configs.Resource, schemas *tofu.Schemas, moduleAddr string) ([]resource, error) {
+func marshalResources(resources map[string]*configs.Resource, schemas *tofu.Schemas, moduleAddr string, opts MarshalOpts) ([]resource, error) {
 	var rs []resource
 	for _, v := range resources {
 		providerConfigKey := opaqueProviderKey(v.ProviderConfigAddr().StringCompact(), moduleAddr)
@@ -528,6 +1741,18 @@ func marshalResources(resources map[string]*configs.Resource, schemas *tofu.Sche
 			Type:              v.Type,
 			Name:              v.Name,
 			ProviderConfigKey: providerConfigKey,
+			ExpansionMode:     expansionMode(v.Count, v.ForEach),
+		}
+
+		if opts.ProviderInferred {
+			r.ProviderInferred = v.ProviderConfigRef == nil
+		}
+		if opts.ConfigKeys {
+			r.ConfigKey = resourceConfigKey(v, moduleAddr)
+		}
+
+		if opts.SourceInfo {
+			r.Range = resourceBlockRange(v)
 		}
 
 		switch v.Mode {
@@ -544,15 +1769,12 @@ func marshalResources(resources map[string]*configs.Resource, schemas *tofu.Sche
 		if !inSingleModuleMode(schemas) {
 			// We don't populate the expression and schema-related properties
 			// when we are in single-module mode.
-			cExp := marshalExpression(v.Count)
-			if !cExp.Empty() {
-				r.CountExpression = &cExp
-			} else {
-				fExp := marshalExpression(v.ForEach)
-				if !fExp.Empty() {
-					r.ForEachExpression = &fExp
-				}
+			cExp, fExp, err := marshalRepetitionExpressions(v.Count, v.ForEach, opts)
+			if err != nil {
+				return nil, fmt.Errorf("resource %s: %w", r.Address, err)
 			}
+			r.CountExpression = cExp
+			r.ForEachExpression = fExp
 
 			schema, schemaVer := schemas.ResourceTypeConfig(
 				v.Provider,
@@ -563,7 +1785,11 @@ func marshalResources(resources map[string]*configs.Resource, schemas *tofu.Sche
 				return nil, fmt.Errorf("no schema found for %s (in provider %s)", v.Addr().String(), v.Provider)
 			}
 			r.SchemaVersion = &schemaVer
-			r.Expressions = marshalExpressions(v.Config, schema.Block)
+			r.Expressions = marshalExpressions(v.Config, schema.Block, opts)
+			r.UsesDeprecatedAttributes = deprecatedAttributes(r.Expressions, schema.Block)
+			if opts.FullyConstant {
+				r.FullyConstant = fullyConstant(r.Expressions)
+			}
 		}
 
 		// Managed is populated only for Mode = addrs.ManagedResourceMode
@@ -575,7 +1801,10 @@ func marshalResources(resources map[string]*configs.Resource, schemas *tofu.Sche
 				})
 				prov := provisioner{
 					Type:        p.Type,
-					Expressions: marshalExpressions(p.Config, schema),
+					Expressions: marshalExpressions(p.Config, schema, opts),
+				}
+				if !inSingleModuleMode(schemas) {
+					prov.Connection = marshalConnection(v.Managed.Connection, p.Connection, opts)
 				}
 				provisioners = append(provisioners, prov)
 			}
@@ -596,6 +1825,18 @@ func marshalResources(resources map[string]*configs.Resource, schemas *tofu.Sche
 			r.DependsOn = dependencies
 		}
 
+		if v.Managed != nil {
+			r.IgnoreChanges = marshalIgnoreChanges(v.Managed)
+			r.Lifecycle = marshalLifecycle(v, schemas, opts)
+		}
+
+		if !inSingleModuleMode(schemas) {
+			r.Preconditions = marshalCheckRules(v.Preconditions, opts)
+			r.Postconditions = marshalCheckRules(v.Postconditions, opts)
+		}
+
+		r.ReferencedResources = referencedResources(r)
+
 		rs = append(rs, r)
 	}
 	sort.Slice(rs, func(i, j int) bool {
@@ -604,6 +1845,434 @@ func marshalResources(resources map[string]*configs.Resource, schemas *tofu.Sche
 	return rs, nil
 }
 
+// forwardsSensitiveOutput returns true if the given expression in the module
+// of the given configuration refers to a sensitive output value of one of
+// its child modules, either directly or by referring to the whole module.
+func forwardsSensitiveOutput(c *configs.Config, expr hcl.Expression) bool {
+	if expr == nil || len(c.Children) == 0 {
+		return false
+	}
+	refs, _ := lang.ReferencesInExpr(addrs.ParseRef, expr)
+	for _, ref := range refs {
+		var call addrs.ModuleCall
+		var name string
+		switch subject := ref.Subject.(type) {
+		case addrs.ModuleCallInstanceOutput:
+			call, name = subject.Call.Call, subject.Name
+		case addrs.ModuleCallInstance:
+			call = subject.Call
+		case addrs.ModuleCall:
+			call = subject
+		default:
+			continue
+		}
+		child := c.Children[call.Name]
+		if child == nil || child.Module == nil {
+			continue
+		}
+		for _, o := range child.Module.Outputs {
+			if o.Sensitive && (name == "" || o.Name == name) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// resourceConfigKey returns the "config_key" of the given resource, declared
+// in the module with the given address, as described for
+// [MarshalOpts.ConfigKeys].
+func resourceConfigKey(r *configs.Resource, moduleAddr string) string {
+	// The fields are separated by a character that can't appear in any of
+	// them, so that different combinations can't produce the same input.
+	src := strings.Join([]string{
+		moduleAddr,
+		r.Mode.String(),
+		r.Type,
+		r.Name,
+		filepath.ToSlash(r.DeclRange.Filename),
+	}, "\x00")
+	sum := sha256.Sum256([]byte(src))
+	return hex.EncodeToString(sum[:])
+}
+
+// moduleExperiments returns the sorted keywords of the given active
+// experiments, or nil if there are none.
+func moduleExperiments(active experiments.Set) []string {
+	if len(active) == 0 {
+		return nil
+	}
+	ret := make([]string, 0, len(active))
+	for exp := range active {
+		ret = append(ret, exp.Keyword())
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+// marshalIgnoreChanges returns the representation of the "ignore_changes"
+// argument of the given managed resource, or nil if it isn't set.
+func marshalIgnoreChanges(m *configs.ManagedResource) *ignoreChanges {
+	if m.IgnoreAllChanges {
+		return &ignoreChanges{All: true}
+	}
+	if len(m.IgnoreChanges) == 0 {
+		return nil
+	}
+	ret := &ignoreChanges{
+		Attributes: make([]ignoredAttribute, 0, len(m.IgnoreChanges)),
+	}
+	for _, traversal := range m.IgnoreChanges {
+		path := make([]json.RawMessage, 0, len(traversal))
+		for _, step := range traversal {
+			switch step := step.(type) {
+			case hcl.TraverseAttr:
+				name, _ := json.Marshal(step.Name)
+				path = append(path, name)
+			case hcl.TraverseIndex:
+				key, err := ctyjson.Marshal(step.Key, step.Key.Type())
+				if err != nil {
+					// Should not happen, because the configuration loader
+					// accepts only constant index keys here.
+					continue
+				}
+				path = append(path, key)
+			}
+		}
+		ret.Attributes = append(ret.Attributes, ignoredAttribute{
+			// The traversals are relative, so they always start with an
+			// attribute step that TraversalStr prefixes with a period.
+			Attribute: strings.TrimPrefix(addrs.TraversalStr(traversal), "."),
+			Path:      path,
+		})
+	}
+	return ret
+}
+
+// marshalLifecycle returns the representation of the lifecycle arguments of
+// the given managed resource that [lifecycle] describes, or nil if none of
+// them are set.
+func marshalLifecycle(rc *configs.Resource, schemas *tofu.Schemas, opts MarshalOpts) *lifecycle {
+	var ret lifecycle
+	if rc.Managed.CreateBeforeDestroySet {
+		v := rc.Managed.CreateBeforeDestroy
+		ret.CreateBeforeDestroy = &v
+	}
+	if rc.Managed.PreventDestroy != nil && !inSingleModuleMode(schemas) {
+		expr := marshalExpression(rc.Managed.PreventDestroy, opts)
+		ret.PreventDestroy = &expr
+	}
+	for _, expr := range rc.TriggersReplacement {
+		refs, _ := lang.ReferencesInExpr(addrs.ParseRef, expr)
+		for _, ref := range refs {
+			// Each expression refers to a single resource or resource
+			// attribute, but might use count.index or each.key to select
+			// the instance, which we don't report separately.
+			switch ref.Subject.(type) {
+			case addrs.Resource, addrs.ResourceInstance:
+				ret.ReplaceTriggeredBy = append(ret.ReplaceTriggeredBy, fmt.Sprintf("%s%s", ref.Subject, addrs.TraversalStr(ref.Remaining)))
+			}
+		}
+	}
+	if rc.Managed.IgnoreAllChanges {
+		ret.IgnoreChanges = []string{"all"}
+	}
+	for _, traversal := range rc.Managed.IgnoreChanges {
+		// The traversals are relative, so they always start with an
+		// attribute step that TraversalStr prefixes with a period.
+		ret.IgnoreChanges = append(ret.IgnoreChanges, strings.TrimPrefix(addrs.TraversalStr(traversal), "."))
+	}
+	if ret.CreateBeforeDestroy == nil && ret.PreventDestroy == nil && ret.ReplaceTriggeredBy == nil && ret.IgnoreChanges == nil {
+		return nil
+	}
+	return &ret
+}
+
+// setEffectiveCreateBeforeDestroy populates the EffectiveCreateBeforeDestroy
+// field of each of the given managed resources, using the given resource
+// configurations to find which of them declare create_before_destroy.
+//
+// OpenTofu forces create_before_destroy for all of the dependencies of a
+// resource that uses it, because otherwise the graph would contain cycles
+// when both are being replaced, so this propagates the declared setting
+// through the dependencies recorded in each resource.
+func setEffectiveCreateBeforeDestroy(rs []resource, rcs map[string]*configs.Resource) {
+	byAddr := make(map[string]*resource, len(rs))
+	var queue []*resource
+	effective := make(map[string]bool, len(rs))
+	for i := range rs {
+		r := &rs[i]
+		byAddr[r.Address] = r
+		if rc := rcs[r.Address]; rc != nil && rc.Managed != nil && rc.Managed.CreateBeforeDestroy {
+			effective[r.Address] = true
+			queue = append(queue, r)
+		}
+	}
+	for len(queue) > 0 {
+		r := queue[0]
+		queue = queue[1:]
+		deps := slices.Concat(r.DependsOn, r.ReferencedResources)
+		for _, dep := range deps {
+			if _, ok := byAddr[dep]; ok && !effective[dep] {
+				effective[dep] = true
+				queue = append(queue, byAddr[dep])
+			}
+		}
+	}
+	for i := range rs {
+		v := effective[rs[i].Address]
+		rs[i].EffectiveCreateBeforeDestroy = &v
+	}
+}
+
+// deprecatedAttributes returns the sorted names of the attributes marshalled
+// into the given expressions that are deprecated in the given schema, or nil
+// if there are none.
+// fullyConstant returns true if all of the given expressions, including
+// those in nested blocks, have a constant value.
+func fullyConstant(exprs map[string]any) bool {
+	ret := true
+	walkExpressions(exprs, func(ex expression) {
+		if ex.ConstantValue == nil {
+			ret = false
+		}
+	})
+	return ret
+}
+
+func deprecatedAttributes(exprs expressions, schema *configschema.Block) []string {
+	var ret []string
+	for name := range exprs {
+		if attr, ok := schema.Attributes[name]; ok && attr.Deprecated {
+			ret = append(ret, name)
+		}
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+// referencedResources returns the sorted, distinct addresses of the resources
+// referred to by the expressions already marshalled into the given resource,
+// or nil if there are none.
+func referencedResources(r resource) []string {
+	seen := make(map[string]struct{})
+	collect := func(expr expression) {
+		for _, refStr := range expr.References {
+			ref, diags := addrs.ParseRefStr(refStr)
+			if diags.HasErrors() {
+				// Should not happen, because these strings were produced
+				// from valid references in the first place.
+				continue
+			}
+			switch subject := ref.Subject.(type) {
+			case addrs.Resource:
+				seen[subject.String()] = struct{}{}
+			case addrs.ResourceInstance:
+				seen[subject.Resource.String()] = struct{}{}
+			}
+		}
+	}
+
+	walkExpressions(r.CountExpression, collect)
+	walkExpressions(r.ForEachExpression, collect)
+	walkExpressions(r.Expressions, collect)
+	for _, p := range r.Provisioners {
+		walkExpressions(p.Expressions, collect)
+	}
+
+	if len(seen) == 0 {
+		return nil
+	}
+	ret := make([]string, 0, len(seen))
+	for addr := range seen {
+		ret = append(ret, addr)
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+// marshalRepetitionExpressions returns the marshalled forms of the given
+// "count" and "for_each" expressions, with nil for any that are not set.
+//
+// Configuration validation should prevent both from being set at once, but
+// if that does happen anyway we return an error rather than silently
+// discarding one of them, since the result would misrepresent the
+// configuration.
+func marshalRepetitionExpressions(count, forEach hcl.Expression, opts MarshalOpts) (*expression, *expression, error) {
+	var cRet, fRet *expression
+	if cExp := marshalExpression(count, opts); !cExp.Empty() {
+		cRet = &cExp
+	}
+	if fExp := marshalExpression(forEach, opts); !fExp.Empty() {
+		fRet = &fExp
+	}
+	if cRet != nil && fRet != nil {
+		return nil, nil, errors.New(`"count" and "for_each" are mutually exclusive, but both are set`)
+	}
+	return cRet, fRet, nil
+}
+
+// resourceBlockRange returns the source range of the whole block declaring
+// the given resource, or nil if the resource was not declared in a file.
+//
+// Only native syntax bodies record where they end, so for other bodies,
+// such as those from JSON files, this is just the range of the block header.
+func resourceBlockRange(r *configs.Resource) *sourceRange {
+	if r.DeclRange.Filename == "" {
+		return nil
+	}
+	rng := r.DeclRange
+	if body, ok := r.Config.(*hclsyntax.Body); ok {
+		rng = hcl.RangeBetween(rng, body.SrcRange)
+	}
+	return marshalSourceRange(rng)
+}
+
+// connectionSchema is the schema used to marshal "connection" blocks, which
+// omits the sensitive arguments so that they are never included.
+var connectionSchema = withoutSensitiveAttributes(shared.ConnectionBlockSupersetSchema)
+
+// marshalConnection returns the expressions in the connection block that
+// applies to a provisioner, given the connection blocks of its resource and
+// of the provisioner itself, either of which may be nil. The result is nil if
+// neither is set.
+func marshalConnection(resourceConn, provisionerConn *configs.Connection, opts MarshalOpts) expressions {
+	conn := provisionerConn
+	if conn == nil {
+		conn = resourceConn
+	}
+	if conn == nil {
+		return nil
+	}
+	return marshalExpressions(conn.Config, connectionSchema, opts)
+}
+
+// withoutSensitiveAttributes returns a shallow copy of the given schema with
+// all of the attributes marked as sensitive removed.
+func withoutSensitiveAttributes(schema *configschema.Block) *configschema.Block {
+	ret := *schema
+	ret.Attributes = make(map[string]*configschema.Attribute, len(schema.Attributes))
+	for name, attr := range schema.Attributes {
+		if !attr.Sensitive {
+			ret.Attributes[name] = attr
+		}
+	}
+	return &ret
+}
+
+// inlineProviderConfigs sets the ProviderConfig of each resource in the given
+// module and its descendents to the entry in pcs for its provider config key,
+// which must already have been normalized by [normalizeModuleProviderKeys].
+func inlineProviderConfigs(m *module, pcs map[string]providerConfig) {
+	for i, r := range m.Resources {
+		if pc, exists := pcs[r.ProviderConfigKey]; exists {
+			m.Resources[i].ProviderConfig = &pc
+		}
+	}
+	for _, mc := range m.ModuleCalls {
+		if mc.Module != nil {
+			inlineProviderConfigs(mc.Module, pcs)
+		}
+	}
+}
+
+// variableBindings returns the references in each of the given module call
+// argument expressions, keyed by argument name, or nil if none of them
+// include any references.
+func variableBindings(exprs map[string]any) map[string][]string {
+	var ret map[string][]string
+	for name, v := range exprs {
+		ex, ok := v.(expression)
+		if !ok || len(ex.References) == 0 {
+			continue
+		}
+		if ret == nil {
+			ret = make(map[string][]string)
+		}
+		ret[name] = ex.References
+	}
+	return ret
+}
+
+// expansionMode returns the "expansion_mode" of a resource or module call
+// with the given "count" and "for_each" expressions.
+// moduleCallInstances returns the instances of the given module call, whose
+// module has the given path, if it uses count or for_each with a constant
+// value, or nil otherwise.
+func moduleCallInstances(mc *configs.ModuleCall, path string) []moduleCallInstance {
+	var keys []addrs.InstanceKey
+	switch {
+	case mc.Count != nil:
+		v, diags := mc.Count.Value(nil)
+		if diags.HasErrors() || !v.IsWhollyKnown() || v.IsNull() {
+			return nil
+		}
+		var n int
+		if nv, err := convert.Convert(v, cty.Number); err != nil || gocty.FromCtyValue(nv, &n) != nil || n < 0 {
+			return nil
+		}
+		for i := range n {
+			keys = append(keys, addrs.IntKey(i))
+		}
+	case mc.ForEach != nil:
+		v, diags := mc.ForEach.Value(nil)
+		if diags.HasErrors() || !v.IsWhollyKnown() || v.IsNull() {
+			return nil
+		}
+		ty := v.Type()
+		switch {
+		case ty.IsMapType() || ty.IsObjectType():
+			for it := v.ElementIterator(); it.Next(); {
+				k, _ := it.Element()
+				keys = append(keys, addrs.StringKey(k.AsString()))
+			}
+		case ty.IsSetType():
+			for it := v.ElementIterator(); it.Next(); {
+				_, elem := it.Element()
+				elem, err := convert.Convert(elem, cty.String)
+				if err != nil || elem.IsNull() {
+					return nil
+				}
+				keys = append(keys, addrs.StringKey(elem.AsString()))
+			}
+		default:
+			return nil
+		}
+		sort.Slice(keys, func(i, j int) bool {
+			return keys[i].(addrs.StringKey) < keys[j].(addrs.StringKey)
+		})
+	default:
+		return nil
+	}
+
+	ret := make([]moduleCallInstance, 0, len(keys))
+	for _, key := range keys {
+		inst := moduleCallInstance{
+			Address: addrs.ModuleCall{Name: mc.Name}.Instance(key).String(),
+			Module:  path,
+		}
+		switch key := key.(type) {
+		case addrs.IntKey:
+			inst.Key = int(key)
+		case addrs.StringKey:
+			inst.Key = string(key)
+		}
+		ret = append(ret, inst)
+	}
+	return ret
+}
+
+func expansionMode(count, forEach hcl.Expression) string {
+	switch {
+	case count != nil:
+		return "count"
+	case forEach != nil:
+		return "for_each"
+	default:
+		return "single"
+	}
+}
+
 // Flatten all resource provider keys in a module and its descendents, such
 // that any resources from providers using a configuration passed through the
 // module call have a direct reference to that provider configuration.
@@ -616,7 +2285,8 @@ func normalizeModuleProviderKeys(m *module, pcs map[string]providerConfig) {
 		}
 	}
 
-	for _, mc := range m.ModuleCalls {
+	for _, name := range slices.Sorted(maps.Keys(m.ModuleCalls)) {
+		mc := m.ModuleCalls[name]
 		if mc.Module == nil {
 			// This field is not populated in single-module mode, but
 			// that's okay because it means we have no need to recurse
@@ -627,6 +2297,55 @@ func normalizeModuleProviderKeys(m *module, pcs map[string]providerConfig) {
 	}
 }
 
+// setModuleCallProviderConfigKeys sets the ProviderConfigKeys of each module
+// call in the given module and its descendents that has a "providers"
+// argument, using the entries that [marshalProviderConfigs] added to pcs for
+// the passed provider configurations, which record the keys they resolve to.
+func setModuleCallProviderConfigKeys(c *configs.Config, m *module, pcs map[string]providerConfig) {
+	for _, name := range slices.Sorted(maps.Keys(m.ModuleCalls)) {
+		mc := m.ModuleCalls[name]
+		call := c.Module.ModuleCalls[name]
+		cc := c.Children[name]
+		if call == nil || cc == nil {
+			// Children are not populated in single-module mode, so neither
+			// are the entries for the passed provider configurations.
+			continue
+		}
+
+		for _, ppc := range call.Providers {
+			childName := ppc.InChild.String()
+			pc, exists := pcs[opaqueProviderKey(childName, cc.Path.String())]
+			if !exists || pc.parentKey == "" {
+				// The passed configuration could not be resolved, so there
+				// is no key to report.
+				continue
+			}
+			if mc.ProviderConfigKeys == nil {
+				mc.ProviderConfigKeys = make(map[string]string)
+			}
+			mc.ProviderConfigKeys[childName] = pc.parentKey
+		}
+		m.ModuleCalls[name] = mc
+
+		if mc.Module != nil {
+			setModuleCallProviderConfigKeys(cc, mc.Module, pcs)
+		}
+	}
+}
+
+// deletePassedProviderConfigs removes the entries for provider
+// configurations that are passed or inherited from another configuration,
+// leaving only the source configurations that resources' provider config
+// keys are normalized to. This must happen only after
+// [normalizeModuleProviderKeys], which relies on those entries.
+func deletePassedProviderConfigs(pcs map[string]providerConfig) {
+	for _, name := range slices.Sorted(maps.Keys(pcs)) {
+		if pcs[name].parentKey != "" {
+			delete(pcs, name)
+		}
+	}
+}
+
 // opaqueProviderKey generates a unique absProviderConfig-like string from the module
 // address and provider
 func opaqueProviderKey(provider string, addr string) (key string) {
@@ -657,3 +2376,69 @@ func findSourceProviderKey(startKey string, fullName string, m map[string]provid
 
 	return parentKey
 }
+
+// sourceProviderKeyPath returns the keys of each provider configuration
+// between startKey and the source configuration that [findSourceProviderKey]
+// would return, inclusive, or nil if startKey does not refer to a
+// configuration for the given provider.
+//
+// The parentKey of each entry in m is already flattened to point at the
+// source, so the intermediate keys are taken from the resolutionPath
+// recorded for the entry at startKey instead. Parent configurations are
+// always visited before their children, so that is already populated.
+func sourceProviderKeyPath(startKey string, fullName string, m map[string]providerConfig) []string {
+	parent, exists := m[startKey]
+	if !exists || parent.FullName != fullName {
+		return nil
+	}
+	return append([]string{startKey}, parent.resolutionPath...)
+}
+
+// setProviderResolutionPaths sets the ProviderResolutionPath of each resource
+// in the given module and its descendents, starting from its provider config
+// key before it is flattened by [normalizeModuleProviderKeys].
+func setProviderResolutionPaths(m *module, pcs map[string]providerConfig) {
+	for i, r := range m.Resources {
+		if pc, exists := pcs[r.ProviderConfigKey]; exists {
+			m.Resources[i].ProviderResolutionPath = append([]string{r.ProviderConfigKey}, pc.resolutionPath...)
+		}
+	}
+	for _, mc := range m.ModuleCalls {
+		if mc.Module != nil {
+			setProviderResolutionPaths(mc.Module, pcs)
+		}
+	}
+}
+
+// setProviderInheritanceDepths sets the InheritanceDepth of each source
+// provider configuration in pcs that is used by a resource in the given
+// module or its descendents, starting from the provider config keys of the
+// resources before they are flattened by [normalizeModuleProviderKeys].
+func setProviderInheritanceDepths(m module, pcs map[string]providerConfig) {
+	for _, r := range m.Resources {
+		pc, exists := pcs[r.ProviderConfigKey]
+		if !exists {
+			continue
+		}
+		sourceKey := r.ProviderConfigKey
+		if pc.parentKey != "" {
+			sourceKey = pc.parentKey
+		}
+		source, exists := pcs[sourceKey]
+		if !exists {
+			continue
+		}
+		// The resolution path lists each configuration the key was passed
+		// or inherited from, so its length is the number of levels.
+		depth := len(pc.resolutionPath)
+		if source.InheritanceDepth == nil || *source.InheritanceDepth < depth {
+			source.InheritanceDepth = &depth
+			pcs[sourceKey] = source
+		}
+	}
+	for _, mc := range m.ModuleCalls {
+		if mc.Module != nil {
+			setProviderInheritanceDepths(*mc.Module, pcs)
+		}
+	}
+}
@@ -6,7 +6,9 @@
 package jsonconfig
 
 import (
+	"bytes"
 	"encoding/json"
+	"slices"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
@@ -15,6 +17,7 @@ import (
 	"github.com/opentofu/opentofu/internal/addrs"
 	"github.com/opentofu/opentofu/internal/configs"
 	"github.com/opentofu/opentofu/internal/configs/configschema"
+	"github.com/opentofu/opentofu/internal/experiments"
 	"github.com/opentofu/opentofu/internal/providers"
 	"github.com/opentofu/opentofu/internal/tofu"
 	"github.com/zclconf/go-cty/cty"
@@ -115,6 +118,387 @@ func TestFindSourceProviderConfig(t *testing.T) {
 	}
 }
 
+func TestMarshalProviderConfigs_requirementOnly(t *testing.T) {
+	cfg := &configs.Config{
+		Module: &configs.Module{
+			ProviderRequirements: &configs.RequiredProviders{
+				RequiredProviders: map[string]*configs.RequiredProvider{
+					"test": {
+						Name: "test",
+						Type: addrs.NewDefaultProvider("test"),
+					},
+					"other": {
+						Name: "other",
+						Type: addrs.NewDefaultProvider("other"),
+					},
+				},
+			},
+			ProviderConfigs: map[string]*configs.Provider{
+				"other": {
+					Name:   "other",
+					Config: hcl.EmptyBody(),
+				},
+			},
+		},
+	}
+
+	got := make(map[string]providerConfig)
+	marshalProviderConfigs(cfg, &tofu.Schemas{}, got, MarshalOpts{})
+
+	if !got["test"].RequirementOnly {
+		t.Errorf("provider \"test\" is not marked as requirement only")
+	}
+	if got["other"].RequirementOnly {
+		t.Errorf("provider \"other\" is marked as requirement only, but has a configuration block")
+	}
+}
+
+func TestMarshalProviderConfigs_emptyConfig(t *testing.T) {
+	f, diags := hclsyntax.ParseConfig([]byte(`region = "us-east-1"`), "main.tf", hcl.InitialPos)
+	if diags.HasErrors() {
+		t.Fatal(diags.Error())
+	}
+	cfg := &configs.Config{
+		Module: &configs.Module{
+			ProviderRequirements: &configs.RequiredProviders{
+				RequiredProviders: map[string]*configs.RequiredProvider{},
+			},
+			ProviderConfigs: map[string]*configs.Provider{
+				"test": {
+					Name:   "test",
+					Config: hcl.EmptyBody(),
+				},
+				"test.configured": {
+					Name:   "test",
+					Alias:  "configured",
+					Config: f.Body,
+				},
+			},
+		},
+	}
+	schemas := &tofu.Schemas{
+		Providers: map[addrs.Provider]providers.ProviderSchema{
+			addrs.NewDefaultProvider("test"): {
+				Provider: providers.Schema{
+					Block: &configschema.Block{
+						Attributes: map[string]*configschema.Attribute{
+							"region": {Type: cty.String, Optional: true},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	got := make(map[string]providerConfig)
+	marshalProviderConfigs(cfg, schemas, got, MarshalOpts{})
+
+	if !got["test"].EmptyConfig {
+		t.Errorf("provider \"test\" is not marked as having an empty configuration")
+	}
+	if got["test.configured"].EmptyConfig {
+		t.Errorf("provider \"test.configured\" is marked as having an empty configuration, but sets region")
+	}
+}
+
+func TestMarshalProviderConfigs_requiredForFunctions(t *testing.T) {
+	testAddr := addrs.NewDefaultProvider("test")
+	otherAddr := addrs.NewDefaultProvider("other")
+	expr, diags := hclsyntax.ParseExpression([]byte(`provider::test::echo("hello")`), "test.tf", hcl.InitialPos)
+	if diags.HasErrors() {
+		t.Fatal(diags.Error())
+	}
+
+	cfg := &configs.Config{
+		Module: &configs.Module{
+			ProviderRequirements: &configs.RequiredProviders{
+				RequiredProviders: map[string]*configs.RequiredProvider{
+					"test": {
+						Name: "test",
+						Type: testAddr,
+					},
+					"other": {
+						Name: "other",
+						Type: otherAddr,
+					},
+				},
+			},
+			Outputs: map[string]*configs.Output{
+				"example": {
+					Name: "example",
+					Expr: expr,
+				},
+			},
+		},
+	}
+
+	got := make(map[string]providerConfig)
+	marshalProviderConfigs(cfg, &tofu.Schemas{}, got, MarshalOpts{})
+
+	if !got["test"].RequiredForFunctions {
+		t.Errorf("provider \"test\" is not marked as required for functions")
+	}
+	if got["other"].RequiredForFunctions {
+		t.Errorf("provider \"other\" is marked as required for functions, but none of its functions are called")
+	}
+}
+
+func TestUnusedProviderConfigs(t *testing.T) {
+	cfg := &configs.Config{
+		Module: &configs.Module{
+			ProviderConfigs: map[string]*configs.Provider{
+				"aws":        {Name: "aws"},
+				"aws.passed": {Name: "aws", Alias: "passed"},
+				"aws.unused": {Name: "aws", Alias: "unused"},
+			},
+		},
+	}
+	cfg.Children = map[string]*configs.Config{
+		"child": {
+			Path:   addrs.RootModule.Child("child"),
+			Parent: cfg,
+			Module: &configs.Module{
+				ProviderConfigs: map[string]*configs.Provider{
+					"null": {Name: "null"},
+				},
+			},
+		},
+	}
+	m := module{
+		Resources: []resource{{ProviderConfigKey: "aws"}},
+		ModuleCalls: map[string]moduleCall{
+			"child": {Module: &module{}},
+		},
+	}
+	pcs := map[string]providerConfig{
+		"aws":               {},
+		"aws.passed":        {},
+		"aws.unused":        {},
+		"module.child:aws":  {parentKey: "aws.passed"},
+		"module.child:null": {},
+	}
+
+	got := unusedProviderConfigs(cfg, &tofu.Schemas{}, m, pcs)
+	want := []string{"aws.unused", "module.child:null"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("wrong result\n%s", diff)
+	}
+}
+
+func TestMarshalWithOpts_ordered(t *testing.T) {
+	cfg := &configs.Config{
+		Module: &configs.Module{
+			ProviderRequirements: &configs.RequiredProviders{
+				RequiredProviders: map[string]*configs.RequiredProvider{
+					"b": {
+						Name: "b",
+						Type: addrs.NewDefaultProvider("b"),
+					},
+					"a": {
+						Name: "a",
+						Type: addrs.NewDefaultProvider("a"),
+					},
+				},
+			},
+		},
+	}
+	cfg.Root = cfg
+
+	got, err := MarshalWithOpts(cfg, &tofu.Schemas{}, MarshalOpts{Ordered: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	want := `{"provider_config":[` +
+		`{"key":"a","name":"a","full_name":"registry.opentofu.org/hashicorp/a","requirement_only":true},` +
+		`{"key":"b","name":"b","full_name":"registry.opentofu.org/hashicorp/b","requirement_only":true}` +
+		`],"root_module":{}}`
+	if string(got) != want {
+		t.Errorf("wrong result\ngot:  %s\nwant: %s", got, want)
+	}
+}
+
+func TestMarshalWithOpts_orderedModule(t *testing.T) {
+	cfg := &configs.Config{
+		Module: &configs.Module{
+			ProviderRequirements: &configs.RequiredProviders{},
+			Outputs: map[string]*configs.Output{
+				"b": {Name: "b", Expr: hcl.StaticExpr(cty.StringVal("b"), hcl.Range{})},
+				"a": {Name: "a", Expr: hcl.StaticExpr(cty.StringVal("a"), hcl.Range{})},
+			},
+			Variables: map[string]*configs.Variable{
+				"y": {Name: "y", ConstraintType: cty.String},
+				"x": {Name: "x", ConstraintType: cty.Number},
+			},
+		},
+	}
+	cfg.Root = cfg
+
+	got, err := MarshalWithOpts(cfg, &tofu.Schemas{}, MarshalOpts{Ordered: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	type keyed struct {
+		Key  string          `json:"key"`
+		Type json.RawMessage `json:"type,omitempty"`
+	}
+	var result struct {
+		RootModule struct {
+			Outputs   []keyed `json:"outputs"`
+			Variables []keyed `json:"variables"`
+		} `json:"root_module"`
+	}
+	if err := json.Unmarshal(got, &result); err != nil {
+		t.Fatalf("invalid result: %s\n%s", err, got)
+	}
+	want := []keyed{{Key: "a"}, {Key: "b"}}
+	if diff := cmp.Diff(want, result.RootModule.Outputs); diff != "" {
+		t.Errorf("wrong outputs\n%s", diff)
+	}
+	want = []keyed{{Key: "x", Type: json.RawMessage(`"number"`)}, {Key: "y", Type: json.RawMessage(`"string"`)}}
+	if diff := cmp.Diff(want, result.RootModule.Variables); diff != "" {
+		t.Errorf("wrong variables\n%s", diff)
+	}
+}
+
+func TestMarshalModule_moduleInfo(t *testing.T) {
+	root := &configs.Config{
+		Module: &configs.Module{
+			ModuleCalls: map[string]*configs.ModuleCall{
+				"a": {Name: "a", Config: &hclsyntax.Body{}},
+			},
+		},
+	}
+	a := &configs.Config{
+		Parent: root,
+		Path:   addrs.RootModule.Child("a"),
+		Module: &configs.Module{
+			ModuleCalls: map[string]*configs.ModuleCall{
+				"b": {Name: "b", Config: &hclsyntax.Body{}},
+			},
+		},
+	}
+	b := &configs.Config{
+		Parent: a,
+		Path:   addrs.RootModule.Child("a").Child("b"),
+		Module: &configs.Module{
+			ManagedResources: map[string]*configs.Resource{
+				"test_thing.x": {
+					Mode:     addrs.ManagedResourceMode,
+					Type:     "test_thing",
+					Name:     "x",
+					Config:   &hclsyntax.Body{},
+					Provider: addrs.NewDefaultProvider("test"),
+				},
+			},
+		},
+	}
+	root.Children = map[string]*configs.Config{"a": a}
+	a.Children = map[string]*configs.Config{"b": b}
+	schemas := &tofu.Schemas{
+		Providers: map[addrs.Provider]providers.ProviderSchema{
+			addrs.NewDefaultProvider("test"): {
+				ResourceTypes: map[string]providers.Schema{
+					"test_thing": {Block: &configschema.Block{}},
+				},
+			},
+		},
+	}
+
+	got, err := marshalModule(root, schemas, "", MarshalOpts{ModuleInfo: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	gotA := got.ModuleCalls["a"].Module
+	gotB := gotA.ModuleCalls["b"].Module
+	for _, test := range []struct {
+		got       *module
+		wantDepth int
+		wantPath  string
+	}{
+		{&got, 0, ""},
+		{gotA, 1, "module.a"},
+		{gotB, 2, "module.a.module.b"},
+	} {
+		if test.got.Depth == nil || *test.got.Depth != test.wantDepth {
+			t.Errorf("wrong depth for %q: got %v, want %d", test.wantPath, test.got.Depth, test.wantDepth)
+		}
+		if test.got.Path != test.wantPath {
+			t.Errorf("wrong path %q; want %q", test.got.Path, test.wantPath)
+		}
+	}
+
+	if got, want := gotB.Resources[0].ModuleCallPath, []string{"a", "b"}; !slices.Equal(got, want) {
+		t.Errorf("wrong module call path %q; want %q", got, want)
+	}
+
+	// Without the option, none of the properties are populated.
+	got, err = marshalModule(root, schemas, "", MarshalOpts{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	gotB = got.ModuleCalls["a"].Module.ModuleCalls["b"].Module
+	if got.Depth != nil || got.ModuleCalls["a"].Module.Path != "" || gotB.Resources[0].ModuleCallPath != nil {
+		t.Errorf("module info included without the ModuleInfo option")
+	}
+}
+
+func TestMarshalModule_summary(t *testing.T) {
+	resource := func(mode addrs.ResourceMode, name string) *configs.Resource {
+		return &configs.Resource{
+			Mode:     mode,
+			Type:     "test_thing",
+			Name:     name,
+			Config:   &hclsyntax.Body{},
+			Provider: addrs.NewDefaultProvider("test"),
+		}
+	}
+	cfg := &configs.Config{
+		Module: &configs.Module{
+			ManagedResources: map[string]*configs.Resource{
+				"test_thing.a": resource(addrs.ManagedResourceMode, "a"),
+				"test_thing.b": resource(addrs.ManagedResourceMode, "b"),
+			},
+			DataResources: map[string]*configs.Resource{
+				"data.test_thing.c": resource(addrs.DataResourceMode, "c"),
+			},
+			Outputs: map[string]*configs.Output{
+				"out": {Name: "out"},
+			},
+			Variables: map[string]*configs.Variable{
+				"in": {Name: "in", ConstraintType: cty.String},
+			},
+			ModuleCalls: map[string]*configs.ModuleCall{
+				"child": {Name: "child", Config: &hclsyntax.Body{}},
+			},
+		},
+	}
+
+	// Single-module mode is enough here, since the counts don't depend on
+	// schemas.
+	got, err := marshalModule(cfg, nil, "", MarshalOpts{ModuleSummary: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	want := &moduleSummary{
+		ManagedResources: 2,
+		DataResources:    1,
+		Outputs:          1,
+		Variables:        1,
+		ModuleCalls:      1,
+	}
+	if diff := cmp.Diff(want, got.Summary); diff != "" {
+		t.Errorf("wrong summary\n%s", diff)
+	}
+
+	got, err = marshalModule(cfg, nil, "", MarshalOpts{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if got.Summary != nil {
+		t.Errorf("summary included without the ModuleSummary option")
+	}
+}
+
 func TestMarshalModule(t *testing.T) {
 	emptySchemas := &tofu.Schemas{}
 	providerAddr := addrs.NewProvider("host", "namespace", "type")
@@ -202,6 +586,54 @@ func TestMarshalModule(t *testing.T) {
 				},
 			},
 		},
+		"variable, not nullable": {
+			Input: &configs.Config{
+				Module: &configs.Module{
+					Variables: map[string]*configs.Variable{
+						"example": {
+							Name:        "example",
+							Nullable:    false,
+							NullableSet: true,
+						},
+					},
+				},
+			},
+			Schemas: emptySchemas,
+			Want: module{
+				Outputs:     map[string]output{},
+				ModuleCalls: map[string]moduleCall{},
+				Variables: variables{
+					"example": {
+						Required: true,
+						Nullable: ptrTo(false),
+					},
+				},
+			},
+		},
+		"variable, explicitly nullable": {
+			Input: &configs.Config{
+				Module: &configs.Module{
+					Variables: map[string]*configs.Variable{
+						"example": {
+							Name:        "example",
+							Nullable:    true,
+							NullableSet: true,
+						},
+					},
+				},
+			},
+			Schemas: emptySchemas,
+			Want: module{
+				Outputs:     map[string]output{},
+				ModuleCalls: map[string]moduleCall{},
+				Variables: variables{
+					"example": {
+						Required: true,
+						Nullable: ptrTo(true),
+					},
+				},
+			},
+		},
 		"variable, collection type": {
 			Input: &configs.Config{
 				Module: &configs.Module{
@@ -328,6 +760,7 @@ func TestMarshalModule(t *testing.T) {
 						SchemaVersion:     ptrTo[uint64](0),
 						Provisioners:      nil,
 						Expressions:       make(map[string]any),
+						ExpansionMode:     "single",
 					},
 					{
 						Address:           "data.test_type.test_data",
@@ -338,6 +771,7 @@ func TestMarshalModule(t *testing.T) {
 						SchemaVersion:     ptrTo[uint64](0),
 						Provisioners:      nil,
 						Expressions:       make(map[string]any),
+						ExpansionMode:     "single",
 					},
 					{
 						Address:           "ephemeral.test_type.test_ephemeral",
@@ -348,6 +782,7 @@ func TestMarshalModule(t *testing.T) {
 						SchemaVersion:     ptrTo[uint64](0),
 						Provisioners:      nil,
 						Expressions:       make(map[string]any),
+						ExpansionMode:     "single",
 					},
 				},
 			},
@@ -366,7 +801,7 @@ func TestMarshalModule(t *testing.T) {
 			Want: module{
 				Outputs: map[string]output{
 					"example": {
-						Expression: ptrTo(marshalExpression(nil)),
+						Expression: ptrTo(marshalExpression(nil, MarshalOpts{})),
 					},
 				},
 				ModuleCalls: map[string]moduleCall{},
@@ -386,7 +821,8 @@ func TestMarshalModule(t *testing.T) {
 							Ephemeral:   true,
 							Preconditions: []*configs.CheckRule{
 								{
-									Condition: &hclsyntax.ConditionalExpr{},
+									Condition:    &hclsyntax.LiteralValueExpr{Val: cty.True},
+									ErrorMessage: &hclsyntax.LiteralValueExpr{Val: cty.StringVal("failed")},
 								},
 							},
 							IsOverridden: false,
@@ -401,8 +837,14 @@ func TestMarshalModule(t *testing.T) {
 						Sensitive:   true,
 						Ephemeral:   true,
 						Deprecated:  "deprecation message",
-						Expression:  ptrTo(marshalExpression(&hclsyntax.LiteralValueExpr{Val: cty.StringVal("test")})),
+						Expression:  ptrTo(marshalExpression(&hclsyntax.LiteralValueExpr{Val: cty.StringVal("test")}, MarshalOpts{})),
 						Description: "description",
+						Preconditions: []checkRule{
+							{
+								Condition:    expression{ConstantValue: json.RawMessage(`true`)},
+								ErrorMessage: expression{ConstantValue: json.RawMessage(`"failed"`)},
+							},
+						},
 					},
 				},
 				ModuleCalls: map[string]moduleCall{},
@@ -423,7 +865,7 @@ func TestMarshalModule(t *testing.T) {
 			input.Root = &input
 			input.Parent = &input
 
-			got, err := marshalModule(&input, schemas, addrs.RootModule.String())
+			got, err := marshalModule(&input, schemas, addrs.RootModule.String(), MarshalOpts{})
 			if err != nil {
 				t.Fatalf("unexpected error: %s", err)
 			}
@@ -435,11 +877,1219 @@ func TestMarshalModule(t *testing.T) {
 	}
 }
 
-// ptrTo is a helper to compensate for the fact that Go doesn't allow
-// using the '&' operator unless the operand is directly addressable.
-//
-// Instead then, this function returns a pointer to a copy of the given
-// value.
-func ptrTo[T any](v T) *T {
-	return &v
+func TestMarshalModule_countAndForEach(t *testing.T) {
+	providerAddr := addrs.NewProvider("host", "namespace", "type")
+	countExpr := &hclsyntax.LiteralValueExpr{Val: cty.NumberIntVal(2)}
+	forEachExpr := &hclsyntax.LiteralValueExpr{Val: cty.SetVal([]cty.Value{cty.StringVal("a")})}
+
+	tests := map[string]struct {
+		Input   *configs.Config
+		WantErr string
+	}{
+		"resource": {
+			Input: &configs.Config{
+				Module: &configs.Module{
+					ManagedResources: map[string]*configs.Resource{
+						"test_type.test_res": {
+							Mode:     addrs.ManagedResourceMode,
+							Name:     "test_res",
+							Type:     "test_type",
+							Count:    countExpr,
+							ForEach:  forEachExpr,
+							Config:   &hclsyntax.Body{},
+							Provider: providerAddr,
+						},
+					},
+				},
+			},
+			WantErr: `resource test_type.test_res: "count" and "for_each" are mutually exclusive, but both are set`,
+		},
+		"module call": {
+			Input: &configs.Config{
+				Module: &configs.Module{
+					ModuleCalls: map[string]*configs.ModuleCall{
+						"child": {
+							Name:    "child",
+							Count:   countExpr,
+							ForEach: forEachExpr,
+							Config:  &hclsyntax.Body{},
+						},
+					},
+				},
+				Children: map[string]*configs.Config{
+					"child": {
+						Path:   addrs.RootModule.Child("child"),
+						Module: &configs.Module{},
+					},
+				},
+			},
+			WantErr: `module call child: "count" and "for_each" are mutually exclusive, but both are set`,
+		},
+		"resource in child module": {
+			Input: &configs.Config{
+				Module: &configs.Module{
+					ModuleCalls: map[string]*configs.ModuleCall{
+						"child": {
+							Name:   "child",
+							Config: &hclsyntax.Body{},
+						},
+					},
+				},
+				Children: map[string]*configs.Config{
+					"child": {
+						Path: addrs.RootModule.Child("child"),
+						Module: &configs.Module{
+							ManagedResources: map[string]*configs.Resource{
+								"test_type.test_res": {
+									Mode:     addrs.ManagedResourceMode,
+									Name:     "test_res",
+									Type:     "test_type",
+									Count:    countExpr,
+									ForEach:  forEachExpr,
+									Config:   &hclsyntax.Body{},
+									Provider: providerAddr,
+								},
+							},
+						},
+					},
+				},
+			},
+			WantErr: `module call child: resource test_type.test_res: "count" and "for_each" are mutually exclusive, but both are set`,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := marshalModule(test.Input, &tofu.Schemas{}, addrs.RootModule.String(), MarshalOpts{})
+			if err == nil {
+				t.Fatal("unexpected success; want error")
+			}
+			if got, want := err.Error(), test.WantErr; got != want {
+				t.Errorf("wrong error\ngot:  %s\nwant: %s", got, want)
+			}
+		})
+	}
+}
+
+func TestModuleCallInstances(t *testing.T) {
+	expr := func(src string) hcl.Expression {
+		t.Helper()
+		e, diags := hclsyntax.ParseExpression([]byte(src), "test.tf", hcl.InitialPos)
+		if diags.HasErrors() {
+			t.Fatal(diags.Error())
+		}
+		return e
+	}
+
+	tests := map[string]struct {
+		call *configs.ModuleCall
+		want []moduleCallInstance
+	}{
+		"single": {
+			call: &configs.ModuleCall{Name: "a"},
+		},
+		"constant count": {
+			call: &configs.ModuleCall{Name: "a", Count: expr(`2`)},
+			want: []moduleCallInstance{
+				{Key: 0, Address: "module.a[0]", Module: "module.a"},
+				{Key: 1, Address: "module.a[1]", Module: "module.a"},
+			},
+		},
+		"constant for_each set": {
+			call: &configs.ModuleCall{Name: "a", ForEach: &hclsyntax.LiteralValueExpr{
+				Val: cty.SetVal([]cty.Value{cty.StringVal("y"), cty.StringVal("x")}),
+			}},
+			want: []moduleCallInstance{
+				{Key: "x", Address: `module.a["x"]`, Module: "module.a"},
+				{Key: "y", Address: `module.a["y"]`, Module: "module.a"},
+			},
+		},
+		"constant for_each map": {
+			call: &configs.ModuleCall{Name: "a", ForEach: expr(`{ y = 1, x = 2 }`)},
+			want: []moduleCallInstance{
+				{Key: "x", Address: `module.a["x"]`, Module: "module.a"},
+				{Key: "y", Address: `module.a["y"]`, Module: "module.a"},
+			},
+		},
+		"count from variable": {
+			call: &configs.ModuleCall{Name: "a", Count: expr(`var.n`)},
+		},
+		"for_each calling a function": {
+			call: &configs.ModuleCall{Name: "a", ForEach: expr(`toset(["x"])`)},
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := moduleCallInstances(test.call, "module.a")
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("wrong result\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSensitiveSources(t *testing.T) {
+	providerAddr := addrs.NewDefaultProvider("test")
+	schemas := &tofu.Schemas{
+		Providers: map[addrs.Provider]providers.ProviderSchema{
+			providerAddr: {
+				ResourceTypes: map[string]providers.Schema{
+					"test_thing": {
+						Block: &configschema.Block{
+							Attributes: map[string]*configschema.Attribute{
+								"name":     {Type: cty.String, Optional: true},
+								"password": {Type: cty.String, Optional: true, Sensitive: true},
+							},
+						},
+					},
+					"test_other": {
+						Block: &configschema.Block{
+							Attributes: map[string]*configschema.Attribute{
+								"name": {Type: cty.String, Optional: true},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	cfg := &configs.Config{
+		Module: &configs.Module{
+			Variables: map[string]*configs.Variable{
+				"secret": {Name: "secret", Sensitive: true},
+				"plain":  {Name: "plain"},
+			},
+			ManagedResources: map[string]*configs.Resource{
+				"test_thing.a": {
+					Mode:     addrs.ManagedResourceMode,
+					Type:     "test_thing",
+					Name:     "a",
+					Provider: providerAddr,
+				},
+				"test_other.b": {
+					Mode:     addrs.ManagedResourceMode,
+					Type:     "test_other",
+					Name:     "b",
+					Provider: providerAddr,
+				},
+			},
+		},
+	}
+
+	got := sensitiveSources(cfg, schemas)
+	want := map[string]struct{}{
+		"var.secret":            {},
+		"test_thing.a":          {},
+		"test_thing.a.password": {},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("wrong result\n%s", diff)
+	}
+}
+
+func TestForwardsSensitiveOutput(t *testing.T) {
+	expr := func(src string) hcl.Expression {
+		t.Helper()
+		e, diags := hclsyntax.ParseExpression([]byte(src), "test.tf", hcl.InitialPos)
+		if diags.HasErrors() {
+			t.Fatal(diags.Error())
+		}
+		return e
+	}
+
+	root := &configs.Config{Module: &configs.Module{}}
+	root.Children = map[string]*configs.Config{
+		"db": {
+			Parent: root,
+			Path:   addrs.RootModule.Child("db"),
+			Module: &configs.Module{
+				Outputs: map[string]*configs.Output{
+					"password": {Name: "password", Sensitive: true},
+					"host":     {Name: "host"},
+				},
+			},
+		},
+	}
+
+	tests := map[string]bool{
+		`module.db.password`:           true,
+		`module.db[0].password`:        true,
+		`"${module.db.password}!"`:     true,
+		`module.db`:                    true,
+		`module.db.host`:               false,
+		`module.other.password`:        false,
+		`var.password`:                 false,
+		`"constant"`:                   false,
+		`[module.db.host, var.secret]`: false,
+	}
+	for src, want := range tests {
+		if got := forwardsSensitiveOutput(root, expr(src)); got != want {
+			t.Errorf("wrong result for %s: got %t, want %t", src, got, want)
+		}
+	}
+}
+
+func TestModuleExperiments(t *testing.T) {
+	if got := moduleExperiments(nil); got != nil {
+		t.Errorf("unexpected result for no experiments: %#v", got)
+	}
+
+	got := moduleExperiments(experiments.NewSet(
+		experiments.VariableValidation,
+		experiments.ConfigDrivenMove,
+	))
+	want := []string{"config_driven_move", "variable_validation"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("wrong result\n%s", diff)
+	}
+}
+
+func TestMarshalVariableValidations(t *testing.T) {
+	expr := func(src string) hcl.Expression {
+		t.Helper()
+		e, diags := hclsyntax.ParseExpression([]byte(src), "test.tf", hcl.InitialPos)
+		if diags.HasErrors() {
+			t.Fatal(diags.Error())
+		}
+		return e
+	}
+	rules := []*configs.CheckRule{
+		{
+			Condition:    expr(`var.name != ""`),
+			ErrorMessage: expr(`"The name must not be empty."`),
+		},
+		{
+			Condition:    expr(`length(var.name) < 10`),
+			ErrorMessage: expr(`"The name ${var.name} is too long."`),
+		},
+	}
+
+	got := marshalVariableValidations(rules, MarshalOpts{})
+	if len(got) != 2 {
+		t.Fatalf("wrong number of validations %d; want 2", len(got))
+	}
+	if got, want := got[0].ErrorMessageText, "The name must not be empty."; got != want {
+		t.Errorf("wrong error message text for constant message\ngot:  %q\nwant: %q", got, want)
+	}
+	if got := got[1].ErrorMessageText; got != "" {
+		t.Errorf("unexpected error message text for templated message: %q", got)
+	}
+	if diff := cmp.Diff([]string{"var.name"}, got[1].ErrorMessage.References); diff != "" {
+		t.Errorf("wrong references for templated message\n%s", diff)
+	}
+}
+
+func TestRootVariableDefaults(t *testing.T) {
+	cfg := &configs.Config{
+		Module: &configs.Module{
+			Variables: map[string]*configs.Variable{
+				"required": {
+					Name: "required",
+				},
+				"string": {
+					Name:    "string",
+					Default: cty.StringVal("hello"),
+				},
+				"list": {
+					Name:    "list",
+					Default: cty.ListVal([]cty.Value{cty.NumberIntVal(1), cty.NumberIntVal(2)}),
+				},
+				"null": {
+					Name:    "null",
+					Default: cty.NullVal(cty.String),
+				},
+			},
+		},
+	}
+
+	got, err := RootVariableDefaults(cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	want := map[string]json.RawMessage{
+		"required": nil,
+		"string":   json.RawMessage(`"hello"`),
+		"list":     json.RawMessage(`[1,2]`),
+		"null":     json.RawMessage(`null`),
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Error("wrong result\n" + diff)
+	}
+}
+
+func TestReferencedResources(t *testing.T) {
+	r := resource{
+		CountExpression: &expression{
+			References: []string{"data.test_type.count.value", "data.test_type.count"},
+		},
+		Expressions: map[string]any{
+			"foo": expression{
+				References: []string{"test_type.a[0].id", "test_type.a[0]", "test_type.a", "var.foo"},
+			},
+			"block": []map[string]any{
+				{
+					"bar": expression{
+						References: []string{"test_type.b.id", "test_type.b", "local.baz"},
+					},
+				},
+			},
+		},
+		Provisioners: []provisioner{
+			{
+				Expressions: map[string]any{
+					"command": expression{
+						References: []string{"test_type.a.id", "test_type.a", "self.id"},
+					},
+				},
+			},
+		},
+	}
+
+	got := referencedResources(r)
+	want := []string{"data.test_type.count", "test_type.a", "test_type.b"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Error("wrong result\n" + diff)
+	}
+
+	if got := referencedResources(resource{}); got != nil {
+		t.Errorf("unexpected result for resource with no references: %#v", got)
+	}
+}
+
+func TestMarshalIgnoreChanges(t *testing.T) {
+	traversal := func(src string) hcl.Traversal {
+		t.Helper()
+		expr, diags := hclsyntax.ParseExpression([]byte(src), "test.tf", hcl.InitialPos)
+		if diags.HasErrors() {
+			t.Fatal(diags.Error())
+		}
+		trav, diags := hcl.RelTraversalForExpr(expr)
+		if diags.HasErrors() {
+			t.Fatal(diags.Error())
+		}
+		return trav
+	}
+
+	tests := map[string]struct {
+		managed *configs.ManagedResource
+		want    *ignoreChanges
+	}{
+		"not set": {
+			managed: &configs.ManagedResource{},
+		},
+		"all": {
+			managed: &configs.ManagedResource{IgnoreAllChanges: true},
+			want:    &ignoreChanges{All: true},
+		},
+		"attributes": {
+			managed: &configs.ManagedResource{
+				IgnoreChanges: []hcl.Traversal{
+					traversal(`ami`),
+					traversal(`tags["Name"]`),
+					traversal(`ebs_block_device[0].volume_size`),
+				},
+			},
+			want: &ignoreChanges{
+				Attributes: []ignoredAttribute{
+					{
+						Attribute: "ami",
+						Path:      []json.RawMessage{json.RawMessage(`"ami"`)},
+					},
+					{
+						Attribute: `tags["Name"]`,
+						Path:      []json.RawMessage{json.RawMessage(`"tags"`), json.RawMessage(`"Name"`)},
+					},
+					{
+						Attribute: "ebs_block_device[0].volume_size",
+						Path: []json.RawMessage{
+							json.RawMessage(`"ebs_block_device"`),
+							json.RawMessage(`0`),
+							json.RawMessage(`"volume_size"`),
+						},
+					},
+				},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := marshalIgnoreChanges(test.managed)
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("wrong result\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestMarshalLifecycle(t *testing.T) {
+	expr := func(src string) hcl.Expression {
+		t.Helper()
+		expr, diags := hclsyntax.ParseExpression([]byte(src), "test.tf", hcl.InitialPos)
+		if diags.HasErrors() {
+			t.Fatal(diags.Error())
+		}
+		return expr
+	}
+
+	tests := map[string]struct {
+		resource *configs.Resource
+		schemas  *tofu.Schemas
+		want     *lifecycle
+	}{
+		"not set": {
+			resource: &configs.Resource{Managed: &configs.ManagedResource{}},
+			schemas:  &tofu.Schemas{},
+		},
+		"create_before_destroy false": {
+			resource: &configs.Resource{Managed: &configs.ManagedResource{
+				CreateBeforeDestroySet: true,
+			}},
+			schemas: &tofu.Schemas{},
+			want:    &lifecycle{CreateBeforeDestroy: ptrTo(false)},
+		},
+		"prevent_destroy": {
+			resource: &configs.Resource{Managed: &configs.ManagedResource{
+				PreventDestroy: expr(`true`),
+			}},
+			schemas: &tofu.Schemas{},
+			want: &lifecycle{
+				PreventDestroy: &expression{ConstantValue: json.RawMessage(`true`)},
+			},
+		},
+		"prevent_destroy in single-module mode": {
+			resource: &configs.Resource{Managed: &configs.ManagedResource{
+				PreventDestroy: expr(`true`),
+			}},
+		},
+		"replace_triggered_by": {
+			resource: &configs.Resource{
+				Managed: &configs.ManagedResource{},
+				TriggersReplacement: []hcl.Expression{
+					expr(`test_thing.a`),
+					expr(`test_thing.b[count.index].id`),
+					expr(`test_thing.c[0].id`),
+				},
+			},
+			want: &lifecycle{
+				ReplaceTriggeredBy: []string{"test_thing.a", "test_thing.b", "test_thing.c[0].id"},
+			},
+		},
+		"ignore_changes": {
+			resource: &configs.Resource{Managed: &configs.ManagedResource{
+				IgnoreChanges: []hcl.Traversal{
+					{hcl.TraverseAttr{Name: "ami"}},
+					{hcl.TraverseAttr{Name: "tags"}, hcl.TraverseIndex{Key: cty.StringVal("Name")}},
+				},
+			}},
+			want: &lifecycle{
+				IgnoreChanges: []string{"ami", `tags["Name"]`},
+			},
+		},
+		"ignore_changes all": {
+			resource: &configs.Resource{Managed: &configs.ManagedResource{
+				IgnoreAllChanges: true,
+			}},
+			want: &lifecycle{IgnoreChanges: []string{"all"}},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := marshalLifecycle(test.resource, test.schemas, MarshalOpts{})
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("wrong result\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRecursiveModuleSources(t *testing.T) {
+	child := func(parent *configs.Config, name, source, dir string) *configs.Config {
+		sourceAddr, err := addrs.ParseModuleSource(source)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c := &configs.Config{
+			Parent:     parent,
+			Root:       parent.Root,
+			Path:       parent.Path.Child(name),
+			SourceAddr: sourceAddr,
+			Module:     &configs.Module{SourceDir: dir},
+		}
+		if parent.Children == nil {
+			parent.Children = make(map[string]*configs.Config)
+		}
+		parent.Children[name] = c
+		return c
+	}
+
+	root := &configs.Config{Module: &configs.Module{SourceDir: "."}}
+	root.Root = root
+	a := child(root, "a", "hashicorp/foo/aws", ".terraform/modules/a")
+	b := child(a, "b", "hashicorp/bar/aws", ".terraform/modules/a.b")
+	child(b, "c", "hashicorp/foo/aws", ".terraform/modules/a.b.c")
+	child(b, "d", "hashicorp/baz/aws", ".terraform/modules/a.b.d")
+	child(root, "e", "hashicorp/bar/aws", ".terraform/modules/e")
+	x := child(root, "x", "./x", "x")
+	child(x, "y", "../x", "x/../x")
+	child(x, "z", "./z", "x/z")
+
+	got := recursiveModuleSources(root)
+	want := []string{
+		"module.a.module.b.module.c",
+		"module.x.module.y",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("wrong result\n%s", diff)
+	}
+}
+
+func TestFullyConstant(t *testing.T) {
+	constant := expression{ConstantValue: json.RawMessage(`"a"`)}
+	reference := expression{References: []string{"var.a"}}
+
+	tests := map[string]struct {
+		exprs map[string]any
+		want  bool
+	}{
+		"empty": {
+			exprs: map[string]any{},
+			want:  true,
+		},
+		"constants": {
+			exprs: map[string]any{
+				"name":  constant,
+				"block": []map[string]any{{"value": constant}},
+			},
+			want: true,
+		},
+		"reference": {
+			exprs: map[string]any{
+				"name":  constant,
+				"value": reference,
+			},
+			want: false,
+		},
+		"reference in nested block": {
+			exprs: map[string]any{
+				"name":  constant,
+				"block": []map[string]any{{"value": reference}},
+			},
+			want: false,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := fullyConstant(test.exprs); got != test.want {
+				t.Errorf("wrong result %t; want %t", got, test.want)
+			}
+		})
+	}
+}
+
+func TestResourceConfigKey(t *testing.T) {
+	resource := func(name, filename string, line int) *configs.Resource {
+		return &configs.Resource{
+			Mode: addrs.ManagedResourceMode,
+			Type: "test_thing",
+			Name: name,
+			DeclRange: hcl.Range{
+				Filename: filename,
+				Start:    hcl.Pos{Line: line, Column: 1},
+				End:      hcl.Pos{Line: line, Column: 10},
+			},
+		}
+	}
+
+	base := resourceConfigKey(resource("a", "main.tf", 1), "module.x")
+	if len(base) != 64 {
+		t.Fatalf("wrong key length %d for %q", len(base), base)
+	}
+	if got := resourceConfigKey(resource("a", "main.tf", 20), "module.x"); got != base {
+		t.Errorf("key changed when the declaration moved within its file")
+	}
+	for name, got := range map[string]string{
+		"different name":   resourceConfigKey(resource("b", "main.tf", 1), "module.x"),
+		"different file":   resourceConfigKey(resource("a", "other.tf", 1), "module.x"),
+		"different module": resourceConfigKey(resource("a", "main.tf", 1), "module.y"),
+		"root module":      resourceConfigKey(resource("a", "main.tf", 1), ""),
+	} {
+		if got == base {
+			t.Errorf("%s: key did not change", name)
+		}
+	}
+}
+
+func TestMarshalResources_providerInferred(t *testing.T) {
+	resources := map[string]*configs.Resource{
+		"test_thing.implied": {
+			Mode:     addrs.ManagedResourceMode,
+			Type:     "test_thing",
+			Name:     "implied",
+			Config:   &hclsyntax.Body{},
+			Provider: addrs.NewDefaultProvider("test"),
+		},
+		"test_thing.explicit": {
+			Mode:     addrs.ManagedResourceMode,
+			Type:     "test_thing",
+			Name:     "explicit",
+			Config:   &hclsyntax.Body{},
+			Provider: addrs.NewDefaultProvider("test"),
+			ProviderConfigRef: &configs.ProviderConfigRef{
+				Name:  "test",
+				Alias: "other",
+			},
+		},
+	}
+
+	got, err := marshalResources(resources, nil, "", MarshalOpts{ProviderInferred: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	inferred := make(map[string]bool)
+	for _, r := range got {
+		inferred[r.Address] = r.ProviderInferred
+	}
+	want := map[string]bool{
+		"test_thing.explicit": false,
+		"test_thing.implied":  true,
+	}
+	if diff := cmp.Diff(want, inferred); diff != "" {
+		t.Errorf("wrong result\n%s", diff)
+	}
+
+	got, err = marshalResources(resources, nil, "", MarshalOpts{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	for _, r := range got {
+		if r.ProviderInferred {
+			t.Errorf("%s has provider_inferred without the ProviderInferred option", r.Address)
+		}
+	}
+}
+
+func TestDeprecatedAttributes(t *testing.T) {
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"current": {Type: cty.String, Optional: true},
+			"old":     {Type: cty.String, Optional: true, Deprecated: true},
+			"older":   {Type: cty.String, Optional: true, Deprecated: true},
+			"unused":  {Type: cty.String, Optional: true, Deprecated: true},
+		},
+		BlockTypes: map[string]*configschema.NestedBlock{
+			"nested": {Nesting: configschema.NestingList},
+		},
+	}
+	exprs := expressions{
+		"current": expression{},
+		"older":   expression{},
+		"old":     expression{},
+		"nested":  []map[string]any{},
+	}
+
+	got := deprecatedAttributes(exprs, schema)
+	want := []string{"old", "older"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("wrong result\n%s", diff)
+	}
+
+	if got := deprecatedAttributes(expressions{"current": expression{}}, schema); got != nil {
+		t.Errorf("unexpected result %#v; want nil", got)
+	}
+}
+
+func TestResourceBlockRange(t *testing.T) {
+	src := "resource \"test_instance\" \"a\" {\n  ami = \"foo\"\n}\n"
+	file, diags := hclsyntax.ParseConfig([]byte(src), "main.tf", hcl.InitialPos)
+	if diags.HasErrors() {
+		t.Fatal(diags.Error())
+	}
+	block := file.Body.(*hclsyntax.Body).Blocks[0]
+
+	got := resourceBlockRange(&configs.Resource{
+		DeclRange: block.DefRange(),
+		Config:    block.Body,
+	})
+	want := &sourceRange{
+		Filename: "main.tf",
+		Start:    sourcePos{Line: 1, Column: 1, Byte: 0},
+		End:      sourcePos{Line: 3, Column: 2, Byte: len(src) - 1},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("wrong result\n%s", diff)
+	}
+
+	if got := resourceBlockRange(&configs.Resource{Config: block.Body}); got != nil {
+		t.Errorf("unexpected range for synthetic resource: %#v", got)
+	}
+}
+
+func TestMarshalConnection(t *testing.T) {
+	parseConn := func(src string) *configs.Connection {
+		file, diags := hclsyntax.ParseConfig([]byte(src), "main.tf", hcl.InitialPos)
+		if diags.HasErrors() {
+			t.Fatal(diags.Error())
+		}
+		return &configs.Connection{Config: file.Body}
+	}
+	resourceConn := parseConn(`
+type        = "ssh"
+host        = self.public_ip
+port        = 2222
+agent       = false
+password    = var.password
+private_key = file("id_rsa")
+bastion_password = "hunter2"
+`)
+	provisionerConn := parseConn(`host = "example.com"`)
+
+	got := marshalConnection(resourceConn, nil, MarshalOpts{})
+	want := expressions{
+		"type":  expression{ConstantValue: json.RawMessage(`"ssh"`)},
+		"host":  expression{References: []string{"self.public_ip", "self"}},
+		"port":  expression{ConstantValue: json.RawMessage(`2222`)},
+		"agent": expression{ConstantValue: json.RawMessage(`false`)},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("wrong result for resource connection\n%s", diff)
+	}
+
+	got = marshalConnection(resourceConn, provisionerConn, MarshalOpts{})
+	want = expressions{
+		"host": expression{ConstantValue: json.RawMessage(`"example.com"`)},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("wrong result for provisioner connection\n%s", diff)
+	}
+
+	if got := marshalConnection(nil, nil, MarshalOpts{}); got != nil {
+		t.Errorf("unexpected result without a connection block: %#v", got)
+	}
+}
+
+func TestTypeKindAndDepth(t *testing.T) {
+	tests := []struct {
+		ty        cty.Type
+		wantKind  string
+		wantDepth int
+	}{
+		{cty.DynamicPseudoType, "any", 0},
+		{cty.String, "primitive", 0},
+		{cty.List(cty.String), "collection", 1},
+		{cty.Map(cty.Set(cty.Number)), "collection", 2},
+		{cty.EmptyObject, "structural", 1},
+		{cty.Tuple([]cty.Type{cty.Bool, cty.List(cty.String)}), "structural", 2},
+		{
+			cty.Object(map[string]cty.Type{
+				"name": cty.String,
+				"rules": cty.List(cty.Object(map[string]cty.Type{
+					"ports": cty.List(cty.Number),
+				})),
+			}),
+			"structural", 4,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.ty.FriendlyName(), func(t *testing.T) {
+			if got := typeKind(test.ty); got != test.wantKind {
+				t.Errorf("wrong kind %q; want %q", got, test.wantKind)
+			}
+			if got := typeDepth(test.ty); got != test.wantDepth {
+				t.Errorf("wrong depth %d; want %d", got, test.wantDepth)
+			}
+		})
+	}
+}
+
+func TestInlineProviderConfigs(t *testing.T) {
+	pcs := map[string]providerConfig{
+		"test": {
+			Name:     "test",
+			FullName: "registry.opentofu.org/hashicorp/test",
+		},
+	}
+	m := module{
+		Resources: []resource{
+			{Address: "test_instance.a", ProviderConfigKey: "test"},
+			{Address: "test_instance.b", ProviderConfigKey: "dangling"},
+		},
+		ModuleCalls: map[string]moduleCall{
+			"child": {
+				Module: &module{
+					Resources: []resource{
+						{Address: "test_instance.c", ProviderConfigKey: "test"},
+					},
+				},
+			},
+		},
+	}
+
+	inlineProviderConfigs(&m, pcs)
+
+	want := pcs["test"].FullName
+	if got := m.Resources[0].ProviderConfig; got == nil || got.FullName != want {
+		t.Errorf("wrong provider config for test_instance.a: %#v", got)
+	}
+	if got := m.Resources[1].ProviderConfig; got != nil {
+		t.Errorf("unexpected provider config for dangling key: %#v", got)
+	}
+	if got := m.ModuleCalls["child"].Module.Resources[0].ProviderConfig; got == nil || got.FullName != want {
+		t.Errorf("wrong provider config for module.child.test_instance.c: %#v", got)
+	}
+}
+
+func TestProviderResolutionPath(t *testing.T) {
+	// The root module passes its aliased configuration to module.a as the
+	// default configuration, which module.a then passes on to module.a.b.
+	const fullName = "registry.opentofu.org/hashicorp/test"
+	pcs := map[string]providerConfig{
+		"test.foo": {Name: "test", FullName: fullName},
+	}
+	add := func(key, parentKey string) {
+		p := providerConfig{Name: "test", FullName: fullName}
+		p.resolutionPath = sourceProviderKeyPath(parentKey, p.FullName, pcs)
+		p.parentKey = findSourceProviderKey(parentKey, p.FullName, pcs)
+		pcs[key] = p
+	}
+	add("module.a:test", "test.foo")
+	add("module.a.module.b:test", "module.a:test")
+
+	if got, want := pcs["module.a.module.b:test"].parentKey, "test.foo"; got != want {
+		t.Fatalf("wrong parent key %q; want %q", got, want)
+	}
+
+	m := module{
+		Resources: []resource{
+			{Address: "test_instance.a", ProviderConfigKey: "test.foo"},
+			{Address: "test_instance.b", ProviderConfigKey: "dangling"},
+		},
+		ModuleCalls: map[string]moduleCall{
+			"a": {
+				Module: &module{
+					ModuleCalls: map[string]moduleCall{
+						"b": {
+							Module: &module{
+								Resources: []resource{
+									{Address: "test_instance.c", ProviderConfigKey: "module.a.module.b:test"},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	setProviderResolutionPaths(&m, pcs)
+	normalizeModuleProviderKeys(&m, pcs)
+
+	if diff := cmp.Diff([]string{"test.foo"}, m.Resources[0].ProviderResolutionPath); diff != "" {
+		t.Errorf("wrong path for test_instance.a:\n%s", diff)
+	}
+	if got := m.Resources[1].ProviderResolutionPath; got != nil {
+		t.Errorf("unexpected path for dangling key: %#v", got)
+	}
+	r := m.ModuleCalls["a"].Module.ModuleCalls["b"].Module.Resources[0]
+	want := []string{"module.a.module.b:test", "module.a:test", "test.foo"}
+	if diff := cmp.Diff(want, r.ProviderResolutionPath); diff != "" {
+		t.Errorf("wrong path for module.a.module.b.test_instance.c:\n%s", diff)
+	}
+	if r.ProviderConfigKey != "test.foo" {
+		t.Errorf("wrong normalized key %q", r.ProviderConfigKey)
+	}
+}
+
+func TestVariableBindings(t *testing.T) {
+	got := variableBindings(map[string]any{
+		"password": expression{References: []string{"var.db_password"}},
+		"subnets":  expression{References: []string{"aws_subnet.a.id", "aws_subnet.a"}},
+		"name":     expression{ConstantValue: json.RawMessage(`"db"`)},
+	})
+	want := map[string][]string{
+		"password": {"var.db_password"},
+		"subnets":  {"aws_subnet.a.id", "aws_subnet.a"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("wrong result\n%s", diff)
+	}
+
+	if got := variableBindings(map[string]any{
+		"name": expression{ConstantValue: json.RawMessage(`"db"`)},
+	}); got != nil {
+		t.Errorf("unexpected bindings for constant arguments: %#v", got)
+	}
+}
+
+func TestSetEffectiveCreateBeforeDestroy(t *testing.T) {
+	// c declares create_before_destroy and depends on b, which refers to a,
+	// so all three are forced to use it. d refers to c, but is a dependent
+	// rather than a dependency, so it is unaffected.
+	rs := []resource{
+		{Address: "test_thing.a"},
+		{Address: "test_thing.b", ReferencedResources: []string{"test_thing.a", "data.test_thing.x"}},
+		{Address: "test_thing.c", DependsOn: []string{"test_thing.b"}},
+		{Address: "test_thing.d", ReferencedResources: []string{"test_thing.c"}},
+	}
+	rcs := map[string]*configs.Resource{
+		"test_thing.a": {Managed: &configs.ManagedResource{}},
+		"test_thing.b": {Managed: &configs.ManagedResource{}},
+		"test_thing.c": {Managed: &configs.ManagedResource{CreateBeforeDestroy: true}},
+		"test_thing.d": {Managed: &configs.ManagedResource{}},
+	}
+
+	setEffectiveCreateBeforeDestroy(rs, rcs)
+
+	want := map[string]bool{
+		"test_thing.a": true,
+		"test_thing.b": true,
+		"test_thing.c": true,
+		"test_thing.d": false,
+	}
+	for _, r := range rs {
+		if r.EffectiveCreateBeforeDestroy == nil {
+			t.Errorf("%s: effective_create_before_destroy not set", r.Address)
+			continue
+		}
+		if got := *r.EffectiveCreateBeforeDestroy; got != want[r.Address] {
+			t.Errorf("%s: got %t, want %t", r.Address, got, want[r.Address])
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	pcs := map[string]providerConfig{
+		"test": {
+			Name:     "test",
+			FullName: "registry.opentofu.org/hashicorp/test",
+		},
+	}
+
+	tests := map[string]struct {
+		Input   config
+		WantErr string
+	}{
+		"valid": {
+			Input: config{
+				ProviderConfigs: pcs,
+				RootModule: module{
+					Resources: []resource{
+						{Address: "test_instance.a", ProviderConfigKey: "test"},
+					},
+					ModuleCalls: map[string]moduleCall{
+						"child": {
+							Module: &module{
+								Resources: []resource{
+									{Address: "test_instance.b", ProviderConfigKey: "test"},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		"dangling key in child module": {
+			Input: config{
+				ProviderConfigs: pcs,
+				RootModule: module{
+					ModuleCalls: map[string]moduleCall{
+						"child": {
+							Module: &module{
+								Resources: []resource{
+									{Address: "test_instance.b", ProviderConfigKey: "module.child:test"},
+								},
+							},
+						},
+					},
+				},
+			},
+			WantErr: `module call child: resource test_instance.b has provider_config_key "module.child:test", which does not match any provider configuration`,
+		},
+		"dangling key passed to child module": {
+			Input: config{
+				ProviderConfigs: pcs,
+				RootModule: module{
+					ModuleCalls: map[string]moduleCall{
+						"child": {
+							ProviderConfigKeys: map[string]string{"test": "test.alt"},
+						},
+					},
+				},
+			},
+			WantErr: `module call child passes test from provider_config_key "test.alt", which does not match any provider configuration`,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := validate(test.Input)
+			switch {
+			case test.WantErr == "" && err != nil:
+				t.Fatalf("unexpected error: %s", err)
+			case test.WantErr != "" && err == nil:
+				t.Fatal("unexpected success; want error")
+			case test.WantErr != "" && err.Error() != test.WantErr:
+				t.Errorf("wrong error\ngot:  %s\nwant: %s", err, test.WantErr)
+			}
+		})
+	}
+}
+
+// ptrTo is a helper to compensate for the fact that Go doesn't allow
+// using the '&' operator unless the operand is directly addressable.
+//
+// Instead then, this function returns a pointer to a copy of the given
+// value.
+func ptrTo[T any](v T) *T {
+	return &v
+}
+
+func TestProviderInheritanceDepth(t *testing.T) {
+	// The root module passes its aliased configuration to module.a as the
+	// default configuration, which module.a then passes on to module.a.b.
+	const fullName = "registry.opentofu.org/hashicorp/test"
+	pcs := map[string]providerConfig{
+		"test.foo": {Name: "test", FullName: fullName},
+		"test":     {Name: "test", FullName: fullName},
+		"test.bar": {Name: "test", FullName: fullName},
+	}
+	add := func(key, parentKey string) {
+		p := providerConfig{Name: "test", FullName: fullName}
+		p.resolutionPath = sourceProviderKeyPath(parentKey, p.FullName, pcs)
+		p.parentKey = findSourceProviderKey(parentKey, p.FullName, pcs)
+		pcs[key] = p
+	}
+	add("module.a:test", "test.foo")
+	add("module.a.module.b:test", "module.a:test")
+
+	m := module{
+		Resources: []resource{
+			{Address: "test_instance.a", ProviderConfigKey: "test.foo"},
+			{Address: "test_instance.b", ProviderConfigKey: "test"},
+		},
+		ModuleCalls: map[string]moduleCall{
+			"a": {
+				Module: &module{
+					Resources: []resource{
+						{Address: "test_instance.c", ProviderConfigKey: "module.a:test"},
+					},
+					ModuleCalls: map[string]moduleCall{
+						"b": {
+							Module: &module{
+								Resources: []resource{
+									{Address: "test_instance.d", ProviderConfigKey: "module.a.module.b:test"},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	setProviderInheritanceDepths(m, pcs)
+
+	depth := func(key string) any {
+		if d := pcs[key].InheritanceDepth; d != nil {
+			return *d
+		}
+		return nil
+	}
+	for key, want := range map[string]any{
+		"test.foo": 2,
+		"test":     0,
+		"test.bar": nil,
+	} {
+		if got := depth(key); got != want {
+			t.Errorf("wrong depth for %s: got %v, want %v", key, got, want)
+		}
+	}
+}
+
+func TestMarshal_deterministic(t *testing.T) {
+	module := func(resourceNames ...string) *configs.Module {
+		rs := make(map[string]*configs.Resource)
+		for _, name := range resourceNames {
+			rs["test_thing."+name] = &configs.Resource{
+				Mode:     addrs.ManagedResourceMode,
+				Type:     "test_thing",
+				Name:     name,
+				Config:   &hclsyntax.Body{},
+				Provider: addrs.NewDefaultProvider("test"),
+			}
+		}
+		return &configs.Module{
+			ProviderRequirements: &configs.RequiredProviders{
+				RequiredProviders: map[string]*configs.RequiredProvider{},
+			},
+			ProviderConfigs:  map[string]*configs.Provider{},
+			ManagedResources: rs,
+			ModuleCalls:      map[string]*configs.ModuleCall{},
+		}
+	}
+
+	// The root module passes its aliased configuration to some of its child
+	// modules, which pass it on in turn, while the others inherit the
+	// default configuration.
+	root := &configs.Config{Module: module("a", "b")}
+	root.Module.ProviderConfigs["test"] = &configs.Provider{Name: "test", Config: hcl.EmptyBody()}
+	root.Module.ProviderConfigs["test.alt"] = &configs.Provider{Name: "test", Alias: "alt", Config: hcl.EmptyBody()}
+	root.Root = root
+	root.Children = make(map[string]*configs.Config)
+	for i, name := range []string{"a", "b", "c", "d", "e", "f"} {
+		call := &configs.ModuleCall{Name: name, Config: &hclsyntax.Body{}}
+		if i%2 == 0 {
+			call.Providers = []configs.PassedProviderConfig{{
+				InChild:  &configs.ProviderConfigRef{Name: "test"},
+				InParent: &configs.ProviderConfigRef{Name: "test", Alias: "alt"},
+			}}
+		}
+		root.Module.ModuleCalls[name] = call
+		child := &configs.Config{
+			Parent: root,
+			Root:   root,
+			Path:   addrs.RootModule.Child(name),
+			Module: module("x"),
+		}
+		child.Module.ModuleCalls["leaf"] = &configs.ModuleCall{Name: "leaf", Config: &hclsyntax.Body{}}
+		child.Children = map[string]*configs.Config{
+			"leaf": {
+				Parent: child,
+				Root:   root,
+				Path:   child.Path.Child("leaf"),
+				Module: module("y"),
+			},
+		}
+		root.Children[name] = child
+	}
+
+	schemas := &tofu.Schemas{
+		Providers: map[addrs.Provider]providers.ProviderSchema{
+			addrs.NewDefaultProvider("test"): {
+				ResourceTypes: map[string]providers.Schema{
+					"test_thing": {Block: &configschema.Block{}},
+				},
+			},
+		},
+	}
+
+	want, err := Marshal(root, schemas)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	output, err := marshalConfig(root, schemas, MarshalOpts{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := validate(output); err != nil {
+		t.Errorf("invalid configuration representation: %s", err)
+	}
+	for i := 0; i < 100; i++ {
+		got, err := Marshal(root, schemas)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Fatalf("result %d differs from the first\ngot:  %s\nwant: %s", i, got, want)
+		}
+	}
 }
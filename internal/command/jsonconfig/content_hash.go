@@ -0,0 +1,105 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+// Copyright (c) 2023 HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package jsonconfig
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+)
+
+// ModuleContentHashes returns the content hash of each module described in
+// a result of [MarshalWithOpts] that was produced with
+// [MarshalOpts.ContentHashes], keyed by module path, with the root module
+// under the empty string.
+//
+// The result is suitable for use as [MarshalOpts.BaseContentHashes] when
+// marshalling a later version of the same configuration.
+func ModuleContentHashes(src []byte) (map[string]string, error) {
+	var c config
+	if err := json.Unmarshal(src, &c); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+	ret := make(map[string]string)
+	collectContentHashes(c.RootModule, "", ret)
+	return ret, nil
+}
+
+func collectContentHashes(m module, path string, into map[string]string) {
+	if m.ContentHash != "" {
+		into[path] = m.ContentHash
+	}
+	for name, mc := range m.ModuleCalls {
+		if mc.Module != nil {
+			collectContentHashes(*mc.Module, childModulePath(path, name), into)
+		}
+	}
+}
+
+// setContentHashes populates the ContentHash field of the given module and
+// all of its descendants, replacing any whose hash matches the one for its
+// path in base with a stub.
+func setContentHashes(m *module, base map[string]string) error {
+	return setContentHashesPath(m, "", base)
+}
+
+func setContentHashesPath(m *module, path string, base map[string]string) error {
+	// Child modules are hashed separately, so each module's hash covers
+	// only its own content and the module calls it makes.
+	children := make(map[string]*module, len(m.ModuleCalls))
+	calls := make(map[string]moduleCall, len(m.ModuleCalls))
+	for name, mc := range m.ModuleCalls {
+		children[name] = mc.Module
+		mc.Module = nil
+		calls[name] = mc
+	}
+	toHash := *m
+	toHash.ModuleCalls = calls
+	toHash.ContentHash = ""
+	toHash.Unchanged = false
+	src, err := json.Marshal(toHash)
+	if err != nil {
+		return fmt.Errorf("failed to hash module %q: %w", path, err)
+	}
+	sum := sha256.Sum256(src)
+	hash := hex.EncodeToString(sum[:])
+
+	unchanged := base != nil && base[path] == hash
+	if unchanged {
+		*m = module{
+			ContentHash: hash,
+			Unchanged:   true,
+		}
+		if len(children) > 0 {
+			m.ModuleCalls = make(map[string]moduleCall, len(children))
+		}
+	} else {
+		m.ContentHash = hash
+	}
+
+	for name, child := range children {
+		mc := m.ModuleCalls[name]
+		if unchanged {
+			mc = moduleCall{}
+		}
+		if child != nil {
+			if err := setContentHashesPath(child, childModulePath(path, name), base); err != nil {
+				return err
+			}
+			mc.Module = child
+		}
+		m.ModuleCalls[name] = mc
+	}
+	return nil
+}
+
+func childModulePath(path, name string) string {
+	if path == "" {
+		return "module." + name
+	}
+	return path + ".module." + name
+}
@@ -0,0 +1,100 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+// Copyright (c) 2023 HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package jsonconfig
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/opentofu/opentofu/internal/addrs"
+	"github.com/opentofu/opentofu/internal/configs"
+	"github.com/opentofu/opentofu/internal/tofu"
+)
+
+func TestMarshalWithOpts_contentHashes(t *testing.T) {
+	makeConfig := func(childDefault string) *configs.Config {
+		root := &configs.Config{
+			Module: &configs.Module{
+				ProviderRequirements: &configs.RequiredProviders{},
+				Variables: map[string]*configs.Variable{
+					"a": {Name: "a", Default: cty.StringVal("a")},
+				},
+				ModuleCalls: map[string]*configs.ModuleCall{
+					"child": {Name: "child", Config: &hclsyntax.Body{}},
+				},
+			},
+		}
+		root.Root = root
+		root.Children = map[string]*configs.Config{
+			"child": {
+				Parent: root,
+				Root:   root,
+				Path:   addrs.RootModule.Child("child"),
+				Module: &configs.Module{
+					ProviderRequirements: &configs.RequiredProviders{},
+					Variables: map[string]*configs.Variable{
+						"b": {Name: "b", Default: cty.StringVal(childDefault)},
+					},
+				},
+			},
+		}
+		return root
+	}
+
+	first, err := MarshalWithOpts(makeConfig("b"), &tofu.Schemas{}, MarshalOpts{ContentHashes: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	base, err := ModuleContentHashes(first)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(base) != 2 || base[""] == "" || base["module.child"] == "" {
+		t.Fatalf("wrong content hashes %#v", base)
+	}
+
+	// Nothing has changed, so every module is a stub.
+	got, err := MarshalWithOpts(makeConfig("b"), &tofu.Schemas{}, MarshalOpts{BaseContentHashes: base})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	var gotConfig config
+	if err := json.Unmarshal(got, &gotConfig); err != nil {
+		t.Fatal(err)
+	}
+	root := gotConfig.RootModule
+	child := root.ModuleCalls["child"].Module
+	if !root.Unchanged || root.Variables != nil || root.ContentHash != base[""] {
+		t.Errorf("root module is not an unchanged stub: %#v", root)
+	}
+	if child == nil || !child.Unchanged || child.Variables != nil {
+		t.Errorf("child module is not an unchanged stub: %#v", child)
+	}
+
+	// Changing only the child module leaves the root module as a stub.
+	got, err = MarshalWithOpts(makeConfig("changed"), &tofu.Schemas{}, MarshalOpts{BaseContentHashes: base})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	gotConfig = config{}
+	if err := json.Unmarshal(got, &gotConfig); err != nil {
+		t.Fatal(err)
+	}
+	root = gotConfig.RootModule
+	child = root.ModuleCalls["child"].Module
+	if !root.Unchanged {
+		t.Errorf("root module should be unchanged")
+	}
+	if child == nil || child.Unchanged || child.Variables["b"] == nil {
+		t.Fatalf("child module should be included in full: %#v", child)
+	}
+	if child.ContentHash == "" || child.ContentHash == base["module.child"] {
+		t.Errorf("child module has wrong content hash %q", child.ContentHash)
+	}
+}
@@ -0,0 +1,72 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+// Copyright (c) 2023 HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package jsonconfig
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/opentofu/opentofu/internal/addrs"
+	"github.com/opentofu/opentofu/internal/configs"
+)
+
+// DeclaredAddresses returns the sorted addresses of all of the objects that
+// the modules in the given configuration declare and that expressions can
+// refer to: resources of each mode, input variables, local values, output
+// values, and module calls.
+//
+// Each address uses the same syntax as the references in the result of
+// [Marshal], prefixed with the path of its module, such as
+// "module.child.var.region", for objects outside of the root module.
+// Together with those references, this allows finding references to objects
+// that are not declared.
+func DeclaredAddresses(c *configs.Config) []string {
+	var ret []string
+
+	var walk func(c *configs.Config)
+	walk = func(c *configs.Config) {
+		if c == nil || c.Module == nil {
+			return
+		}
+		prefix := ""
+		if !c.Path.IsRoot() {
+			prefix = c.Path.String() + "."
+		}
+		add := func(addr fmt.Stringer) {
+			ret = append(ret, prefix+addr.String())
+		}
+
+		for _, rcs := range []map[string]*configs.Resource{
+			c.Module.ManagedResources,
+			c.Module.DataResources,
+			c.Module.EphemeralResources,
+		} {
+			for _, r := range rcs {
+				add(r.Addr())
+			}
+		}
+		for name := range c.Module.Variables {
+			add(addrs.InputVariable{Name: name})
+		}
+		for name := range c.Module.Locals {
+			add(addrs.LocalValue{Name: name})
+		}
+		for name := range c.Module.Outputs {
+			add(addrs.OutputValue{Name: name})
+		}
+		for name := range c.Module.ModuleCalls {
+			add(addrs.ModuleCall{Name: name})
+		}
+
+		for _, child := range c.Children {
+			walk(child)
+		}
+	}
+	walk(c)
+
+	sort.Strings(ret)
+	return ret
+}
@@ -0,0 +1,65 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+// Copyright (c) 2023 HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package jsonconfig
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/opentofu/opentofu/internal/addrs"
+	"github.com/opentofu/opentofu/internal/configs"
+)
+
+func TestDeclaredAddresses(t *testing.T) {
+	root := &configs.Config{
+		Module: &configs.Module{
+			Variables: map[string]*configs.Variable{
+				"region": {Name: "region"},
+			},
+			Locals: map[string]*configs.Local{
+				"name": {Name: "name"},
+			},
+			ManagedResources: map[string]*configs.Resource{
+				"test_thing.a": {Mode: addrs.ManagedResourceMode, Type: "test_thing", Name: "a"},
+			},
+			DataResources: map[string]*configs.Resource{
+				"data.test_thing.b": {Mode: addrs.DataResourceMode, Type: "test_thing", Name: "b"},
+			},
+			ModuleCalls: map[string]*configs.ModuleCall{
+				"child": {Name: "child"},
+			},
+		},
+	}
+	root.Children = map[string]*configs.Config{
+		"child": {
+			Path:   addrs.RootModule.Child("child"),
+			Parent: root,
+			Module: &configs.Module{
+				Outputs: map[string]*configs.Output{
+					"id": {Name: "id"},
+				},
+				EphemeralResources: map[string]*configs.Resource{
+					"ephemeral.test_thing.c": {Mode: addrs.EphemeralResourceMode, Type: "test_thing", Name: "c"},
+				},
+			},
+		},
+	}
+
+	got := DeclaredAddresses(root)
+	want := []string{
+		"data.test_thing.b",
+		"local.name",
+		"module.child",
+		"module.child.ephemeral.test_thing.c",
+		"module.child.output.id",
+		"test_thing.a",
+		"var.region",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("wrong result\n%s", diff)
+	}
+}
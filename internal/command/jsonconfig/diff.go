@@ -0,0 +1,165 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+// Copyright (c) 2023 HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package jsonconfig
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// ConfigDiff describes the differences between two configuration
+// representations, as returned by [DiffConfigs].
+type ConfigDiff struct {
+	// Resources are identified by their absolute addresses, such as
+	// "module.a.aws_instance.example".
+	Resources ObjectsDiff
+
+	// ProviderConfigs are identified by their keys in "provider_config".
+	ProviderConfigs ObjectsDiff
+
+	// Variables and Outputs are identified by their absolute addresses,
+	// such as "var.example" or "module.a.output.example".
+	Variables ObjectsDiff
+	Outputs   ObjectsDiff
+}
+
+// ObjectsDiff lists the identifiers of objects of a particular kind that
+// were added, removed, or changed between two configuration representations.
+// Each list is sorted and is nil if there are no such objects.
+type ObjectsDiff struct {
+	Added   []string
+	Removed []string
+	Changed []string
+}
+
+// Empty returns true if the receiver describes no differences.
+func (d ObjectsDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// Empty returns true if the receiver describes no differences.
+func (d ConfigDiff) Empty() bool {
+	return d.Resources.Empty() && d.ProviderConfigs.Empty() && d.Variables.Empty() && d.Outputs.Empty()
+}
+
+// DiffConfigs compares two configuration representations produced by
+// [Marshal] and reports which resources, provider configurations, variables,
+// and outputs were added, removed, or changed between a and b.
+//
+// An object is considered changed if any part of its representation differs,
+// including the expressions in its configuration. The representation
+// produced with [MarshalOpts.Ordered] is not supported.
+func DiffConfigs(a, b []byte) (ConfigDiff, error) {
+	var ret ConfigDiff
+
+	var configA, configB config
+	if err := json.Unmarshal(a, &configA); err != nil {
+		return ret, fmt.Errorf("invalid first configuration: %w", err)
+	}
+	if err := json.Unmarshal(b, &configB); err != nil {
+		return ret, fmt.Errorf("invalid second configuration: %w", err)
+	}
+
+	flatA, err := flattenConfig(configA)
+	if err != nil {
+		return ret, err
+	}
+	flatB, err := flattenConfig(configB)
+	if err != nil {
+		return ret, err
+	}
+
+	ret.Resources = diffObjects(flatA.resources, flatB.resources)
+	ret.ProviderConfigs = diffObjects(flatA.providerConfigs, flatB.providerConfigs)
+	ret.Variables = diffObjects(flatA.variables, flatB.variables)
+	ret.Outputs = diffObjects(flatA.outputs, flatB.outputs)
+	return ret, nil
+}
+
+// flatConfig is the JSON encoding of each of the objects in a configuration
+// that [DiffConfigs] compares, keyed by the identifiers it reports.
+type flatConfig struct {
+	resources       map[string][]byte
+	providerConfigs map[string][]byte
+	variables       map[string][]byte
+	outputs         map[string][]byte
+}
+
+func flattenConfig(c config) (flatConfig, error) {
+	ret := flatConfig{
+		resources:       make(map[string][]byte),
+		providerConfigs: make(map[string][]byte),
+		variables:       make(map[string][]byte),
+		outputs:         make(map[string][]byte),
+	}
+	for k, pc := range c.ProviderConfigs {
+		if err := addFlatObject(ret.providerConfigs, k, pc); err != nil {
+			return ret, err
+		}
+	}
+	err := flattenModule(c.RootModule, "", ret)
+	return ret, err
+}
+
+func flattenModule(m module, prefix string, into flatConfig) error {
+	for _, r := range m.Resources {
+		if err := addFlatObject(into.resources, prefix+r.Address, r); err != nil {
+			return err
+		}
+	}
+	for name, v := range m.Variables {
+		if err := addFlatObject(into.variables, prefix+"var."+name, v); err != nil {
+			return err
+		}
+	}
+	for name, o := range m.Outputs {
+		if err := addFlatObject(into.outputs, prefix+"output."+name, o); err != nil {
+			return err
+		}
+	}
+	for name, mc := range m.ModuleCalls {
+		if mc.Module == nil {
+			continue
+		}
+		if err := flattenModule(*mc.Module, prefix+"module."+name+".", into); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func addFlatObject(into map[string][]byte, key string, obj any) error {
+	src, err := json.Marshal(obj)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", key, err)
+	}
+	into[key] = src
+	return nil
+}
+
+func diffObjects(a, b map[string][]byte) ObjectsDiff {
+	var ret ObjectsDiff
+	for k, srcA := range a {
+		srcB, ok := b[k]
+		switch {
+		case !ok:
+			ret.Removed = append(ret.Removed, k)
+		case !bytes.Equal(srcA, srcB):
+			ret.Changed = append(ret.Changed, k)
+		}
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			ret.Added = append(ret.Added, k)
+		}
+	}
+	sort.Strings(ret.Added)
+	sort.Strings(ret.Removed)
+	sort.Strings(ret.Changed)
+	return ret
+}
@@ -0,0 +1,122 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+// Copyright (c) 2023 HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package jsonconfig
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDiffConfigs(t *testing.T) {
+	a := `{
+		"provider_config": {
+			"test": {"name": "test", "full_name": "registry.opentofu.org/hashicorp/test"},
+			"old": {"name": "old", "full_name": "registry.opentofu.org/hashicorp/old"}
+		},
+		"root_module": {
+			"resources": [
+				{"address": "test_thing.same", "mode": "managed", "type": "test_thing", "name": "same", "provider_config_key": "test"},
+				{"address": "test_thing.changed", "mode": "managed", "type": "test_thing", "name": "changed", "provider_config_key": "test",
+					"expressions": {"value": {"constant_value": "a"}}},
+				{"address": "test_thing.removed", "mode": "managed", "type": "test_thing", "name": "removed", "provider_config_key": "test"}
+			],
+			"variables": {
+				"same": {"required": true},
+				"changed": {"default": "a"}
+			},
+			"outputs": {
+				"removed": {"expression": {"references": ["var.same"]}}
+			},
+			"module_calls": {
+				"child": {
+					"source": "./child",
+					"module": {
+						"resources": [
+							{"address": "test_thing.x", "mode": "managed", "type": "test_thing", "name": "x", "provider_config_key": "child:test"}
+						]
+					}
+				}
+			}
+		}
+	}`
+	b := `{
+		"provider_config": {
+			"test": {"name": "test", "full_name": "registry.opentofu.org/hashicorp/test"},
+			"new": {"name": "new", "full_name": "registry.opentofu.org/hashicorp/new"}
+		},
+		"root_module": {
+			"resources": [
+				{"address": "test_thing.same", "mode": "managed", "type": "test_thing", "name": "same", "provider_config_key": "test"},
+				{"address": "test_thing.changed", "mode": "managed", "type": "test_thing", "name": "changed", "provider_config_key": "test",
+					"expressions": {"value": {"constant_value": "b"}}},
+				{"address": "test_thing.added", "mode": "managed", "type": "test_thing", "name": "added", "provider_config_key": "test"}
+			],
+			"variables": {
+				"same": {"required": true},
+				"changed": {"default": "b"},
+				"added": {"required": true}
+			},
+			"outputs": {},
+			"module_calls": {
+				"child": {
+					"source": "./child",
+					"module": {
+						"resources": [
+							{"address": "test_thing.x", "mode": "managed", "type": "test_thing", "name": "x", "provider_config_key": "child:test"},
+							{"address": "test_thing.y", "mode": "managed", "type": "test_thing", "name": "y", "provider_config_key": "child:test"}
+						],
+						"outputs": {
+							"added": {"expression": {"constant_value": 1}}
+						}
+					}
+				}
+			}
+		}
+	}`
+
+	got, err := DiffConfigs([]byte(a), []byte(b))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	want := ConfigDiff{
+		Resources: ObjectsDiff{
+			Added:   []string{"module.child.test_thing.y", "test_thing.added"},
+			Removed: []string{"test_thing.removed"},
+			Changed: []string{"test_thing.changed"},
+		},
+		ProviderConfigs: ObjectsDiff{
+			Added:   []string{"new"},
+			Removed: []string{"old"},
+		},
+		Variables: ObjectsDiff{
+			Added:   []string{"var.added"},
+			Changed: []string{"var.changed"},
+		},
+		Outputs: ObjectsDiff{
+			Added:   []string{"module.child.output.added"},
+			Removed: []string{"output.removed"},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("wrong result\n%s", diff)
+	}
+
+	got, err = DiffConfigs([]byte(a), []byte(a))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !got.Empty() {
+		t.Errorf("unexpected differences comparing a configuration to itself: %#v", got)
+	}
+}
+
+func TestDiffConfigs_invalid(t *testing.T) {
+	_, err := DiffConfigs([]byte(`{}`), []byte(`{"provider_config":[]}`))
+	if err == nil {
+		t.Fatal("unexpected success; want error")
+	}
+}
@@ -8,9 +8,11 @@ package jsonconfig
 import (
 	"encoding/json"
 	"fmt"
+	"slices"
 
 	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/hcldec"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
 	"github.com/zclconf/go-cty/cty"
 	ctyjson "github.com/zclconf/go-cty/cty/json"
 
@@ -35,18 +37,104 @@ type expression struct {
 	// expressions. Callers should only use string equality checks here, since
 	// the syntax may be extended in future releases.
 	References []string `json:"references,omitempty"`
+
+	// "range" describes where the expression appears in the configuration
+	// source. This is set only when [MarshalOpts.SourceInfo] is enabled.
+	Range *sourceRange `json:"range,omitempty"`
+
+	// "source_text" is the literal source code of the expression as written
+	// in the configuration. This is set only when [MarshalOpts.Sources]
+	// includes the source of the file containing the expression.
+	SourceText string `json:"source_text,omitempty"`
+
+	// "references_sensitive" is true if any of the references is to a
+	// sensitive input variable or sensitive resource attribute. This is set
+	// only when [MarshalOpts.SensitiveReferences] is enabled.
+	ReferencesSensitive bool `json:"references_sensitive,omitempty"`
+
+	// "template" is true if the expression is a string template made of
+	// multiple parts. This is set only when [MarshalOpts.TemplateInfo] is
+	// enabled.
+	Template bool `json:"template,omitempty"`
+
+	// "value_with_defaults" is set only if the expression is not constant
+	// but its value can be determined using the default values of input
+	// variables, in which case it gives that value. This is set only when
+	// [MarshalOpts.DefaultsPreview] is enabled.
+	ValueWithDefaults json.RawMessage `json:"value_with_defaults,omitempty"`
+
+	// "constant_redacted" is true if the expression has a constant value that
+	// was omitted because it is for a sensitive argument. This is set only by
+	// [MarshalProviderConfigs].
+	ConstantRedacted bool `json:"constant_redacted,omitempty"`
 }
 
-func marshalExpression(ex hcl.Expression) expression {
+// sourceRange is the JSON representation of a range of configuration source
+// code, such as that of an expression.
+type sourceRange struct {
+	Filename string    `json:"filename"`
+	Start    sourcePos `json:"start"`
+	End      sourcePos `json:"end"`
+}
+
+// sourcePos is the JSON representation of a position in a source file.
+// Line and column numbers are one-based, while the byte offset is zero-based.
+type sourcePos struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+	Byte   int `json:"byte"`
+}
+
+func marshalSourceRange(rng hcl.Range) *sourceRange {
+	return &sourceRange{
+		Filename: rng.Filename,
+		Start: sourcePos{
+			Line:   rng.Start.Line,
+			Column: rng.Start.Column,
+			Byte:   rng.Start.Byte,
+		},
+		End: sourcePos{
+			Line:   rng.End.Line,
+			Column: rng.End.Column,
+			Byte:   rng.End.Byte,
+		},
+	}
+}
+
+func marshalExpression(ex hcl.Expression, opts MarshalOpts) expression {
 	var ret expression
 	if ex == nil {
 		return ret
 	}
 
+	if opts.SourceInfo {
+		ret.Range = marshalSourceRange(ex.Range())
+	}
+
+	if f, ok := opts.Sources[ex.Range().Filename]; ok && f != nil {
+		ret.SourceText = string(ex.Range().SliceBytes(f.Bytes))
+	}
+
+	if opts.TemplateInfo {
+		if tmpl, ok := ex.(*hclsyntax.TemplateExpr); ok && len(tmpl.Parts) > 1 {
+			ret.Template = true
+		}
+	}
+
+	if opts.functionsUsed != nil {
+		collectFunctionNames(ex, opts.functionsUsed)
+	}
+
 	val, valueDiags := ex.Value(nil)
 	if val != cty.NilVal && !valueDiags.HasErrors() {
 		valJSON, _ := ctyjson.Marshal(val, val.Type())
 		ret.ConstantValue = valJSON
+	} else if opts.defaultsContext != nil {
+		val, valueDiags := ex.Value(opts.defaultsContext)
+		if val != cty.NilVal && !valueDiags.HasErrors() && val.IsWhollyKnown() {
+			valJSON, _ := ctyjson.Marshal(val, val.Type())
+			ret.ValueWithDefaults = valJSON
+		}
 	}
 
 	refs, _ := lang.ReferencesInExpr(addrs.ParseRef, ex)
@@ -63,6 +151,10 @@ func marshalExpression(ex hcl.Expression) expression {
 			}
 			varString = append(varString, ref.Subject.String())
 
+			if opts.sensitiveSources != nil && referencesSensitive(ref, opts.sensitiveSources) {
+				ret.ReferencesSensitive = true
+			}
+
 			switch ref.Subject.(type) {
 			case addrs.ModuleCallInstance:
 				if ref.Subject.(addrs.ModuleCallInstance).Key != addrs.NoKey {
@@ -79,12 +171,63 @@ func marshalExpression(ex hcl.Expression) expression {
 				varString = append(varString, ref.Subject.(addrs.ModuleCallInstanceOutput).Call.String())
 			}
 		}
+		if opts.SortedReferences {
+			slices.Sort(varString)
+			varString = slices.Compact(varString)
+		}
 		ret.References = varString
 	}
 
 	return ret
 }
 
+// collectFunctionNames adds the names of all of the functions called in the
+// given expression to the given set. Only native syntax expressions are
+// analyzed, since function calls in JSON syntax are hidden inside templates.
+func collectFunctionNames(ex hcl.Expression, into map[string]struct{}) {
+	synExpr, ok := ex.(hclsyntax.Expression)
+	if !ok {
+		return
+	}
+	hclsyntax.VisitAll(synExpr, func(n hclsyntax.Node) hcl.Diagnostics {
+		if call, ok := n.(*hclsyntax.FunctionCallExpr); ok {
+			into[call.Name] = struct{}{}
+		}
+		return nil
+	})
+}
+
+// referencesSensitive returns true if the given reference is to one of the
+// objects in the given set, as returned by [sensitiveSources]. Any instance
+// key is disregarded, because sensitivity is decided by the schema of the
+// resource as a whole.
+func referencesSensitive(ref *addrs.Reference, sensitive map[string]struct{}) bool {
+	var key string
+	switch subject := ref.Subject.(type) {
+	case addrs.InputVariable:
+		key = subject.String()
+	case addrs.ResourceInstance:
+		key = resourceAttrKey(subject.Resource, ref.Remaining)
+	case addrs.Resource:
+		key = resourceAttrKey(subject, ref.Remaining)
+	default:
+		return false
+	}
+	_, ok := sensitive[key]
+	return ok
+}
+
+// resourceAttrKey returns the resource address followed by the name of the
+// top-level attribute selected by the given traversal, if any.
+func resourceAttrKey(r addrs.Resource, remain hcl.Traversal) string {
+	for _, step := range remain {
+		if attr, ok := step.(hcl.TraverseAttr); ok {
+			return r.String() + "." + attr.Name
+		}
+	}
+	return r.String()
+}
+
 func (e *expression) Empty() bool {
 	return e.ConstantValue == nil && e.References == nil
 }
@@ -100,7 +243,7 @@ type expressions map[string]any
 // If [inSingleModuleMode] returns true when given schema, the result is always
 // nil to represent that expression information is not available in
 // single-module mode.
-func marshalExpressions(body hcl.Body, schema *configschema.Block) expressions {
+func marshalExpressions(body hcl.Body, schema *configschema.Block, opts MarshalOpts) expressions {
 	if inSingleModuleMode(schema) {
 		// We never generate any expressions in single-module mode.
 		return nil
@@ -132,7 +275,7 @@ func marshalExpressions(body hcl.Body, schema *configschema.Block) expressions {
 
 	// Any attributes we encode directly as expression objects.
 	for name, attr := range content.Attributes {
-		ret[name] = marshalExpression(attr.Expr) // note: singular expression for this one
+		ret[name] = marshalExpression(attr.Expr, opts) // note: singular expression for this one
 	}
 
 	// Any nested blocks require a recursive call to produce nested expressions
@@ -148,12 +291,12 @@ func marshalExpressions(body hcl.Body, schema *configschema.Block) expressions {
 
 		switch blockS.Nesting {
 		case configschema.NestingSingle, configschema.NestingGroup:
-			ret[typeName] = marshalExpressions(block.Body, &blockS.Block)
+			ret[typeName] = marshalExpressions(block.Body, &blockS.Block, opts)
 		case configschema.NestingList, configschema.NestingSet:
 			if _, exists := ret[typeName]; !exists {
 				ret[typeName] = make([]map[string]any, 0, 1)
 			}
-			ret[typeName] = append(ret[typeName].([]map[string]any), marshalExpressions(block.Body, &blockS.Block))
+			ret[typeName] = append(ret[typeName].([]map[string]any), marshalExpressions(block.Body, &blockS.Block, opts))
 		case configschema.NestingMap:
 			if _, exists := ret[typeName]; !exists {
 				ret[typeName] = make(map[string]map[string]any)
@@ -161,9 +304,37 @@ func marshalExpressions(body hcl.Body, schema *configschema.Block) expressions {
 			// NestingMap blocks always have the key in the first (and only) label
 			key := block.Labels[0]
 			retMap := ret[typeName].(map[string]map[string]any)
-			retMap[key] = marshalExpressions(block.Body, &blockS.Block)
+			retMap[key] = marshalExpressions(block.Body, &blockS.Block, opts)
 		}
 	}
 
 	return ret
 }
+
+// walkExpressions calls the given function for each expression object
+// within the given value, which must be either an [expression] or a result
+// of [marshalExpressions], or a value nested inside one.
+func walkExpressions(v any, fn func(expression)) {
+	switch v := v.(type) {
+	case expression:
+		fn(v)
+	case *expression:
+		if v != nil {
+			fn(*v)
+		}
+	case expressions:
+		walkExpressions(map[string]any(v), fn)
+	case map[string]any:
+		for _, nested := range v {
+			walkExpressions(nested, fn)
+		}
+	case []map[string]any:
+		for _, nested := range v {
+			walkExpressions(nested, fn)
+		}
+	case map[string]map[string]any:
+		for _, nested := range v {
+			walkExpressions(nested, fn)
+		}
+	}
+}
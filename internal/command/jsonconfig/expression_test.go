@@ -15,6 +15,7 @@ import (
 	"github.com/hashicorp/hcl/v2/hcltest"
 	"github.com/zclconf/go-cty/cty"
 
+	"github.com/opentofu/opentofu/internal/configs"
 	"github.com/opentofu/opentofu/internal/configs/configschema"
 )
 
@@ -125,7 +126,7 @@ func TestMarshalExpressions(t *testing.T) {
 			},
 		}
 
-		got := marshalExpressions(test.Input, schema)
+		got := marshalExpressions(test.Input, schema, MarshalOpts{})
 		if !reflect.DeepEqual(got, test.Want) {
 			t.Errorf("wrong result:\nGot: %#v\nWant: %#v\n", got, test.Want)
 		}
@@ -144,7 +145,7 @@ func TestMarshalExpressions_singleModuleMode(t *testing.T) {
 			},
 		},
 	})
-	got := marshalExpressions(input, nil)
+	got := marshalExpressions(input, nil, MarshalOpts{})
 	if got != nil {
 		t.Errorf("wrong result:\nGot: %#v\nWant: <nil>", got)
 	}
@@ -162,9 +163,288 @@ func TestMarshalExpression(t *testing.T) {
 	}
 
 	for _, test := range tests {
-		got := marshalExpression(test.Input)
+		got := marshalExpression(test.Input, MarshalOpts{})
 		if !reflect.DeepEqual(got, test.Want) {
 			t.Fatalf("wrong result:\nGot: %#v\nWant: %#v\n", got, test.Want)
 		}
 	}
 }
+
+func TestMarshalExpression_sourceInfo(t *testing.T) {
+	expr, diags := hclsyntax.ParseExpression([]byte(`var.foo`), "main.tf", hcl.InitialPos)
+	if diags.HasErrors() {
+		t.Fatal(diags.Error())
+	}
+
+	got := marshalExpression(expr, MarshalOpts{})
+	if got.Range != nil {
+		t.Errorf("unexpected range without SourceInfo: %#v", got.Range)
+	}
+
+	got = marshalExpression(expr, MarshalOpts{SourceInfo: true})
+	want := &sourceRange{
+		Filename: "main.tf",
+		Start:    sourcePos{Line: 1, Column: 1, Byte: 0},
+		End:      sourcePos{Line: 1, Column: 8, Byte: 7},
+	}
+	if !reflect.DeepEqual(got.Range, want) {
+		t.Errorf("wrong range:\nGot: %#v\nWant: %#v\n", got.Range, want)
+	}
+}
+
+func TestMarshalExpressions_sourceInfo(t *testing.T) {
+	src := `
+name = "a"
+rule {
+  value = var.foo
+}
+`
+	f, diags := hclsyntax.ParseConfig([]byte(src), "main.tf", hcl.InitialPos)
+	if diags.HasErrors() {
+		t.Fatal(diags.Error())
+	}
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"name": {Type: cty.String, Optional: true},
+		},
+		BlockTypes: map[string]*configschema.NestedBlock{
+			"rule": {
+				Nesting: configschema.NestingList,
+				Block: configschema.Block{
+					Attributes: map[string]*configschema.Attribute{
+						"value": {Type: cty.String, Optional: true},
+					},
+				},
+			},
+		},
+	}
+
+	got := marshalExpressions(f.Body, schema, MarshalOpts{SourceInfo: true})
+
+	// Expressions in nested blocks must carry their own ranges, rather than
+	// that of the block.
+	if rng := got["name"].(expression).Range; rng == nil || rng.Start.Line != 2 {
+		t.Errorf("wrong range for name: %#v", rng)
+	}
+	rules := got["rule"].([]map[string]any)
+	want := &sourceRange{
+		Filename: "main.tf",
+		Start:    sourcePos{Line: 4, Column: 11, Byte: 29},
+		End:      sourcePos{Line: 4, Column: 18, Byte: 36},
+	}
+	if rng := rules[0]["value"].(expression).Range; !reflect.DeepEqual(rng, want) {
+		t.Errorf("wrong range for rule.value:\nGot: %#v\nWant: %#v\n", rng, want)
+	}
+}
+
+func TestMarshalExpressions_sourceText(t *testing.T) {
+	src := `
+name = "${var.foo}-suffix"
+rule {
+  value = var.foo
+}
+`
+	f, diags := hclsyntax.ParseConfig([]byte(src), "main.tf", hcl.InitialPos)
+	if diags.HasErrors() {
+		t.Fatal(diags.Error())
+	}
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"name": {Type: cty.String, Optional: true},
+		},
+		BlockTypes: map[string]*configschema.NestedBlock{
+			"rule": {
+				Nesting: configschema.NestingList,
+				Block: configschema.Block{
+					Attributes: map[string]*configschema.Attribute{
+						"value": {Type: cty.String, Optional: true},
+					},
+				},
+			},
+		},
+	}
+
+	got := marshalExpressions(f.Body, schema, MarshalOpts{})
+	if text := got["name"].(expression).SourceText; text != "" {
+		t.Errorf("unexpected source text without sources: %q", text)
+	}
+
+	// Sources for other files must not be used.
+	got = marshalExpressions(f.Body, schema, MarshalOpts{
+		Sources: map[string]*hcl.File{"other.tf": f},
+	})
+	if text := got["name"].(expression).SourceText; text != "" {
+		t.Errorf("unexpected source text from another file: %q", text)
+	}
+
+	got = marshalExpressions(f.Body, schema, MarshalOpts{
+		Sources: map[string]*hcl.File{"main.tf": f},
+	})
+	if text, want := got["name"].(expression).SourceText, `"${var.foo}-suffix"`; text != want {
+		t.Errorf("wrong source text for name\ngot:  %s\nwant: %s", text, want)
+	}
+	rules := got["rule"].([]map[string]any)
+	if text, want := rules[0]["value"].(expression).SourceText, "var.foo"; text != want {
+		t.Errorf("wrong source text for rule.value\ngot:  %s\nwant: %s", text, want)
+	}
+}
+
+func TestMarshalExpression_instanceKeyReferences(t *testing.T) {
+	expr, diags := hclsyntax.ParseExpression([]byte(`"${each.key}-${count.index}-${each.value.name}"`), "main.tf", hcl.InitialPos)
+	if diags.HasErrors() {
+		t.Fatal(diags.Error())
+	}
+
+	got := marshalExpression(expr, MarshalOpts{})
+	want := []string{"each.key", "count.index", "each.value.name", "each.value"}
+	if !reflect.DeepEqual(got.References, want) {
+		t.Errorf("wrong references:\nGot: %#v\nWant: %#v\n", got.References, want)
+	}
+}
+
+func TestMarshalExpression_referencesSensitive(t *testing.T) {
+	sensitive := map[string]struct{}{
+		"var.secret":                {},
+		"test_thing.a":              {},
+		"test_thing.a.password":     {},
+		"data.test_thing.b":         {},
+		"data.test_thing.b.api_key": {},
+	}
+	tests := map[string]bool{
+		`var.secret`:                   true,
+		`var.other`:                    false,
+		`test_thing.a.password`:        true,
+		`test_thing.a[0].password`:     true,
+		`test_thing.a.name`:            false,
+		`test_thing.a`:                 true,
+		`data.test_thing.b.api_key`:    true,
+		`"${var.other}-${var.secret}"`: true,
+		`local.secret`:                 false,
+		`"constant"`:                   false,
+	}
+	for src, want := range tests {
+		t.Run(src, func(t *testing.T) {
+			expr, diags := hclsyntax.ParseExpression([]byte(src), "main.tf", hcl.InitialPos)
+			if diags.HasErrors() {
+				t.Fatal(diags.Error())
+			}
+
+			got := marshalExpression(expr, MarshalOpts{sensitiveSources: sensitive})
+			if got.ReferencesSensitive != want {
+				t.Errorf("wrong result %t; want %t", got.ReferencesSensitive, want)
+			}
+			if got := marshalExpression(expr, MarshalOpts{}); got.ReferencesSensitive {
+				t.Errorf("references_sensitive set without any sensitive sources")
+			}
+		})
+	}
+}
+
+func TestMarshalExpression_sortedReferences(t *testing.T) {
+	expr, diags := hclsyntax.ParseExpression([]byte(`"${var.b}-${var.a}-${var.b}"`), "main.tf", hcl.InitialPos)
+	if diags.HasErrors() {
+		t.Fatal(diags.Error())
+	}
+
+	got := marshalExpression(expr, MarshalOpts{})
+	if want := []string{"var.b", "var.a", "var.b"}; !reflect.DeepEqual(got.References, want) {
+		t.Errorf("wrong references:\nGot: %#v\nWant: %#v\n", got.References, want)
+	}
+
+	got = marshalExpression(expr, MarshalOpts{SortedReferences: true})
+	if want := []string{"var.a", "var.b"}; !reflect.DeepEqual(got.References, want) {
+		t.Errorf("wrong sorted references:\nGot: %#v\nWant: %#v\n", got.References, want)
+	}
+}
+
+func TestMarshalExpression_templateInfo(t *testing.T) {
+	tests := map[string]bool{
+		"<<-EOT\n  Hello, ${var.name}!\n  EOT\n": true,
+		`"${var.a}-${var.b}"`:                    true,
+		`"${var.name}"`:                          false,
+		`"constant"`:                             false,
+		`var.name`:                               false,
+	}
+	for src, want := range tests {
+		t.Run(src, func(t *testing.T) {
+			expr, diags := hclsyntax.ParseExpression([]byte(src), "main.tf", hcl.InitialPos)
+			if diags.HasErrors() {
+				t.Fatal(diags.Error())
+			}
+
+			got := marshalExpression(expr, MarshalOpts{TemplateInfo: true})
+			if got.Template != want {
+				t.Errorf("wrong result %t; want %t", got.Template, want)
+			}
+			if got := marshalExpression(expr, MarshalOpts{}); got.Template {
+				t.Errorf("template set without the TemplateInfo option")
+			}
+		})
+	}
+}
+
+func TestMarshalExpression_defaultsPreview(t *testing.T) {
+	mod := &configs.Module{
+		Variables: map[string]*configs.Variable{
+			"name":     {Name: "name", Default: cty.StringVal("world")},
+			"required": {Name: "required"},
+			"secret":   {Name: "secret", Default: cty.StringVal("hunter2"), Sensitive: true},
+		},
+		Locals: map[string]*configs.Local{
+			"greeting": {Name: "greeting", Expr: hcltest.MockExprLiteral(cty.StringVal("Hello"))},
+			"dynamic":  {Name: "dynamic", Expr: hcltest.MockExprTraversalSrc("var.name")},
+		},
+	}
+	opts := MarshalOpts{DefaultsPreview: true, defaultsContext: defaultsEvalContext(mod)}
+
+	tests := map[string]struct {
+		wantConstant string
+		wantPreview  string
+	}{
+		`"constant"`:                        {wantConstant: `"constant"`},
+		`"${local.greeting}, ${var.name}!"`: {wantPreview: `"Hello, world!"`},
+		`var.required`:                      {},
+		`var.secret`:                        {},
+		`local.dynamic`:                     {},
+	}
+	for src, test := range tests {
+		t.Run(src, func(t *testing.T) {
+			expr, diags := hclsyntax.ParseExpression([]byte(src), "main.tf", hcl.InitialPos)
+			if diags.HasErrors() {
+				t.Fatal(diags.Error())
+			}
+
+			got := marshalExpression(expr, opts)
+			if string(got.ConstantValue) != test.wantConstant {
+				t.Errorf("wrong constant_value %s; want %s", got.ConstantValue, test.wantConstant)
+			}
+			if string(got.ValueWithDefaults) != test.wantPreview {
+				t.Errorf("wrong value_with_defaults %s; want %s", got.ValueWithDefaults, test.wantPreview)
+			}
+		})
+	}
+}
+
+func TestCollectFunctionNames(t *testing.T) {
+	expr, diags := hclsyntax.ParseExpression([]byte(`upper(format("%s-%s", var.a, lower(var.b)))`), "main.tf", hcl.InitialPos)
+	if diags.HasErrors() {
+		t.Fatal(diags.Error())
+	}
+
+	got := make(map[string]struct{})
+	collectFunctionNames(expr, got)
+	want := map[string]struct{}{
+		"upper":  {},
+		"format": {},
+		"lower":  {},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("wrong result\ngot:  %#v\nwant: %#v", got, want)
+	}
+
+	opts := MarshalOpts{functionsUsed: make(map[string]struct{})}
+	marshalExpression(expr, opts)
+	if !reflect.DeepEqual(opts.functionsUsed, want) {
+		t.Errorf("wrong functions collected by marshalExpression\ngot:  %#v\nwant: %#v", opts.functionsUsed, want)
+	}
+}
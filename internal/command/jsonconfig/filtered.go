@@ -0,0 +1,97 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+// Copyright (c) 2023 HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package jsonconfig
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/opentofu/opentofu/internal/addrs"
+	"github.com/opentofu/opentofu/internal/configs"
+	"github.com/opentofu/opentofu/internal/tofu"
+)
+
+// MarshalFiltered is a variant of [Marshal] that describes only the module
+// with the given path and its descendants, along with the provider
+// configurations that they declare or use.
+//
+// The "root_module" property of the result describes the selected module,
+// and all addresses within it remain relative to that module as usual.
+// Provider configuration keys are the same as in the result of [Marshal], so
+// a resource that uses a configuration passed from outside of the subtree
+// refers to the entry for the ancestor module that declares it. The
+// top-level summaries that describe the whole configuration, such as
+// "recursive_module_sources", are omitted.
+//
+// An empty path selects the root module, in which case the result describes
+// the same modules as [Marshal].
+func MarshalFiltered(c *configs.Config, schemas *tofu.Schemas, prefix addrs.Module) ([]byte, error) {
+	sub := c.Descendent(prefix)
+	if sub == nil || sub.Module == nil {
+		return nil, fmt.Errorf("configuration has no module %s", prefix)
+	}
+
+	// Provider configurations can be passed into the subtree from any of its
+	// ancestors, so we must consider the whole tree to resolve them.
+	pcs := make(map[string]providerConfig)
+	marshalProviderConfigs(c, schemas, pcs, MarshalOpts{})
+
+	rootModule, err := marshalModule(sub, schemas, sub.Path.String(), MarshalOpts{})
+	if err != nil {
+		return nil, err
+	}
+	normalizeModuleProviderKeys(&rootModule, pcs)
+	setModuleCallProviderConfigKeys(sub, &rootModule, pcs)
+
+	used := make(map[string]struct{})
+	collectProviderConfigKeys(rootModule, used)
+	for key, pc := range pcs {
+		if pc.parentKey != "" {
+			delete(pcs, key)
+			continue
+		}
+		if _, ok := used[key]; ok {
+			continue
+		}
+		if !moduleAddrWithin(pc.ModuleAddress, prefix) {
+			delete(pcs, key)
+		}
+	}
+
+	return json.Marshal(config{
+		ProviderConfigs: pcs,
+		RootModule:      rootModule,
+	})
+}
+
+// collectProviderConfigKeys adds the provider config keys of all of the
+// resources in the given module and its descendants, and those that their
+// module calls pass to their child modules, to the given set.
+func collectProviderConfigKeys(m module, into map[string]struct{}) {
+	for _, r := range m.Resources {
+		into[r.ProviderConfigKey] = struct{}{}
+	}
+	for _, mc := range m.ModuleCalls {
+		for _, key := range mc.ProviderConfigKeys {
+			into[key] = struct{}{}
+		}
+		if mc.Module != nil {
+			collectProviderConfigKeys(*mc.Module, into)
+		}
+	}
+}
+
+// moduleAddrWithin returns true if the module with the given address string,
+// as used for the "module_address" of provider configurations, is the given
+// module or one of its descendants.
+func moduleAddrWithin(addr string, prefix addrs.Module) bool {
+	if prefix.IsRoot() {
+		return true
+	}
+	p := prefix.String()
+	return addr == p || strings.HasPrefix(addr, p+".")
+}
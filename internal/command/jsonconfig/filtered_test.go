@@ -0,0 +1,120 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+// Copyright (c) 2023 HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package jsonconfig
+
+import (
+	"encoding/json"
+	"maps"
+	"slices"
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+
+	"github.com/opentofu/opentofu/internal/addrs"
+	"github.com/opentofu/opentofu/internal/configs"
+	"github.com/opentofu/opentofu/internal/configs/configschema"
+	"github.com/opentofu/opentofu/internal/providers"
+	"github.com/opentofu/opentofu/internal/tofu"
+)
+
+func TestMarshalFiltered(t *testing.T) {
+	module := func(resourceNames ...string) *configs.Module {
+		rs := make(map[string]*configs.Resource)
+		for _, name := range resourceNames {
+			rs["test_thing."+name] = &configs.Resource{
+				Mode:     addrs.ManagedResourceMode,
+				Type:     "test_thing",
+				Name:     name,
+				Config:   &hclsyntax.Body{},
+				Provider: addrs.NewDefaultProvider("test"),
+			}
+		}
+		return &configs.Module{
+			ProviderRequirements: &configs.RequiredProviders{
+				RequiredProviders: map[string]*configs.RequiredProvider{},
+			},
+			ProviderConfigs:  map[string]*configs.Provider{},
+			ManagedResources: rs,
+			ModuleCalls:      map[string]*configs.ModuleCall{},
+		}
+	}
+
+	root := &configs.Config{Module: module("root")}
+	root.Module.ProviderConfigs["test"] = &configs.Provider{Name: "test", Config: hcl.EmptyBody()}
+	root.Module.ModuleCalls["a"] = &configs.ModuleCall{Name: "a", Config: &hclsyntax.Body{}}
+	root.Module.ModuleCalls["b"] = &configs.ModuleCall{Name: "b", Config: &hclsyntax.Body{}}
+	a := &configs.Config{
+		Parent: root,
+		Path:   addrs.RootModule.Child("a"),
+		Module: module("a"),
+	}
+	a.Module.ModuleCalls["c"] = &configs.ModuleCall{Name: "c", Config: &hclsyntax.Body{}}
+	c := &configs.Config{
+		Parent: a,
+		Path:   addrs.RootModule.Child("a").Child("c"),
+		Module: module("c"),
+	}
+	b := &configs.Config{
+		Parent: root,
+		Path:   addrs.RootModule.Child("b"),
+		Module: module("b"),
+	}
+	b.Module.ProviderConfigs["test"] = &configs.Provider{Name: "test", Config: hcl.EmptyBody()}
+	root.Children = map[string]*configs.Config{"a": a, "b": b}
+	a.Children = map[string]*configs.Config{"c": c}
+	root.Root = root
+	a.Root = root
+	b.Root = root
+	c.Root = root
+
+	schemas := &tofu.Schemas{
+		Providers: map[addrs.Provider]providers.ProviderSchema{
+			addrs.NewDefaultProvider("test"): {
+				ResourceTypes: map[string]providers.Schema{
+					"test_thing": {Block: &configschema.Block{}},
+				},
+			},
+		},
+	}
+
+	got, err := MarshalFiltered(root, schemas, addrs.RootModule.Child("a"))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	var result struct {
+		ProviderConfigs map[string]json.RawMessage `json:"provider_config"`
+		RootModule      struct {
+			Resources []struct {
+				Address           string `json:"address"`
+				ProviderConfigKey string `json:"provider_config_key"`
+			} `json:"resources"`
+			ModuleCalls map[string]json.RawMessage `json:"module_calls"`
+		} `json:"root_module"`
+	}
+	if err := json.Unmarshal(got, &result); err != nil {
+		t.Fatalf("invalid result: %s\n%s", err, got)
+	}
+
+	gotKeys := slices.Sorted(maps.Keys(result.ProviderConfigs))
+	// The root module's configuration is inherited by module.a and
+	// module.a.module.c, while module.b's is not used in the subtree.
+	if want := []string{"test"}; !slices.Equal(gotKeys, want) {
+		t.Errorf("wrong provider config keys %q; want %q", gotKeys, want)
+	}
+	if len(result.RootModule.Resources) != 1 || result.RootModule.Resources[0].Address != "test_thing.a" {
+		t.Errorf("wrong resources %#v", result.RootModule.Resources)
+	} else if got, want := result.RootModule.Resources[0].ProviderConfigKey, "test"; got != want {
+		t.Errorf("wrong provider config key %q; want %q", got, want)
+	}
+	if _, ok := result.RootModule.ModuleCalls["c"]; !ok || len(result.RootModule.ModuleCalls) != 1 {
+		t.Errorf("wrong module calls %q", slices.Sorted(maps.Keys(result.RootModule.ModuleCalls)))
+	}
+
+	if _, err := MarshalFiltered(root, schemas, addrs.RootModule.Child("d")); err == nil {
+		t.Errorf("no error for a module that doesn't exist")
+	}
+}
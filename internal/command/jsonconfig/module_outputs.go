@@ -0,0 +1,44 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+// Copyright (c) 2023 HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package jsonconfig
+
+import (
+	"github.com/opentofu/opentofu/internal/addrs"
+	"github.com/opentofu/opentofu/internal/configs"
+	"github.com/opentofu/opentofu/internal/tfdiags"
+)
+
+// ResolveModuleOutput returns the representation of the expression that
+// defines the output value with the given name in the module with the given
+// path, such as "module.foo" or "module.foo.module.bar", using the same
+// representation as the "expression" property of outputs in the result of
+// [Marshal]. An empty path refers to the root module.
+//
+// This allows following a reference such as "module.foo.bar" into the
+// child module. The path must not include instance keys, because all of the
+// instances of a module call share the same configuration. The second
+// return value is false if there is no such module or output value.
+func ResolveModuleOutput(c *configs.Config, modPath, outputName string) (*expression, bool) {
+	path := addrs.RootModule
+	if modPath != "" {
+		var diags tfdiags.Diagnostics
+		path, diags = addrs.ParseModuleStr(modPath)
+		if diags.HasErrors() {
+			return nil, false
+		}
+	}
+
+	mc := c.Descendent(path)
+	if mc == nil || mc.Module == nil {
+		return nil, false
+	}
+	o, ok := mc.Module.Outputs[outputName]
+	if !ok {
+		return nil, false
+	}
+	expr := marshalExpression(o.Expr, MarshalOpts{})
+	return &expr, true
+}
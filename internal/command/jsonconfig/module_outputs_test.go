@@ -0,0 +1,85 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+// Copyright (c) 2023 HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package jsonconfig
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+
+	"github.com/opentofu/opentofu/internal/addrs"
+	"github.com/opentofu/opentofu/internal/configs"
+)
+
+func TestResolveModuleOutput(t *testing.T) {
+	expr := func(src string) hcl.Expression {
+		t.Helper()
+		e, diags := hclsyntax.ParseExpression([]byte(src), "test.tf", hcl.InitialPos)
+		if diags.HasErrors() {
+			t.Fatal(diags.Error())
+		}
+		return e
+	}
+
+	root := &configs.Config{
+		Module: &configs.Module{
+			Outputs: map[string]*configs.Output{
+				"id": {Name: "id", Expr: expr(`module.foo.id`)},
+			},
+		},
+	}
+	foo := &configs.Config{
+		Path:   addrs.RootModule.Child("foo"),
+		Parent: root,
+		Module: &configs.Module{
+			Outputs: map[string]*configs.Output{
+				"id": {Name: "id", Expr: expr(`test_thing.a.id`)},
+			},
+		},
+	}
+	root.Children = map[string]*configs.Config{"foo": foo}
+
+	tests := map[string]struct {
+		modPath string
+		output  string
+		want    *expression
+	}{
+		"root module": {
+			output: "id",
+			want:   &expression{References: []string{"module.foo.id", "module.foo"}},
+		},
+		"child module": {
+			modPath: "module.foo",
+			output:  "id",
+			want:    &expression{References: []string{"test_thing.a.id", "test_thing.a"}},
+		},
+		"missing output": {
+			modPath: "module.foo",
+			output:  "name",
+		},
+		"missing module": {
+			modPath: "module.bar",
+			output:  "id",
+		},
+		"instance key": {
+			modPath: "module.foo[0]",
+			output:  "id",
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, ok := ResolveModuleOutput(root, test.modPath, test.output)
+			if ok != (test.want != nil) {
+				t.Fatalf("wrong ok %t", ok)
+			}
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("wrong result\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+// Copyright (c) 2023 HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package jsonconfig
+
+import (
+	"sort"
+
+	"github.com/apparentlymart/go-versions/versions"
+
+	"github.com/opentofu/opentofu/internal/addrs"
+	"github.com/opentofu/opentofu/internal/configs"
+	"github.com/opentofu/opentofu/internal/getproviders"
+)
+
+// providerConstraintConflict describes a provider for which the version
+// constraints declared in different modules cannot all be met at once.
+type providerConstraintConflict struct {
+	// Provider is the fully-qualified provider source address.
+	Provider string `json:"provider"`
+
+	// Modules lists each module that declares a version constraint for the
+	// provider, sorted by module address.
+	Modules []moduleProviderConstraint `json:"modules"`
+}
+
+type moduleProviderConstraint struct {
+	ModuleAddress     string `json:"module_address,omitempty"`
+	VersionConstraint string `json:"version_constraint"`
+}
+
+// providerConstraintConflicts returns the providers for which the version
+// constraints declared across the modules in the given configuration have no
+// version in common, sorted by provider address, or nil if there are none.
+//
+// This is advisory: it considers only the constraints themselves and not
+// which versions actually exist, so "tofu init" can still fail to find a
+// suitable version for a provider that is not reported here.
+func providerConstraintConflicts(c *configs.Config) []providerConstraintConflict {
+	byProvider := make(map[addrs.Provider][]moduleProviderConstraint)
+	specs := make(map[addrs.Provider][]getproviders.VersionConstraints)
+
+	var walk func(c *configs.Config)
+	walk = func(c *configs.Config) {
+		if c == nil || c.Module == nil {
+			return
+		}
+		// Disregard any diagnostics, as marshalProviderConfigs does.
+		reqs, _ := c.ProviderRequirementsShallow()
+		for provider, vc := range reqs {
+			if len(vc) == 0 {
+				continue
+			}
+			byProvider[provider] = append(byProvider[provider], moduleProviderConstraint{
+				ModuleAddress:     c.Path.String(),
+				VersionConstraint: getproviders.VersionConstraintsString(vc),
+			})
+			specs[provider] = append(specs[provider], vc)
+		}
+		for _, child := range c.Children {
+			walk(child)
+		}
+	}
+	walk(c)
+
+	var ret []providerConstraintConflict
+	for provider, modules := range byProvider {
+		if len(modules) < 2 || constraintsOverlap(specs[provider]) {
+			continue
+		}
+		sort.Slice(modules, func(i, j int) bool {
+			return modules[i].ModuleAddress < modules[j].ModuleAddress
+		})
+		ret = append(ret, providerConstraintConflict{
+			Provider: provider.String(),
+			Modules:  modules,
+		})
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].Provider < ret[j].Provider
+	})
+	return ret
+}
+
+// constraintsOverlap returns true if at least one version meets all of the
+// given constraints.
+//
+// The sets of matching versions are unbounded, so rather than enumerating
+// them this tests the versions at and just above each boundary mentioned in
+// the constraints. The lowest matching version, if any, is always one of
+// those.
+func constraintsOverlap(all []getproviders.VersionConstraints) bool {
+	var combined getproviders.VersionConstraints
+	for _, vc := range all {
+		combined = append(combined, vc...)
+	}
+	allowed := getproviders.MeetingConstraints(combined)
+
+	candidates := []getproviders.Version{{}}
+	for _, sel := range combined {
+		b := sel.Boundary.ConstrainToZero()
+		v := getproviders.Version{Major: b.Major.Num, Minor: b.Minor.Num, Patch: b.Patch.Num}
+		candidates = append(candidates,
+			v,
+			getproviders.Version{Major: v.Major, Minor: v.Minor, Patch: v.Patch, Prerelease: versions.VersionExtra(b.Prerelease)},
+			getproviders.Version{Major: v.Major, Minor: v.Minor, Patch: v.Patch + 1},
+			getproviders.Version{Major: v.Major, Minor: v.Minor + 1},
+			getproviders.Version{Major: v.Major + 1},
+		)
+	}
+	for _, v := range candidates {
+		if allowed.Has(v) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,91 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+// Copyright (c) 2023 HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package jsonconfig
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	version "github.com/hashicorp/go-version"
+
+	"github.com/opentofu/opentofu/internal/addrs"
+	"github.com/opentofu/opentofu/internal/configs"
+	"github.com/opentofu/opentofu/internal/getproviders"
+)
+
+func TestProviderConstraintConflicts(t *testing.T) {
+	module := func(constraints map[string]string) *configs.Module {
+		reqs := make(map[string]*configs.RequiredProvider)
+		for name, c := range constraints {
+			reqs[name] = &configs.RequiredProvider{
+				Name: name,
+				Type: addrs.NewDefaultProvider(name),
+				Requirement: configs.VersionConstraint{
+					Required: version.MustConstraints(version.NewConstraint(c)),
+				},
+			}
+		}
+		return &configs.Module{
+			ProviderRequirements: &configs.RequiredProviders{RequiredProviders: reqs},
+		}
+	}
+
+	root := &configs.Config{
+		Module: module(map[string]string{"aws": "~> 4.0", "null": ">= 3.0"}),
+	}
+	root.Children = map[string]*configs.Config{
+		"a": {
+			Parent: root,
+			Path:   addrs.RootModule.Child("a"),
+			Module: module(map[string]string{"aws": ">= 5.0", "null": "< 4.0"}),
+		},
+		"b": {
+			Parent: root,
+			Path:   addrs.RootModule.Child("b"),
+			Module: module(map[string]string{"aws": "!= 4.2.0"}),
+		},
+	}
+
+	got := providerConstraintConflicts(root)
+	want := []providerConstraintConflict{
+		{
+			Provider: "registry.opentofu.org/hashicorp/aws",
+			Modules: []moduleProviderConstraint{
+				{VersionConstraint: "~> 4.0"},
+				{ModuleAddress: "module.a", VersionConstraint: ">= 5.0.0"},
+				{ModuleAddress: "module.b", VersionConstraint: "!= 4.2.0"},
+			},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("wrong result\n%s", diff)
+	}
+}
+
+func TestConstraintsOverlap(t *testing.T) {
+	tests := []struct {
+		constraints []string
+		want        bool
+	}{
+		{[]string{"~> 1.0", ">= 1.5"}, true},
+		{[]string{"~> 1.0", ">= 2.0"}, false},
+		{[]string{"> 1.2.3", "< 1.2.4"}, false},
+		{[]string{"> 1.2.3", "<= 1.2.4"}, true},
+		{[]string{"> 1.2", "< 1.4"}, true},
+		{[]string{"1.0.0-beta1", "~> 1.0.0-beta1"}, true},
+		{[]string{"1.0.0", "!= 1.0.0"}, false},
+		{[]string{"< 1.0", "!= 0.0.0"}, true},
+	}
+	for _, test := range tests {
+		var all []getproviders.VersionConstraints
+		for _, c := range test.constraints {
+			all = append(all, getproviders.MustParseVersionConstraints(c))
+		}
+		if got := constraintsOverlap(all); got != test.want {
+			t.Errorf("wrong result for %q: got %t, want %t", test.constraints, got, test.want)
+		}
+	}
+}
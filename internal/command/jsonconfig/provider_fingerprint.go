@@ -0,0 +1,109 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+// Copyright (c) 2023 HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package jsonconfig
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+
+	"github.com/opentofu/opentofu/internal/configs/configschema"
+)
+
+// providerConfigFingerprint returns a hash of the given expressions from a
+// provider configuration block, as produced by [marshalExpressions] with the
+// given schema, that is the same for any two blocks whose arguments have the
+// same references and constant values.
+//
+// Only the references and constant values of each expression contribute to
+// the result, so that it does not depend on where the block is declared or
+// on which other marshal options are set. The constant values of sensitive
+// and write-only arguments are omitted, as are all constant values if the
+// schema is not available, so that the result cannot be used to confirm a
+// guess of a secret.
+func providerConfigFingerprint(exprs map[string]any, schema *configschema.Block) (string, error) {
+	src, err := json.Marshal(fingerprintExpressions(exprs, schema))
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(src)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func fingerprintExpressions(exprs map[string]any, schema *configschema.Block) map[string]any {
+	return transformExpressions(exprs, schema, func(ex expression, disclosable bool) expression {
+		ret := expression{References: ex.References}
+		if disclosable {
+			ret.ConstantValue = ex.ConstantValue
+		}
+		return ret
+	})
+}
+
+// redactSensitiveConstants returns a copy of the given expressions from a
+// provider configuration block, as produced by [marshalExpressions] with the
+// given schema, with the values of any arguments that are sensitive or
+// write-only removed and replaced by a "constant_redacted" flag.
+func redactSensitiveConstants(exprs map[string]any, schema *configschema.Block) map[string]any {
+	return transformExpressions(exprs, schema, func(ex expression, disclosable bool) expression {
+		if !disclosable && (ex.ConstantValue != nil || ex.ValueWithDefaults != nil) {
+			ex.ConstantValue = nil
+			ex.ValueWithDefaults = nil
+			ex.ConstantRedacted = true
+		}
+		return ex
+	})
+}
+
+// transformExpressions returns a copy of the given expressions, as produced
+// by [marshalExpressions] with the given schema, in which each expression is
+// replaced by the result of calling fn with it and whether its constant value
+// can be disclosed, as decided by [disclosableConstant].
+func transformExpressions(exprs map[string]any, schema *configschema.Block, fn func(ex expression, disclosable bool) expression) map[string]any {
+	ret := make(map[string]any, len(exprs))
+	for name, v := range exprs {
+		var nested *configschema.Block
+		if schema != nil {
+			if blockS, ok := schema.BlockTypes[name]; ok {
+				nested = &blockS.Block
+			}
+		}
+
+		switch v := v.(type) {
+		case expression:
+			ret[name] = fn(v, disclosableConstant(schema, name))
+		case expressions:
+			ret[name] = transformExpressions(v, nested, fn)
+		case []map[string]any:
+			blocks := make([]map[string]any, len(v))
+			for i, b := range v {
+				blocks[i] = transformExpressions(b, nested, fn)
+			}
+			ret[name] = blocks
+		case map[string]map[string]any:
+			blocks := make(map[string]map[string]any, len(v))
+			for k, b := range v {
+				blocks[k] = transformExpressions(b, nested, fn)
+			}
+			ret[name] = blocks
+		}
+	}
+	return ret
+}
+
+// disclosableConstant returns true if the constant value of the named
+// argument in the given schema is not secret, and so may be included in a
+// fingerprint or an audit report.
+func disclosableConstant(schema *configschema.Block, name string) bool {
+	if schema == nil {
+		return false
+	}
+	attr, ok := schema.Attributes[name]
+	if !ok || attr.Sensitive || attr.WriteOnly {
+		return false
+	}
+	return attr.NestedType == nil || !attr.NestedType.ContainsSensitive()
+}
@@ -0,0 +1,151 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+// Copyright (c) 2023 HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package jsonconfig
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/opentofu/opentofu/internal/addrs"
+	"github.com/opentofu/opentofu/internal/configs"
+	"github.com/opentofu/opentofu/internal/configs/configschema"
+	"github.com/opentofu/opentofu/internal/providers"
+	"github.com/opentofu/opentofu/internal/tofu"
+)
+
+func TestProviderConfigFingerprint(t *testing.T) {
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"region": {Type: cty.String, Optional: true},
+			"token":  {Type: cty.String, Optional: true, Sensitive: true},
+		},
+		BlockTypes: map[string]*configschema.NestedBlock{
+			"assume_role": {
+				Nesting: configschema.NestingList,
+				Block: configschema.Block{
+					Attributes: map[string]*configschema.Attribute{
+						"role_arn": {Type: cty.String, Optional: true},
+					},
+				},
+			},
+		},
+	}
+	config := func(region, token, roleARN string, rng *sourceRange) map[string]any {
+		return map[string]any{
+			"region": expression{ConstantValue: json.RawMessage(region), Range: rng},
+			"token":  expression{ConstantValue: json.RawMessage(token)},
+			"assume_role": []map[string]any{
+				{"role_arn": expression{References: []string{roleARN}}},
+			},
+		}
+	}
+	fingerprint := func(exprs map[string]any) string {
+		t.Helper()
+		fp, err := providerConfigFingerprint(exprs, schema)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return fp
+	}
+
+	base := fingerprint(config(`"us-east-1"`, `"a"`, "var.role", nil))
+
+	tests := map[string]struct {
+		exprs map[string]any
+		same  bool
+	}{
+		"different range": {
+			config(`"us-east-1"`, `"a"`, "var.role", &sourceRange{Filename: "other.tf"}),
+			true,
+		},
+		"different sensitive constant": {
+			config(`"us-east-1"`, `"b"`, "var.role", nil),
+			true,
+		},
+		"different constant": {
+			config(`"us-west-2"`, `"a"`, "var.role", nil),
+			false,
+		},
+		"different nested reference": {
+			config(`"us-east-1"`, `"a"`, "var.other", nil),
+			false,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := fingerprint(test.exprs); (got == base) != test.same {
+				t.Errorf("fingerprint %s, base %s; want same: %t", got, base, test.same)
+			}
+		})
+	}
+}
+
+func TestMarshalProviderConfigs(t *testing.T) {
+	f, diags := hclsyntax.ParseConfig([]byte(`
+region = "us-east-1"
+token  = "hunter2"
+`), "main.tf", hcl.InitialPos)
+	if diags.HasErrors() {
+		t.Fatal(diags.Error())
+	}
+	cfg := &configs.Config{
+		Module: &configs.Module{
+			ProviderRequirements: &configs.RequiredProviders{
+				RequiredProviders: map[string]*configs.RequiredProvider{},
+			},
+			ProviderConfigs: map[string]*configs.Provider{
+				"test": {
+					Name:   "test",
+					Config: f.Body,
+				},
+			},
+		},
+	}
+	schemas := &tofu.Schemas{
+		Providers: map[addrs.Provider]providers.ProviderSchema{
+			addrs.NewDefaultProvider("test"): {
+				Provider: providers.Schema{
+					Block: &configschema.Block{
+						Attributes: map[string]*configschema.Attribute{
+							"region": {Type: cty.String, Optional: true},
+							"token":  {Type: cty.String, Optional: true, Sensitive: true},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	src, err := MarshalProviderConfigs(cfg, schemas)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]any
+	if err := json.Unmarshal(src, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]any{
+		"provider_config": map[string]any{
+			"test": map[string]any{
+				"name":      "test",
+				"full_name": "registry.opentofu.org/hashicorp/test",
+				"expressions": map[string]any{
+					"region": map[string]any{"constant_value": "us-east-1"},
+					"token":  map[string]any{"constant_redacted": true},
+				},
+			},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("wrong result\n%s", diff)
+	}
+}
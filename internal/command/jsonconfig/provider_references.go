@@ -0,0 +1,101 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+// Copyright (c) 2023 HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package jsonconfig
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/opentofu/opentofu/internal/addrs"
+	"github.com/opentofu/opentofu/internal/configs"
+)
+
+// unresolvedProviderReference describes a resource whose "provider"
+// argument refers to an alternate provider configuration that is neither
+// declared in its module nor passed into it by the module call.
+type unresolvedProviderReference struct {
+	// Address is the absolute address of the resource.
+	Address string `json:"address"`
+
+	// Provider is the provider configuration reference as written in the
+	// resource's "provider" argument, such as "aws.west".
+	Provider string `json:"provider"`
+
+	Message string `json:"message"`
+}
+
+// unresolvedProviderReferences returns the resources in the given
+// configuration whose explicit provider configuration reference doesn't
+// match any configuration available in their module, sorted by address, or
+// nil if there are none.
+//
+// This is advisory: OpenTofu reports these as errors during validation, but
+// consumers of the JSON representation might be inspecting a configuration
+// that has not been validated. Only references to alternate configurations
+// are checked, because a default provider configuration is always
+// available, either inherited or empty.
+func unresolvedProviderReferences(c *configs.Config) []unresolvedProviderReference {
+	var ret []unresolvedProviderReference
+
+	var walk func(c *configs.Config)
+	walk = func(c *configs.Config) {
+		if c == nil || c.Module == nil {
+			return
+		}
+		prefix := ""
+		if !c.Path.IsRoot() {
+			prefix = c.Path.String() + "."
+		}
+
+		available := make(map[string]struct{})
+		for _, p := range c.Module.ProviderConfigs {
+			available[p.Addr().StringCompact()] = struct{}{}
+		}
+		if c.Parent != nil && c.Parent.Module != nil {
+			if mc := c.Parent.Module.ModuleCalls[c.Path[len(c.Path)-1]]; mc != nil {
+				for _, pp := range mc.Providers {
+					addr := addrs.LocalProviderConfig{LocalName: pp.InChild.Name, Alias: pp.InChild.Alias}
+					available[addr.StringCompact()] = struct{}{}
+				}
+			}
+		}
+
+		for _, rcs := range []map[string]*configs.Resource{
+			c.Module.ManagedResources,
+			c.Module.DataResources,
+			c.Module.EphemeralResources,
+		} {
+			for _, r := range rcs {
+				if r.ProviderConfigRef == nil || r.ProviderConfigRef.Alias == "" {
+					continue
+				}
+				ref := r.ProviderConfigAddr().StringCompact()
+				if _, ok := available[ref]; ok {
+					continue
+				}
+				msg := fmt.Sprintf("There is no provider configuration %q declared in this module.", ref)
+				if c.Parent != nil {
+					msg = fmt.Sprintf("There is no provider configuration %q declared in this module or passed to it in the \"providers\" argument of its module call.", ref)
+				}
+				ret = append(ret, unresolvedProviderReference{
+					Address:  prefix + r.Addr().String(),
+					Provider: ref,
+					Message:  msg,
+				})
+			}
+		}
+
+		for _, child := range c.Children {
+			walk(child)
+		}
+	}
+	walk(c)
+
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].Address < ret[j].Address
+	})
+	return ret
+}
@@ -0,0 +1,82 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+// Copyright (c) 2023 HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package jsonconfig
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/opentofu/opentofu/internal/addrs"
+	"github.com/opentofu/opentofu/internal/configs"
+)
+
+func TestUnresolvedProviderReferences(t *testing.T) {
+	resource := func(name, provider, alias string) *configs.Resource {
+		r := &configs.Resource{
+			Mode: addrs.ManagedResourceMode,
+			Type: "aws_instance",
+			Name: name,
+		}
+		if provider != "" {
+			r.ProviderConfigRef = &configs.ProviderConfigRef{Name: provider, Alias: alias}
+		}
+		return r
+	}
+
+	root := &configs.Config{
+		Module: &configs.Module{
+			ProviderConfigs: map[string]*configs.Provider{
+				"aws.east": {Name: "aws", Alias: "east"},
+			},
+			ManagedResources: map[string]*configs.Resource{
+				"aws_instance.default":  resource("default", "", ""),
+				"aws_instance.declared": resource("declared", "aws", "east"),
+				"aws_instance.missing":  resource("missing", "aws", "west"),
+			},
+			ModuleCalls: map[string]*configs.ModuleCall{
+				"child": {
+					Name: "child",
+					Providers: []configs.PassedProviderConfig{
+						{
+							InChild:  &configs.ProviderConfigRef{Name: "aws", Alias: "passed"},
+							InParent: &configs.ProviderConfigRef{Name: "aws", Alias: "east"},
+						},
+					},
+				},
+			},
+		},
+	}
+	root.Children = map[string]*configs.Config{
+		"child": {
+			Path:   addrs.RootModule.Child("child"),
+			Parent: root,
+			Module: &configs.Module{
+				ManagedResources: map[string]*configs.Resource{
+					"aws_instance.passed":    resource("passed", "aws", "passed"),
+					"aws_instance.inherited": resource("inherited", "aws", "east"),
+				},
+			},
+		},
+	}
+
+	got := unresolvedProviderReferences(root)
+	want := []unresolvedProviderReference{
+		{
+			Address:  "aws_instance.missing",
+			Provider: "aws.west",
+			Message:  `There is no provider configuration "aws.west" declared in this module.`,
+		},
+		{
+			Address:  "module.child.aws_instance.inherited",
+			Provider: "aws.east",
+			Message:  `There is no provider configuration "aws.east" declared in this module or passed to it in the "providers" argument of its module call.`,
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("wrong result\n%s", diff)
+	}
+}
@@ -0,0 +1,160 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+// Copyright (c) 2023 HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package jsonconfig
+
+import (
+	"sort"
+
+	"github.com/hashicorp/hcl/v2"
+
+	"github.com/opentofu/opentofu/internal/addrs"
+	"github.com/opentofu/opentofu/internal/configs"
+	"github.com/opentofu/opentofu/internal/tofu"
+)
+
+// sensitivitySummary collects the parts of a configuration that handle
+// sensitive values, so that they can be reviewed in one place. All of the
+// addresses are absolute module paths, like "module.a.var.password".
+type sensitivitySummary struct {
+	// Variables and Outputs list the input variables and output values
+	// that are declared as sensitive.
+	Variables []string `json:"variables,omitempty"`
+	Outputs   []string `json:"outputs,omitempty"`
+
+	// Resources lists the resources whose configuration sets at least one
+	// argument that the provider schema marks as sensitive.
+	Resources []sensitiveResource `json:"resources,omitempty"`
+
+	// Provisioners lists the provisioners that have a connection block,
+	// either their own or one inherited from their resource, since
+	// connection blocks typically include credentials.
+	Provisioners []connectedProvisioner `json:"provisioners,omitempty"`
+}
+
+type sensitiveResource struct {
+	Address    string   `json:"address"`
+	Attributes []string `json:"attributes"`
+}
+
+type connectedProvisioner struct {
+	ResourceAddress string `json:"resource_address"`
+	Index           int    `json:"index"`
+	Type            string `json:"type"`
+}
+
+// sensitivitySummaryFor returns the sensitivity summary for the given
+// configuration, or nil if there is nothing to report.
+//
+// Resources are reported only if their provider schema is available, so
+// none are reported in single-module mode.
+func sensitivitySummaryFor(c *configs.Config, schemas *tofu.Schemas) *sensitivitySummary {
+	var ret sensitivitySummary
+
+	var walk func(c *configs.Config)
+	walk = func(c *configs.Config) {
+		if c == nil || c.Module == nil {
+			return
+		}
+		prefix := ""
+		if !c.Path.IsRoot() {
+			prefix = c.Path.String() + "."
+		}
+
+		for _, v := range c.Module.Variables {
+			if v.Sensitive {
+				ret.Variables = append(ret.Variables, prefix+addrs.InputVariable{Name: v.Name}.String())
+			}
+		}
+		for _, o := range c.Module.Outputs {
+			if o.Sensitive {
+				ret.Outputs = append(ret.Outputs, prefix+addrs.OutputValue{Name: o.Name}.String())
+			}
+		}
+
+		for _, rcs := range []map[string]*configs.Resource{
+			c.Module.ManagedResources,
+			c.Module.DataResources,
+			c.Module.EphemeralResources,
+		} {
+			for _, r := range rcs {
+				addr := prefix + r.Addr().String()
+				if attrs := configuredSensitiveAttributes(r, schemas); len(attrs) > 0 {
+					ret.Resources = append(ret.Resources, sensitiveResource{
+						Address:    addr,
+						Attributes: attrs,
+					})
+				}
+				if r.Managed == nil {
+					continue
+				}
+				for i, p := range r.Managed.Provisioners {
+					if p.Connection == nil && r.Managed.Connection == nil {
+						continue
+					}
+					ret.Provisioners = append(ret.Provisioners, connectedProvisioner{
+						ResourceAddress: addr,
+						Index:           i,
+						Type:            p.Type,
+					})
+				}
+			}
+		}
+
+		for _, child := range c.Children {
+			walk(child)
+		}
+	}
+	walk(c)
+
+	if len(ret.Variables) == 0 && len(ret.Outputs) == 0 && len(ret.Resources) == 0 && len(ret.Provisioners) == 0 {
+		return nil
+	}
+	sort.Strings(ret.Variables)
+	sort.Strings(ret.Outputs)
+	sort.Slice(ret.Resources, func(i, j int) bool {
+		return ret.Resources[i].Address < ret.Resources[j].Address
+	})
+	sort.Slice(ret.Provisioners, func(i, j int) bool {
+		if ret.Provisioners[i].ResourceAddress != ret.Provisioners[j].ResourceAddress {
+			return ret.Provisioners[i].ResourceAddress < ret.Provisioners[j].ResourceAddress
+		}
+		return ret.Provisioners[i].Index < ret.Provisioners[j].Index
+	})
+	return &ret
+}
+
+// configuredSensitiveAttributes returns the sorted names of the top-level
+// arguments set in the given resource's configuration that its schema marks
+// as sensitive.
+func configuredSensitiveAttributes(r *configs.Resource, schemas *tofu.Schemas) []string {
+	if r.Config == nil {
+		return nil
+	}
+	schema, _ := schemas.ResourceTypeConfig(r.Provider, r.Mode, r.Type)
+	if schema == nil || schema.Block == nil {
+		return nil
+	}
+	var bodySchema hcl.BodySchema
+	for name, attr := range schema.Block.Attributes {
+		if attr.Sensitive {
+			bodySchema.Attributes = append(bodySchema.Attributes, hcl.AttributeSchema{Name: name})
+		}
+	}
+	if len(bodySchema.Attributes) == 0 {
+		return nil
+	}
+	// Any problems with the body are reported during validation instead.
+	content, _, _ := r.Config.PartialContent(&bodySchema)
+	if content == nil || len(content.Attributes) == 0 {
+		return nil
+	}
+	ret := make([]string, 0, len(content.Attributes))
+	for name := range content.Attributes {
+		ret = append(ret, name)
+	}
+	sort.Strings(ret)
+	return ret
+}
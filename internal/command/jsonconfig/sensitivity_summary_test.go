@@ -0,0 +1,130 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+// Copyright (c) 2023 HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package jsonconfig
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/opentofu/opentofu/internal/addrs"
+	"github.com/opentofu/opentofu/internal/configs"
+	"github.com/opentofu/opentofu/internal/configs/configschema"
+	"github.com/opentofu/opentofu/internal/providers"
+	"github.com/opentofu/opentofu/internal/tofu"
+)
+
+func TestSensitivitySummaryFor(t *testing.T) {
+	providerAddr := addrs.NewDefaultProvider("test")
+	schemas := &tofu.Schemas{
+		Providers: map[addrs.Provider]providers.ProviderSchema{
+			providerAddr: {
+				ResourceTypes: map[string]providers.Schema{
+					"test_thing": {
+						Block: &configschema.Block{
+							Attributes: map[string]*configschema.Attribute{
+								"name":     {Type: cty.String, Optional: true},
+								"password": {Type: cty.String, Optional: true, Sensitive: true},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	body := func(src string) hcl.Body {
+		t.Helper()
+		f, diags := hclsyntax.ParseConfig([]byte(src), "test.tf", hcl.InitialPos)
+		if diags.HasErrors() {
+			t.Fatal(diags.Error())
+		}
+		return f.Body
+	}
+
+	root := &configs.Config{
+		Module: &configs.Module{
+			Variables: map[string]*configs.Variable{
+				"secret": {Name: "secret", Sensitive: true},
+				"plain":  {Name: "plain"},
+			},
+			ManagedResources: map[string]*configs.Resource{
+				"test_thing.a": {
+					Mode:     addrs.ManagedResourceMode,
+					Type:     "test_thing",
+					Name:     "a",
+					Provider: providerAddr,
+					Config:   body(`password = var.secret`),
+					Managed: &configs.ManagedResource{
+						Provisioners: []*configs.Provisioner{
+							{Type: "local-exec"},
+							{Type: "remote-exec", Connection: &configs.Connection{}},
+						},
+					},
+				},
+				"test_thing.b": {
+					Mode:     addrs.ManagedResourceMode,
+					Type:     "test_thing",
+					Name:     "b",
+					Provider: providerAddr,
+					Config:   body(`name = "b"`),
+					Managed:  &configs.ManagedResource{},
+				},
+			},
+		},
+	}
+	root.Root = root
+	root.Children = map[string]*configs.Config{
+		"child": {
+			Path:   addrs.RootModule.Child("child"),
+			Parent: root,
+			Root:   root,
+			Module: &configs.Module{
+				Outputs: map[string]*configs.Output{
+					"token": {Name: "token", Sensitive: true},
+					"id":    {Name: "id"},
+				},
+				ManagedResources: map[string]*configs.Resource{
+					"test_thing.c": {
+						Mode:     addrs.ManagedResourceMode,
+						Type:     "test_thing",
+						Name:     "c",
+						Provider: providerAddr,
+						Config:   body(`name = "c"`),
+						Managed: &configs.ManagedResource{
+							Connection: &configs.Connection{},
+							Provisioners: []*configs.Provisioner{
+								{Type: "file"},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	got := sensitivitySummaryFor(root, schemas)
+	want := &sensitivitySummary{
+		Variables: []string{"var.secret"},
+		Outputs:   []string{"module.child.output.token"},
+		Resources: []sensitiveResource{
+			{Address: "test_thing.a", Attributes: []string{"password"}},
+		},
+		Provisioners: []connectedProvisioner{
+			{ResourceAddress: "module.child.test_thing.c", Index: 0, Type: "file"},
+			{ResourceAddress: "test_thing.a", Index: 1, Type: "remote-exec"},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("wrong result\n%s", diff)
+	}
+
+	if got := sensitivitySummaryFor(&configs.Config{Module: &configs.Module{}}, schemas); got != nil {
+		t.Errorf("unexpected summary for empty configuration: %#v", got)
+	}
+}
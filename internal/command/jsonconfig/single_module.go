@@ -37,7 +37,7 @@ func MarshalSingleModule(m *configs.Module) ([]byte, error) {
 		// Everything else intentionally not populated because single module
 		// mode should not attempt to access anything else.
 	}
-	return marshal(cfg, nil)
+	return marshal(cfg, nil, MarshalOpts{})
 }
 
 // inSingleModuleMode returns true if the given schema value indicates that
@@ -0,0 +1,60 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+// Copyright (c) 2023 HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package jsonconfig
+
+import (
+	"github.com/opentofu/opentofu/internal/addrs"
+	"github.com/opentofu/opentofu/internal/configs"
+	"github.com/opentofu/opentofu/internal/getproviders"
+	"github.com/opentofu/opentofu/internal/tfdiags"
+)
+
+// SubtreeProviderRequirements returns the providers required by the module
+// with the given path, such as "module.foo", and by all of its descendant
+// modules, mapping each provider's fully-qualified source address to the
+// version constraints merged from every module in that subtree. A provider
+// required without any version constraint maps to an empty string. An empty
+// path refers to the root module, and so to the whole configuration.
+//
+// The second return value is false if there is no module with the given
+// path.
+func SubtreeProviderRequirements(c *configs.Config, modPath string) (map[string]string, bool) {
+	path := addrs.RootModule
+	if modPath != "" {
+		var diags tfdiags.Diagnostics
+		path, diags = addrs.ParseModuleStr(modPath)
+		if diags.HasErrors() {
+			return nil, false
+		}
+	}
+
+	mc := c.Descendent(path)
+	if mc == nil || mc.Module == nil {
+		return nil, false
+	}
+
+	all := make(getproviders.Requirements)
+	var walk func(c *configs.Config)
+	walk = func(c *configs.Config) {
+		if c == nil || c.Module == nil {
+			return
+		}
+		// Any problems with the requirements are reported during validation
+		// instead.
+		reqs, _ := c.ProviderRequirementsShallow()
+		all = all.Merge(reqs)
+		for _, child := range c.Children {
+			walk(child)
+		}
+	}
+	walk(mc)
+
+	ret := make(map[string]string, len(all))
+	for addr, vc := range all {
+		ret[addr.String()] = getproviders.VersionConstraintsString(vc)
+	}
+	return ret, true
+}
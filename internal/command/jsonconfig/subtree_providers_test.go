@@ -0,0 +1,102 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+// Copyright (c) 2023 HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package jsonconfig
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	version "github.com/hashicorp/go-version"
+
+	"github.com/opentofu/opentofu/internal/addrs"
+	"github.com/opentofu/opentofu/internal/configs"
+)
+
+func TestSubtreeProviderRequirements(t *testing.T) {
+	module := func(constraints map[string]string) *configs.Module {
+		reqs := make(map[string]*configs.RequiredProvider)
+		for name, c := range constraints {
+			rp := &configs.RequiredProvider{
+				Name: name,
+				Type: addrs.NewDefaultProvider(name),
+			}
+			if c != "" {
+				rp.Requirement = configs.VersionConstraint{
+					Required: version.MustConstraints(version.NewConstraint(c)),
+				}
+			}
+			reqs[name] = rp
+		}
+		return &configs.Module{
+			ProviderRequirements: &configs.RequiredProviders{RequiredProviders: reqs},
+		}
+	}
+
+	root := &configs.Config{
+		Module: module(map[string]string{"aws": "~> 5.0"}),
+	}
+	a := &configs.Config{
+		Parent: root,
+		Path:   addrs.RootModule.Child("a"),
+		Module: module(map[string]string{"aws": ">= 5.1", "null": ""}),
+	}
+	b := &configs.Config{
+		Parent: a,
+		Path:   addrs.RootModule.Child("a").Child("b"),
+		Module: module(map[string]string{"random": ">= 3.0"}),
+	}
+	root.Children = map[string]*configs.Config{"a": a}
+	a.Children = map[string]*configs.Config{"b": b}
+
+	tests := map[string]struct {
+		modPath string
+		want    map[string]string
+		wantOK  bool
+	}{
+		"whole configuration": {
+			want: map[string]string{
+				"registry.opentofu.org/hashicorp/aws":    "~> 5.0, >= 5.1.0",
+				"registry.opentofu.org/hashicorp/null":   "",
+				"registry.opentofu.org/hashicorp/random": ">= 3.0.0",
+			},
+			wantOK: true,
+		},
+		"subtree": {
+			modPath: "module.a",
+			want: map[string]string{
+				"registry.opentofu.org/hashicorp/aws":    ">= 5.1.0",
+				"registry.opentofu.org/hashicorp/null":   "",
+				"registry.opentofu.org/hashicorp/random": ">= 3.0.0",
+			},
+			wantOK: true,
+		},
+		"leaf module": {
+			modPath: "module.a.module.b",
+			want: map[string]string{
+				"registry.opentofu.org/hashicorp/random": ">= 3.0.0",
+			},
+			wantOK: true,
+		},
+		"no such module": {
+			modPath: "module.c",
+		},
+		"invalid path": {
+			modPath: "module.a[0]",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, ok := SubtreeProviderRequirements(root, test.modPath)
+			if ok != test.wantOK {
+				t.Fatalf("wrong ok result %t; want %t", ok, test.wantOK)
+			}
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("wrong result\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,269 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+// Copyright (c) 2023 HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package jsonconfig
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/opentofu/opentofu/internal/addrs"
+)
+
+// ValidateOutput checks that the given result of [Marshal] or
+// [MarshalWithOpts] meets the structural invariants of the configuration
+// representation, returning an error describing the first violation found,
+// if any:
+//   - Every resource's "provider_config_key", and each of the
+//     "provider_config_keys" of module calls, refers to one of the entries in
+//     "provider_config".
+//   - Every resource's "mode" is "managed", "data", or "ephemeral".
+//   - Every string in the "references" of each expression is a valid
+//     reference.
+//
+// References qualified by the path of their module, as produced by
+// [MarshalOpts.AbsoluteAddresses], are checked by parsing what follows that
+// path. This is recognized from the addresses of the resources in the child
+// modules, so the references of a result whose child modules have no
+// resources are checked as relative references, which accepts any
+// qualified reference as a reference to a module output.
+//
+// The result must use the default representation rather than the one
+// produced by [MarshalOpts.Ordered]. Results of [MarshalSingleModule] have no
+// provider configurations or expressions, so they are not supported either.
+func ValidateOutput(data []byte) error {
+	var c config
+	if err := json.Unmarshal(data, &c); err != nil {
+		return fmt.Errorf("invalid configuration representation: %w", err)
+	}
+
+	if err := validate(c); err != nil {
+		return err
+	}
+
+	absolute := hasAbsoluteAddresses(c.RootModule)
+
+	keys := make([]string, 0, len(c.ProviderConfigs))
+	for key := range c.ProviderConfigs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		pc := c.ProviderConfigs[key]
+		var prefix string
+		if absolute && pc.ModuleAddress != "" {
+			prefix = pc.ModuleAddress + "."
+		}
+		if err := validateJSONReferences(pc.Expressions, prefix); err != nil {
+			return fmt.Errorf("provider configuration %s: %w", key, err)
+		}
+	}
+
+	return validateModuleContent(c.RootModule, "", absolute)
+}
+
+// hasAbsoluteAddresses returns true if the resources of any of the
+// descendents of the given root module have addresses qualified by the path
+// of their module, as produced by [MarshalOpts.AbsoluteAddresses].
+func hasAbsoluteAddresses(root module) bool {
+	var check func(m module, prefix string) bool
+	check = func(m module, prefix string) bool {
+		for _, r := range m.Resources {
+			if prefix != "" && strings.HasPrefix(r.Address, prefix) {
+				return true
+			}
+		}
+		for _, name := range sortedKeys(m.ModuleCalls) {
+			mc := m.ModuleCalls[name]
+			if mc.Module != nil && check(*mc.Module, prefix+addrs.ModuleCall{Name: name}.String()+".") {
+				return true
+			}
+		}
+		return false
+	}
+	return check(root, "")
+}
+
+// validateModuleContent checks the resource modes and the references of the
+// expressions in the given module and its descendents, for [ValidateOutput].
+// The prefix is the path of the module followed by a period, or empty for
+// the root module, and absolute is set if the references are qualified by
+// it.
+func validateModuleContent(m module, prefix string, absolute bool) error {
+	refPrefix := ""
+	if absolute {
+		refPrefix = prefix
+	}
+
+	for _, r := range m.Resources {
+		switch r.Mode {
+		case "managed", "data", "ephemeral":
+		default:
+			return fmt.Errorf("resource %s has invalid mode %q", r.Address, r.Mode)
+		}
+
+		exprs := []any{r.Expressions, r.CountExpression, r.ForEachExpression}
+		for _, p := range r.Provisioners {
+			exprs = append(exprs, p.Expressions, p.Connection)
+		}
+		for _, rule := range slices.Concat(r.Preconditions, r.Postconditions) {
+			exprs = append(exprs, rule.Condition, rule.ErrorMessage)
+		}
+		if r.Lifecycle != nil {
+			exprs = append(exprs, expression{References: r.Lifecycle.ReplaceTriggeredBy})
+		}
+		for _, expr := range exprs {
+			if err := validateJSONReferences(expr, refPrefix); err != nil {
+				return fmt.Errorf("resource %s: %w", r.Address, err)
+			}
+		}
+	}
+
+	for _, name := range sortedKeys(m.Outputs) {
+		o := m.Outputs[name]
+		exprs := []any{o.Expression}
+		for _, rule := range o.Preconditions {
+			exprs = append(exprs, rule.Condition, rule.ErrorMessage)
+		}
+		for _, expr := range exprs {
+			if err := validateJSONReferences(expr, refPrefix); err != nil {
+				return fmt.Errorf("output %s: %w", name, err)
+			}
+		}
+	}
+
+	for _, name := range sortedKeys(m.Variables) {
+		for _, rule := range m.Variables[name].Validations {
+			for _, expr := range []any{rule.Condition, rule.ErrorMessage} {
+				if err := validateJSONReferences(expr, refPrefix); err != nil {
+					return fmt.Errorf("variable %s: %w", name, err)
+				}
+			}
+		}
+	}
+
+	for _, name := range sortedKeys(m.ModuleCalls) {
+		mc := m.ModuleCalls[name]
+		for _, expr := range []any{mc.Expressions, mc.CountExpression, mc.ForEachExpression} {
+			if err := validateJSONReferences(expr, refPrefix); err != nil {
+				return fmt.Errorf("module call %s: %w", name, err)
+			}
+		}
+		if mc.Module == nil {
+			continue
+		}
+		if err := validateModuleContent(*mc.Module, prefix+addrs.ModuleCall{Name: name}.String()+".", absolute); err != nil {
+			return fmt.Errorf("module call %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// validateJSONReferences checks that each of the references in the given
+// expressions, which can be anything accepted by [walkExpressions] or the
+// generic representation of block expressions decoded from JSON, can be
+// parsed as a reference. Any of the references that start with the given
+// prefix are parsed without it.
+func validateJSONReferences(v any, prefix string) error {
+	var err error
+	check := func(refs []string) {
+		for _, ref := range refs {
+			if err != nil {
+				return
+			}
+			// References to values like count.index are never qualified.
+			relative := ref
+			if prefix != "" {
+				relative = strings.TrimPrefix(ref, prefix)
+			}
+			if _, diags := addrs.ParseRefStr(relative); diags.HasErrors() {
+				err = fmt.Errorf("invalid reference %q: %w", ref, diags.Err())
+			}
+		}
+	}
+
+	walkExpressions(v, func(expr expression) {
+		check(expr.References)
+	})
+	if err != nil {
+		return err
+	}
+
+	// Block expressions decoded from JSON are generic maps and slices rather
+	// than expression objects, so walkExpressions can't find them.
+	walkDecodedExpressions(v, check)
+	return err
+}
+
+// expressionProperties is the set of properties of the JSON representation
+// of [expression], used to recognize expressions in generic decoded JSON.
+var expressionProperties = map[string]struct{}{
+	"constant_value":       {},
+	"references":           {},
+	"range":                {},
+	"source_text":          {},
+	"references_sensitive": {},
+	"template":             {},
+	"value_with_defaults":  {},
+	"constant_redacted":    {},
+}
+
+// walkDecodedExpressions calls the given function with the references of
+// each object within the given generic decoded JSON value that has only the
+// properties of an expression.
+func walkDecodedExpressions(v any, fn func([]string)) {
+	switch v := v.(type) {
+	case expressions:
+		walkDecodedExpressions(map[string]any(v), fn)
+	case map[string]any:
+		if isDecodedExpression(v) {
+			refs, _ := v["references"].([]any)
+			strs := make([]string, 0, len(refs))
+			for _, ref := range refs {
+				if s, ok := ref.(string); ok {
+					strs = append(strs, s)
+				}
+			}
+			fn(strs)
+			return
+		}
+		for _, nested := range v {
+			walkDecodedExpressions(nested, fn)
+		}
+	case []any:
+		for _, nested := range v {
+			walkDecodedExpressions(nested, fn)
+		}
+	}
+}
+
+func isDecodedExpression(v map[string]any) bool {
+	if len(v) == 0 {
+		return false
+	}
+	for k := range v {
+		if _, ok := expressionProperties[k]; !ok {
+			return false
+		}
+	}
+	if refs, exists := v["references"]; exists {
+		if _, ok := refs.([]any); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
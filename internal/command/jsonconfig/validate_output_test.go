@@ -0,0 +1,157 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+// Copyright (c) 2023 HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package jsonconfig
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateOutput(t *testing.T) {
+	tests := map[string]struct {
+		Input   string
+		WantErr string
+	}{
+		"valid": {
+			Input: `{
+				"provider_config": {"test": {"name": "test"}},
+				"root_module": {
+					"resources": [{
+						"address": "test_thing.a",
+						"mode": "managed",
+						"provider_config_key": "test",
+						"expressions": {
+							"name": {"references": ["var.name"]},
+							"rule": [{"value": {"references": ["count.index"]}}]
+						}
+					}],
+					"module_calls": {
+						"child": {
+							"expressions": {"id": {"references": ["test_thing.a.id", "test_thing.a"]}},
+							"module": {
+								"resources": [{
+									"address": "data.test_thing.b",
+									"mode": "data",
+									"provider_config_key": "test"
+								}]
+							}
+						}
+					}
+				}
+			}`,
+		},
+		"absolute addresses": {
+			Input: `{
+				"provider_config": {
+					"test": {"name": "test"},
+					"module.child:test": {"name": "test", "module_address": "module.child", "expressions": {"region": {"references": ["module.child.var.region"]}}}
+				},
+				"root_module": {
+					"module_calls": {
+						"child": {
+							"expressions": {"region": {"references": ["var.region"]}},
+							"module": {
+								"resources": [{
+									"address": "module.child.test_thing.b",
+									"mode": "managed",
+									"provider_config_key": "module.child:test",
+									"expressions": {"name": {"references": ["module.child.var.name", "count.index"]}}
+								}]
+							}
+						}
+					}
+				}
+			}`,
+		},
+		"invalid absolute reference": {
+			Input: `{
+				"provider_config": {"test": {"name": "test"}},
+				"root_module": {
+					"module_calls": {
+						"child": {
+							"module": {
+								"resources": [{
+									"address": "module.child.test_thing.b",
+									"mode": "managed",
+									"provider_config_key": "test",
+									"expressions": {"name": {"references": ["module.child.var"]}}
+								}]
+							}
+						}
+					}
+				}
+			}`,
+			WantErr: `module call child: resource module.child.test_thing.b: invalid reference "module.child.var"`,
+		},
+		"not json": {
+			Input:   `{`,
+			WantErr: "invalid configuration representation",
+		},
+		"dangling provider key": {
+			Input: `{
+				"root_module": {
+					"resources": [{"address": "test_thing.a", "mode": "managed", "provider_config_key": "test"}]
+				}
+			}`,
+			WantErr: `resource test_thing.a has provider_config_key "test"`,
+		},
+		"invalid mode": {
+			Input: `{
+				"provider_config": {"test": {"name": "test"}},
+				"root_module": {
+					"resources": [{"address": "test_thing.a", "mode": "resource", "provider_config_key": "test"}]
+				}
+			}`,
+			WantErr: `resource test_thing.a has invalid mode "resource"`,
+		},
+		"invalid reference in nested block": {
+			Input: `{
+				"provider_config": {"test": {"name": "test"}},
+				"root_module": {
+					"resources": [{
+						"address": "test_thing.a",
+						"mode": "managed",
+						"provider_config_key": "test",
+						"expressions": {"rule": [{"value": {"references": ["var"]}}]}
+					}]
+				}
+			}`,
+			WantErr: `resource test_thing.a: invalid reference "var"`,
+		},
+		"invalid reference in child module output": {
+			Input: `{
+				"root_module": {
+					"module_calls": {
+						"child": {
+							"module": {
+								"outputs": {"id": {"expression": {"references": ["module"]}}}
+							}
+						}
+					}
+				}
+			}`,
+			WantErr: `module call child: output id: invalid reference "module"`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := ValidateOutput([]byte(test.Input))
+			if test.WantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("unexpected success; want error containing %q", test.WantErr)
+			}
+			if !strings.Contains(err.Error(), test.WantErr) {
+				t.Errorf("wrong error\ngot:  %s\nwant: %s", err, test.WantErr)
+			}
+		})
+	}
+}
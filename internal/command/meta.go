@@ -192,6 +192,14 @@ type Meta struct {
 	backendArgs arguments.Backend
 	parallelism int
 
+	// workspaceOverride, if set, is used as the current workspace in
+	// preference to both the TF_WORKSPACE environment variable and the
+	// workspace recorded in the data directory. createWorkspace allows the
+	// selected workspace to be absent from the backend when it is loaded,
+	// leaving the command responsible for creating it.
+	workspaceOverride string
+	createWorkspace   bool
+
 	// Used to cache the root module rootModuleCallCache and known variables.
 	// This helps prevent duplicate errors/warnings.
 	rootModuleCallCache *configs.StaticModuleCall
@@ -476,8 +484,12 @@ func (m *Meta) Workspace(ctx context.Context) (string, error) {
 
 // WorkspaceOverridden returns the name of the currently configured workspace,
 // corresponding to the desired named state, as well as a bool saying whether
-// this was set via the TF_WORKSPACE environment variable.
+// this was set via the TF_WORKSPACE environment variable or a command's
+// -workspace flag.
 func (m *Meta) WorkspaceOverridden(_ context.Context) (string, bool) {
+	if m.workspaceOverride != "" {
+		return m.workspaceOverride, true
+	}
 	if envVar := os.Getenv(WorkspaceNameEnvVar); envVar != "" {
 		return envVar, true
 	}
@@ -51,6 +51,10 @@ type BackendOpts struct {
 	// arguments in Config.
 	ConfigOverride hcl.Body
 
+	// ConfigDir is the directory to look for a backend configuration block in
+	// when Config is nil. If empty, the current working directory is used.
+	ConfigDir string
+
 	// Init should be set to true if initialization is allowed. If this is
 	// false, then any configuration that requires configuration will show
 	// an error asking the user to reinitialize.
@@ -248,7 +252,7 @@ func (m *Meta) selectWorkspace(ctx context.Context, b backend.Backend) error {
 	if err != nil {
 		return fmt.Errorf("Failed to get existing workspaces: %w", err)
 	}
-	if len(workspaces) == 0 {
+	if len(workspaces) == 0 && !m.createWorkspace {
 		if c, ok := b.(*cloud.Cloud); ok && m.input {
 			// len is always 1 if using Name; 0 means we're using Tags and there
 			// aren't any matching workspaces. Which might be normal and fine, so
@@ -289,6 +293,19 @@ func (m *Meta) selectWorkspace(ctx context.Context, b backend.Backend) error {
 		fmt.Fprintf(&list, "%d. %s\n", i+1, w)
 	}
 
+	// The command will create the selected workspace itself once the
+	// backend is loaded.
+	if m.createWorkspace {
+		log.Printf("[TRACE] Meta.selectWorkspace: the selected workspace %q will be created by the command", workspace)
+		return nil
+	}
+
+	// A workspace given explicitly by a command's -workspace flag must
+	// never be silently swapped for another one.
+	if m.workspaceOverride != "" {
+		return fmt.Errorf("Workspace %q does not exist in the configured backend", workspace)
+	}
+
 	// If the backend only has a single workspace, select that as the current workspace
 	if len(workspaces) == 1 {
 		log.Printf("[TRACE] Meta.selectWorkspace: automatically selecting the single workspace provided by the backend (%s)", workspaces[0])
@@ -491,7 +508,11 @@ func (m *Meta) backendConfig(ctx context.Context, opts *BackendOpts) (*configs.B
 
 	if opts.Config == nil {
 		// check if the config was missing, or just not required
-		conf, moreDiags := m.loadBackendConfig(ctx, ".")
+		configDir := opts.ConfigDir
+		if configDir == "" {
+			configDir = "."
+		}
+		conf, moreDiags := m.loadBackendConfig(ctx, configDir)
 		diags = diags.Append(moreDiags)
 		if moreDiags.HasErrors() {
 			return nil, 0, diags
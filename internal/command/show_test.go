@@ -1817,8 +1817,10 @@ func TestShow_module(t *testing.T) {
 		"root_module": map[string]any{
 			"module_calls": map[string]any{
 				"child": map[string]any{
-					"source":             "example.com/not/actually/used",
-					"version_constraint": "~> 1.0.0",
+					"source":               "example.com/not/actually/used",
+					"version_constraint":   "~> 1.0.0",
+					"provider_inheritance": "inherited",
+					"expansion_mode":       "single",
 					// "module" intentionally omitted in single-module mode
 					// "expressions" intentionally omitted in single-module mode
 				},
@@ -1836,6 +1838,7 @@ func TestShow_module(t *testing.T) {
 					"type":                "test_instance",
 					"name":                "foo",
 					"provider_config_key": "test",
+					"expansion_mode":      "count",
 					// "expressions" intentionally omitted in single-module mode
 					// "schema_version" intentionally omitted in single-module mode (because we're not including anything that's schema-sensitive)
 					// "for_each_expression" intentionally omitted in single-module mode
@@ -0,0 +1,9 @@
+provider "test" {
+    foo = "bar"
+}
+
+resource "test_instance" "foo" {
+}
+
+resource "test_instance" "bar" {
+}
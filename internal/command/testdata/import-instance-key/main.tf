@@ -0,0 +1,14 @@
+provider "test" {
+    foo = "bar"
+}
+
+resource "test_instance" "single" {
+}
+
+resource "test_instance" "counted" {
+  count = 2
+}
+
+resource "test_instance" "each" {
+  for_each = toset(["a", "b"])
+}
@@ -6,10 +6,17 @@
 package views
 
 import (
+	"context"
+	encJson "encoding/json"
 	"fmt"
 
 	"github.com/opentofu/opentofu/internal/addrs"
 	"github.com/opentofu/opentofu/internal/command/arguments"
+	"github.com/opentofu/opentofu/internal/command/jsonformat"
+	"github.com/opentofu/opentofu/internal/command/jsonprovider"
+	"github.com/opentofu/opentofu/internal/command/jsonstate"
+	"github.com/opentofu/opentofu/internal/command/views/json"
+	"github.com/opentofu/opentofu/internal/states/statefile"
 	"github.com/opentofu/opentofu/internal/tfdiags"
 	"github.com/opentofu/opentofu/internal/tofu"
 )
@@ -20,10 +27,28 @@ type Import interface {
 	Operation() Operation
 
 	InvalidAddressReference()
+
+	// InstanceKey echoes the string instance key parsed from the target
+	// address, so that the user can confirm that it survived shell quoting
+	// intact.
+	InstanceKey(addr addrs.AbsResourceInstance, key string)
+
 	MissingResourceConfiguration(addr addrs.AbsResourceInstance, modulePath string, resourceType string, resourceName string)
-	Success()
+
+	// Success reports that the given number of resources were imported and
+	// saved to the state.
+	Success(count int)
 	UnsupportedLocalOp()
 
+	// ReadOnlyResult shows the imported object in the given state file, whose
+	// sensitive attributes must already have been redacted, when the import
+	// was requested with -read-only. It returns the exit code for the command.
+	ReadOnlyResult(ctx context.Context, stateFile *statefile.File, schemas *tofu.Schemas) int
+
+	// Capabilities shows the given JSON report of the import capabilities
+	// of providers, for the -capabilities option.
+	Capabilities(report []byte)
+
 	// Backend returns the non-command view that contains methods to provide
 	// progress output for the backend operations.
 	Backend() Backend
@@ -64,15 +89,35 @@ func (m ImportMulti) InvalidAddressReference() {
 	}
 }
 
+func (m ImportMulti) InstanceKey(addr addrs.AbsResourceInstance, key string) {
+	for _, o := range m {
+		o.InstanceKey(addr, key)
+	}
+}
+
 func (m ImportMulti) MissingResourceConfiguration(addr addrs.AbsResourceInstance, modulePath string, resourceType string, resourceName string) {
 	for _, o := range m {
 		o.MissingResourceConfiguration(addr, modulePath, resourceType, resourceName)
 	}
 }
 
-func (m ImportMulti) Success() {
+func (m ImportMulti) Success(count int) {
+	for _, o := range m {
+		o.Success(count)
+	}
+}
+
+func (m ImportMulti) ReadOnlyResult(ctx context.Context, stateFile *statefile.File, schemas *tofu.Schemas) int {
+	var ret int
+	for _, o := range m {
+		ret = max(ret, o.ReadOnlyResult(ctx, stateFile, schemas))
+	}
+	return ret
+}
+
+func (m ImportMulti) Capabilities(report []byte) {
 	for _, o := range m {
-		o.Success()
+		o.Capabilities(report)
 	}
 }
 
@@ -123,6 +168,10 @@ https://opentofu.org/docs/cli/state/resource-addressing/`
 	_, _ = v.view.streams.Println(msg)
 }
 
+func (v *ImportHuman) InstanceKey(addr addrs.AbsResourceInstance, key string) {
+	_, _ = v.view.streams.Println(fmt.Sprintf("Importing into %s, whose instance key is %q.", addr, key))
+}
+
 func (v *ImportHuman) MissingResourceConfiguration(addr addrs.AbsResourceInstance, modulePath string, resourceType string, resourceName string) {
 	// This is not a diagnostic because currently our diagnostics printer
 	// doesn't support having a code example in the detail, and there's
@@ -146,16 +195,56 @@ resource %q %q {
 	_, _ = v.view.streams.Eprintln(output)
 }
 
-func (v *ImportHuman) Success() {
-	const msg = `Import successful!
+func (v *ImportHuman) Success(count int) {
+	const msg = `
 
 The resources that were imported are shown above. These resources are now in
 your OpenTofu state and will henceforth be managed by OpenTofu.`
 
-	output := v.view.colorize.Color(fmt.Sprintf("\n[reset][green]%s\n", msg))
+	summary := "Import successful!"
+	if count > 1 {
+		summary = fmt.Sprintf("Import successful! %d resources were imported.", count)
+	}
+	output := v.view.colorize.Color(fmt.Sprintf("\n[reset][green]%s%s\n", summary, msg))
 	_, _ = v.view.streams.Println(output)
 }
 
+func (v *ImportHuman) ReadOnlyResult(_ context.Context, stateFile *statefile.File, schemas *tofu.Schemas) int {
+	renderer := jsonformat.Renderer{
+		Colorize:            v.view.colorize,
+		Streams:             v.view.streams,
+		RunningInAutomation: v.view.runningInAutomation,
+	}
+
+	root, outputs, err := jsonstate.MarshalForRenderer(stateFile, schemas)
+	if err != nil {
+		v.Diagnostics(tfdiags.Diagnostics{}.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Failed to marshal state to json",
+			fmt.Sprintf("Error while marshalling state to json: %s", err),
+		)))
+		return 1
+	}
+
+	renderer.RenderHumanState(jsonformat.State{
+		StateFormatVersion:    jsonstate.FormatVersion,
+		ProviderFormatVersion: jsonprovider.FormatVersion,
+		RootModule:            root,
+		RootModuleOutputs:     outputs,
+		ProviderSchemas:       jsonprovider.MarshalForRenderer(schemas),
+	})
+
+	const msg = `The object shown above was read from the provider, but nothing was persisted:
+it has not been saved to your OpenTofu state. Run this command again without
+-read-only or -dry-run to import it.`
+	_, _ = v.view.streams.Println(v.view.colorize.Color(fmt.Sprintf("\n[reset][bold]%s", msg)))
+	return 0
+}
+
+func (v *ImportHuman) Capabilities(report []byte) {
+	_, _ = v.view.streams.Println(string(report))
+}
+
 func (v *ImportHuman) UnsupportedLocalOp() {
 	v.Diagnostics(tfdiags.Diagnostics{diagUnsupportedLocalOp})
 }
@@ -189,16 +278,50 @@ func (v *ImportJSON) InvalidAddressReference() {
 	v.view.Info(msg)
 }
 
+func (v *ImportJSON) InstanceKey(addr addrs.AbsResourceInstance, key string) {
+	v.view.Info(fmt.Sprintf("Importing into %s, whose instance key is %q", addr, key))
+}
+
 func (v *ImportJSON) MissingResourceConfiguration(addr addrs.AbsResourceInstance, modulePath string, _ string, _ string) {
 	msg := fmt.Sprintf("Resource address %q does not exist in the configuration. Before importing this resource, please create its configuration in %s", addr, modulePath)
 	v.view.Error(msg)
 }
 
-func (v *ImportJSON) Success() {
-	msg := "Import successful! The resources that were imported are shown above. These resources are now in your OpenTofu state and will henceforth be managed by OpenTofu"
+func (v *ImportJSON) Success(count int) {
+	summary := "Import successful!"
+	if count > 1 {
+		summary = fmt.Sprintf("Import successful! %d resources were imported.", count)
+	}
+	msg := summary + " The resources that were imported are shown above. These resources are now in your OpenTofu state and will henceforth be managed by OpenTofu"
 	v.view.Info(msg)
 }
 
+func (v *ImportJSON) ReadOnlyResult(_ context.Context, stateFile *statefile.File, schemas *tofu.Schemas) int {
+	rawState, err := jsonstate.Marshal(stateFile, schemas)
+	if err != nil {
+		v.Diagnostics(tfdiags.Diagnostics{}.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Failed to marshal state to json",
+			fmt.Sprintf("Error while marshalling state to json: %s", err),
+		)))
+		return 1
+	}
+	v.view.log.Info(
+		"Imported object read without saving it to the state",
+		"type", json.MessageLog,
+		"state", encJson.RawMessage(rawState),
+	)
+	return 0
+}
+
+func (v *ImportJSON) Capabilities(report []byte) {
+	v.view.log.Info(
+		"Import capabilities",
+		"type", json.MessageLog,
+		"capabilities", encJson.RawMessage(report),
+	)
+}
+
 func (v *ImportJSON) UnsupportedLocalOp() {
 	v.Diagnostics(tfdiags.Diagnostics{diagUnsupportedLocalOp})
 }
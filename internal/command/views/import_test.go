@@ -37,6 +37,21 @@ func TestImportViews(t *testing.T) {
 			wantStdout: withNewline(`For information on valid syntax, see:
 https://opentofu.org/docs/cli/state/resource-addressing/`),
 		},
+		"instance key": {
+			viewCall: func(v Import) {
+				v.InstanceKey(addrs.RootModuleInstance.ResourceInstance(
+					addrs.ManagedResourceMode, "test", "test_name", addrs.StringKey("a/b=c"),
+				), "a/b=c")
+			},
+			wantJson: []map[string]any{
+				{
+					"@level":   "info",
+					"@message": `Importing into test.test_name["a/b=c"], whose instance key is "a/b=c"`,
+					"@module":  "tofu.ui",
+				},
+			},
+			wantStdout: withNewline(`Importing into test.test_name["a/b=c"], whose instance key is "a/b=c".`),
+		},
 		"missing resource configuration": {
 			viewCall: func(v Import) {
 				v.MissingResourceConfiguration(addrs.AbsResourceInstance{
@@ -66,7 +81,7 @@ resource "test" "test_name" {
 		},
 		"success": {
 			viewCall: func(v Import) {
-				v.Success()
+				v.Success(1)
 			},
 			wantStdout: withNewline(`
 Import successful!
@@ -83,6 +98,25 @@ your OpenTofu state and will henceforth be managed by OpenTofu.
 				},
 			},
 		},
+		"success, several resources": {
+			viewCall: func(v Import) {
+				v.Success(3)
+			},
+			wantStdout: withNewline(`
+Import successful! 3 resources were imported.
+
+The resources that were imported are shown above. These resources are now in
+your OpenTofu state and will henceforth be managed by OpenTofu.
+`),
+			wantStderr: "",
+			wantJson: []map[string]any{
+				{
+					"@level":   "info",
+					"@message": "Import successful! 3 resources were imported. The resources that were imported are shown above. These resources are now in your OpenTofu state and will henceforth be managed by OpenTofu",
+					"@module":  "tofu.ui",
+				},
+			},
+		},
 		"unsupported local op": {
 			viewCall: func(v Import) {
 				v.UnsupportedLocalOp()
@@ -38,8 +38,9 @@ var ConnectionBlockSupersetSchema = &configschema.Block{
 			Optional: true,
 		},
 		"password": {
-			Type:     cty.String,
-			Optional: true,
+			Type:      cty.String,
+			Optional:  true,
+			Sensitive: true,
 		},
 		"port": {
 			Type:     cty.Number,
@@ -59,8 +60,9 @@ var ConnectionBlockSupersetSchema = &configschema.Block{
 			Optional: true,
 		},
 		"private_key": {
-			Type:     cty.String,
-			Optional: true,
+			Type:      cty.String,
+			Optional:  true,
+			Sensitive: true,
 		},
 		"certificate": {
 			Type:     cty.String,
@@ -95,8 +97,9 @@ var ConnectionBlockSupersetSchema = &configschema.Block{
 			Optional: true,
 		},
 		"proxy_user_password": {
-			Type:     cty.String,
-			Optional: true,
+			Type:      cty.String,
+			Optional:  true,
+			Sensitive: true,
 		},
 		"bastion_host": {
 			Type:     cty.String,
@@ -115,12 +118,14 @@ var ConnectionBlockSupersetSchema = &configschema.Block{
 			Optional: true,
 		},
 		"bastion_password": {
-			Type:     cty.String,
-			Optional: true,
+			Type:      cty.String,
+			Optional:  true,
+			Sensitive: true,
 		},
 		"bastion_private_key": {
-			Type:     cty.String,
-			Optional: true,
+			Type:      cty.String,
+			Optional:  true,
+			Sensitive: true,
 		},
 		"bastion_certificate": {
 			Type:     cty.String,
@@ -8,6 +8,7 @@ package providers
 import (
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/opentofu/opentofu/internal/addrs"
 )
@@ -100,14 +101,33 @@ func (resp ProviderSchema) Validate(addr addrs.Provider) error {
 
 type SchemaCache func(func() ProviderSchema) ProviderSchema
 
+// schemaErrorTTL is how long a schema response containing errors is reused
+// before the schema is requested again. Errors are still cached briefly so
+// that the many callers that typically request a schema at around the same
+// time all see the same failure, but a transient failure must not persist
+// for the rest of the process as a successful response does.
+var schemaErrorTTL = 10 * time.Second
+
 func NewSchemaCache() SchemaCache {
-	var once sync.Once
+	var mu sync.Mutex
 	var schema ProviderSchema
+	var cached bool
+	var expires time.Time // zero if the cached schema never expires
 
 	return func(getSchema func() ProviderSchema) ProviderSchema {
-		once.Do(func() {
-			schema = getSchema()
-		})
+		mu.Lock()
+		defer mu.Unlock()
+
+		if cached && (expires.IsZero() || time.Now().Before(expires)) {
+			return schema
+		}
+
+		schema = getSchema()
+		cached = true
+		expires = time.Time{}
+		if schema.Diagnostics.HasErrors() {
+			expires = time.Now().Add(schemaErrorTTL)
+		}
 		return schema
 	}
 }
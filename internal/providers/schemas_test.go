@@ -0,0 +1,87 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+// Copyright (c) 2023 HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package providers
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/opentofu/opentofu/internal/tfdiags"
+)
+
+func TestSchemaCache(t *testing.T) {
+	t.Run("success is cached indefinitely", func(t *testing.T) {
+		setSchemaErrorTTL(t, 0)
+
+		calls := 0
+		cache := NewSchemaCache()
+		for range 3 {
+			cache(func() ProviderSchema {
+				calls++
+				return ProviderSchema{}
+			})
+		}
+		if calls != 1 {
+			t.Errorf("schema requested %d times; want 1", calls)
+		}
+	})
+
+	t.Run("errors are cached until they expire", func(t *testing.T) {
+		setSchemaErrorTTL(t, time.Hour)
+
+		calls := 0
+		cache := NewSchemaCache()
+		for range 3 {
+			got := cache(func() ProviderSchema {
+				calls++
+				var diags tfdiags.Diagnostics
+				return ProviderSchema{Diagnostics: diags.Append(errors.New("transient failure"))}
+			})
+			if !got.Diagnostics.HasErrors() {
+				t.Fatal("cached response has lost its errors")
+			}
+		}
+		if calls != 1 {
+			t.Errorf("schema requested %d times; want 1", calls)
+		}
+	})
+
+	t.Run("expired errors are retried", func(t *testing.T) {
+		setSchemaErrorTTL(t, 0)
+
+		calls := 0
+		cache := NewSchemaCache()
+		getSchema := func() ProviderSchema {
+			calls++
+			if calls == 1 {
+				var diags tfdiags.Diagnostics
+				return ProviderSchema{Diagnostics: diags.Append(errors.New("transient failure"))}
+			}
+			return ProviderSchema{}
+		}
+
+		if got := cache(getSchema); !got.Diagnostics.HasErrors() {
+			t.Fatal("first response should have errors")
+		}
+		if got := cache(getSchema); got.Diagnostics.HasErrors() {
+			t.Fatal("second response should have been requested again and succeeded")
+		}
+		cache(getSchema)
+		if calls != 2 {
+			t.Errorf("schema requested %d times; want 2", calls)
+		}
+	})
+}
+
+func setSchemaErrorTTL(t *testing.T, ttl time.Duration) {
+	t.Helper()
+	old := schemaErrorTTL
+	schemaErrorTTL = ttl
+	t.Cleanup(func() {
+		schemaErrorTTL = old
+	})
+}
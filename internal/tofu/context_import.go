@@ -42,6 +42,15 @@ type CommandLineImportTarget struct {
 
 	// ID is the string ID of the resource to import. This is resource-specific.
 	ID string
+
+	// IDSensitive is true if ID must not appear in logs or UI output. The ID
+	// is still sent to the provider as normal.
+	IDSensitive bool
+
+	// Identity is the identity of the resource to import, as an alternative
+	// to ID for resource types whose provider supports identity-based import.
+	// It must conform to the resource type's identity schema.
+	Identity cty.Value
 }
 
 // ImportTarget is a target that we need to import.
@@ -404,9 +413,10 @@ func (ri *ImportResolver) addCLIImportTarget(importTarget *ImportTarget) {
 	// setting nil value to Config here to reuse Context.postExpansionImportValidation,
 	// and there should be no possible paths to dereference this with a nil value during the import command
 	ri.imports.Put(importAddress, EvaluatedConfigImportTarget{
-		Config: nil,
-		Addr:   importAddress,
-		ID:     importTarget.CommandLineImportTarget.ID,
+		Config:   nil,
+		Addr:     importAddress,
+		ID:       importTarget.CommandLineImportTarget.ID,
+		Identity: importTarget.CommandLineImportTarget.Identity,
 	})
 }
 
@@ -467,7 +477,7 @@ func (c *Context) Import(ctx context.Context, config *configs.Config, prevRunSta
 	// Once we have all instances expanded, we are able to do a complete validation for import targets
 	// This part validates imports of both types (import blocks and CLI imports)
 	allInstances := walker.InstanceExpander.AllInstances()
-	importValidateDiags := c.postExpansionImportValidation(walker.ImportResolver, allInstances)
+	importValidateDiags := c.postExpansionImportValidation(config, walker.ImportResolver, allInstances)
 	if importValidateDiags.HasErrors() {
 		return nil, importValidateDiags
 	}
@@ -19,6 +19,7 @@ import (
 
 	"github.com/opentofu/opentofu/internal/addrs"
 	"github.com/opentofu/opentofu/internal/configs/configschema"
+	"github.com/opentofu/opentofu/internal/lang/marks"
 	"github.com/opentofu/opentofu/internal/plugins"
 	"github.com/opentofu/opentofu/internal/providers"
 	"github.com/opentofu/opentofu/internal/states"
@@ -67,6 +68,164 @@ func TestContextImport_basic(t *testing.T) {
 	}
 }
 
+func TestContextImport_identity(t *testing.T) {
+	p := testProvider("aws")
+	m := testModule(t, "import-provider")
+	ctx := testContext2(t, &ContextOpts{
+		Plugins: plugins.NewLibrary(map[addrs.Provider]providers.Factory{
+			addrs.NewDefaultProvider("aws"): testProviderFuncFixed(p),
+		}, nil),
+	})
+
+	identity := cty.ObjectVal(map[string]cty.Value{
+		"name": cty.StringVal("bar"),
+	})
+	p.ImportResourceStateResponse = &providers.ImportResourceStateResponse{
+		ImportedResources: []providers.ImportedResource{
+			{
+				TypeName: "aws_instance",
+				State: cty.ObjectVal(map[string]cty.Value{
+					"id": cty.StringVal("foo"),
+				}),
+			},
+		},
+	}
+
+	state, diags := ctx.Import(context.Background(), m, states.NewState(), &ImportOpts{
+		Targets: []*ImportTarget{
+			{
+				CommandLineImportTarget: &CommandLineImportTarget{
+					Addr: addrs.RootModuleInstance.ResourceInstance(
+						addrs.ManagedResourceMode, "aws_instance", "foo", addrs.NoKey,
+					),
+					Identity: identity,
+				},
+			},
+		},
+	})
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+	if got := p.ImportResourceStateRequest.Target; got.ID != "" || !got.Identity.RawEquals(identity) {
+		t.Errorf("wrong import target: ID %q, identity %#v", got.ID, got.Identity)
+	}
+	actual := strings.TrimSpace(state.String())
+	expected := strings.TrimSpace(testImportStr)
+	if actual != expected {
+		t.Fatalf("wrong final state\ngot:\n%s\nwant:\n%s", actual, expected)
+	}
+}
+
+func TestContextImport_countExcludesTarget(t *testing.T) {
+	p := testProvider("aws")
+	m := testModuleInline(t, map[string]string{
+		"main.tf": `
+			variable "enabled" {
+				type = bool
+			}
+
+			resource "aws_instance" "foo" {
+				count = var.enabled ? 1 : 0
+			}
+		`,
+	})
+	ctx := testContext2(t, &ContextOpts{
+		Plugins: plugins.NewLibrary(map[addrs.Provider]providers.Factory{
+			addrs.NewDefaultProvider("aws"): testProviderFuncFixed(p),
+		}, nil),
+	})
+
+	p.ImportResourceStateResponse = &providers.ImportResourceStateResponse{
+		ImportedResources: []providers.ImportedResource{
+			{
+				TypeName: "aws_instance",
+				State: cty.ObjectVal(map[string]cty.Value{
+					"id": cty.StringVal("foo"),
+				}),
+			},
+		},
+	}
+
+	_, diags := ctx.Import(context.Background(), m, states.NewState(), &ImportOpts{
+		Targets: []*ImportTarget{
+			{
+				CommandLineImportTarget: &CommandLineImportTarget{
+					Addr: addrs.RootModuleInstance.ResourceInstance(
+						addrs.ManagedResourceMode, "aws_instance", "foo", addrs.IntKey(0),
+					),
+					ID: "bar",
+				},
+			},
+		},
+		SetVariables: InputValues{
+			"enabled": &InputValue{
+				Value:      cty.False,
+				SourceType: ValueFromCaller,
+			},
+		},
+	})
+	if !diags.HasErrors() {
+		t.Fatal("unexpected success")
+	}
+	if got, want := diags.Err().Error(), "The argument refers to var.enabled."; !strings.Contains(got, want) {
+		t.Errorf("wrong error\ngot:  %s\nwant substring: %s", got, want)
+	}
+}
+
+func TestContextImport_idSensitive(t *testing.T) {
+	p := testProvider("aws")
+	m := testModule(t, "import-provider")
+	hook := new(MockHook)
+	ctx := testContext2(t, &ContextOpts{
+		Hooks: []Hook{hook},
+		Plugins: plugins.NewLibrary(map[addrs.Provider]providers.Factory{
+			addrs.NewDefaultProvider("aws"): testProviderFuncFixed(p),
+		}, nil),
+	})
+
+	p.ImportResourceStateResponse = &providers.ImportResourceStateResponse{
+		ImportedResources: []providers.ImportedResource{
+			{
+				TypeName: "aws_instance",
+				State: cty.ObjectVal(map[string]cty.Value{
+					"id": cty.StringVal("foo"),
+				}),
+			},
+		},
+	}
+
+	addr := addrs.RootModuleInstance.ResourceInstance(addrs.ManagedResourceMode, "aws_instance", "foo", addrs.NoKey)
+	state, diags := ctx.Import(context.Background(), m, states.NewState(), &ImportOpts{
+		Targets: []*ImportTarget{
+			{
+				CommandLineImportTarget: &CommandLineImportTarget{
+					Addr:        addr,
+					ID:          "secret",
+					IDSensitive: true,
+				},
+			},
+		},
+	})
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+
+	if got, want := p.ImportResourceStateRequest.Target.ID, "secret"; got != want {
+		t.Errorf("wrong ID sent to provider %q; want %q", got, want)
+	}
+	if got, want := hook.PreImportStateID, redactedImportID; got != want {
+		t.Errorf("wrong ID passed to hook %q; want %q", got, want)
+	}
+	if !hook.PreRefreshPriorState.GetAttr("id").HasMark(marks.Sensitive) {
+		t.Errorf("id attribute not marked as sensitive for the refresh hook")
+	}
+	if is := state.ResourceInstance(addr); is == nil || is.Current == nil {
+		t.Fatalf("%s is not in the state", addr)
+	} else if len(is.Current.AttrSensitivePaths) != 0 {
+		t.Errorf("the sensitive import ID mark was saved in the state: %#v", is.Current.AttrSensitivePaths)
+	}
+}
+
 // import 1 of count instances in the configuration
 func TestContextImport_countIndex(t *testing.T) {
 	p := testProvider("aws")
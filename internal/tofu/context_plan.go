@@ -21,6 +21,7 @@ import (
 	"github.com/opentofu/opentofu/internal/addrs"
 	"github.com/opentofu/opentofu/internal/configs"
 	"github.com/opentofu/opentofu/internal/instances"
+	"github.com/opentofu/opentofu/internal/lang"
 	"github.com/opentofu/opentofu/internal/lang/globalref"
 	"github.com/opentofu/opentofu/internal/plans"
 	"github.com/opentofu/opentofu/internal/refactoring"
@@ -665,12 +666,22 @@ func (c *Context) postPlanValidateMoves(config *configs.Config, stmts []refactor
 // All import target addresses with a key must already exist in config.
 // When we are able to generate config for expanded resources, this rule can be
 // relaxed.
-func (c *Context) postExpansionImportValidation(importResolver *ImportResolver, allInst instances.Set) tfdiags.Diagnostics {
+func (c *Context) postExpansionImportValidation(config *configs.Config, importResolver *ImportResolver, allInst instances.Set) tfdiags.Diagnostics {
 	var diags tfdiags.Diagnostics
 	for _, importTarget := range importResolver.GetAllImports() {
-		if !allInst.HasResourceInstance(importTarget.Addr) {
-			diags = diags.Append(importResourceWithoutConfigDiags(importTarget.Addr.String(), nil))
+		if allInst.HasResourceInstance(importTarget.Addr) {
+			continue
+		}
+		// Targets from the import command have no import block, so the
+		// most useful thing we can point at is the argument that excluded
+		// the instance, if there is one.
+		if importTarget.Config == nil && allInst.HasModuleInstance(importTarget.Addr.Module) {
+			if diag := importTargetNotExpandedDiags(config, importTarget.Addr); diag != nil {
+				diags = diags.Append(diag)
+				continue
+			}
 		}
+		diags = diags.Append(importResourceWithoutConfigDiags(importTarget.Addr.String(), nil))
 	}
 	return diags
 }
@@ -760,6 +771,53 @@ func importConfigGenerationWithIndexDiags(addressStr string, config *configs.Imp
 	return &diag
 }
 
+// importTargetNotExpandedDiags returns an error explaining that the resource
+// instance at addr is not among those declared by the "count" or "for_each"
+// argument of its resource under the current input variable values, naming
+// the input variables that the argument refers to, or nil if the resource is
+// not configured or has neither argument.
+func importTargetNotExpandedDiags(config *configs.Config, addr addrs.AbsResourceInstance) *hcl.Diagnostic {
+	if config == nil {
+		return nil
+	}
+	modCfg := config.DescendentForInstance(addr.Module)
+	if modCfg == nil {
+		return nil
+	}
+	rc := modCfg.Module.ResourceByAddr(addr.Resource.Resource)
+	if rc == nil {
+		return nil
+	}
+	argName, expr := "count", rc.Count
+	if expr == nil {
+		argName, expr = "for_each", rc.ForEach
+	}
+	if expr == nil {
+		return nil
+	}
+
+	var vars []string
+	refs, _ := lang.ReferencesInExpr(addrs.ParseRef, expr)
+	for _, ref := range refs {
+		if v, ok := ref.Subject.(addrs.InputVariable); ok && !slices.Contains(vars, v.String()) {
+			vars = append(vars, v.String())
+		}
+	}
+
+	detail := fmt.Sprintf("The %q argument of %s does not declare the instance %s under the current input variable values.", argName, addr.ContainingResource(), addr)
+	if len(vars) > 0 {
+		detail += fmt.Sprintf(" The argument refers to %s. Set the values of those variables so that the instance exists, such as with the -var option, and then run the import again.", strings.Join(vars, ", "))
+	} else {
+		detail += " Change the configuration so that the instance exists, and then run the import again."
+	}
+	return &hcl.Diagnostic{
+		Severity: hcl.DiagError,
+		Summary:  "Import target is not declared under the current configuration",
+		Detail:   detail,
+		Subject:  expr.Range().Ptr(),
+	}
+}
+
 func importResourceWithoutConfigDiags(addressStr string, config *configs.Import) *hcl.Diagnostic {
 	diag := hcl.Diagnostic{
 		Severity: hcl.DiagError,
@@ -824,7 +882,7 @@ func (c *Context) planWalk(ctx context.Context, config *configs.Config, prevRunS
 
 	allInsts := walker.InstanceExpander.AllInstances()
 
-	importValidateDiags := c.postExpansionImportValidation(walker.ImportResolver, allInsts)
+	importValidateDiags := c.postExpansionImportValidation(config, walker.ImportResolver, allInsts)
 	if importValidateDiags.HasErrors() {
 		return nil, importValidateDiags
 	}
@@ -13,6 +13,7 @@ import (
 	"github.com/opentofu/opentofu/internal/addrs"
 	"github.com/opentofu/opentofu/internal/configs"
 	"github.com/opentofu/opentofu/internal/configs/configschema"
+	"github.com/opentofu/opentofu/internal/lang/marks"
 	"github.com/opentofu/opentofu/internal/providers"
 	"github.com/opentofu/opentofu/internal/states"
 	"github.com/opentofu/opentofu/internal/tfdiags"
@@ -22,6 +23,7 @@ import (
 type graphNodeImportState struct {
 	Addr                addrs.AbsResourceInstance // Addr is the resource address to import into
 	ID                  string                    // ID is the ID to import as
+	IDSensitive         bool                      // IDSensitive hides ID from logs and hooks
 	Identity            cty.Value                 // Identity is an alternative to ID for providers to use for importing
 	ResolvedProvider    ResolvedProvider          // provider node address after resolution
 	ResolvedProviderKey addrs.InstanceKey         // resolved from ResolvedProviderKeyExpr+ResolvedProviderKeyPath in method Execute
@@ -41,9 +43,22 @@ var (
 )
 
 func (n *graphNodeImportState) Name() string {
-	return fmt.Sprintf("%s (import id %q)", n.Addr, n.ID)
+	return fmt.Sprintf("%s (import id %q)", n.Addr, n.displayID())
 }
 
+// displayID returns the import ID as it should appear in logs and UI output,
+// which is a redaction marker if the ID was declared sensitive.
+func (n *graphNodeImportState) displayID() string {
+	if n.IDSensitive {
+		return redactedImportID
+	}
+	return n.ID
+}
+
+// redactedImportID replaces a sensitive import ID anywhere it would otherwise
+// be shown to the user or written to logs.
+const redactedImportID = "(sensitive value)"
+
 // GraphNodeProviderConsumer
 func (n *graphNodeImportState) ProvidedBy() RequestedProvider {
 	// This has already been resolved by nodeExpandPlannableResource
@@ -113,7 +128,7 @@ func (n *graphNodeImportState) Execute(ctx context.Context, evalCtx EvalContext,
 
 	// Call pre-import hook
 	diags = diags.Append(evalCtx.Hook(func(h Hook) (HookAction, error) {
-		return h.PreImportState(absAddr, n.ID)
+		return h.PreImportState(absAddr, n.displayID())
 	}))
 	if diags.HasErrors() {
 		return diags
@@ -133,7 +148,7 @@ func (n *graphNodeImportState) Execute(ctx context.Context, evalCtx EvalContext,
 
 	imported := resp.ImportedResources
 	for _, obj := range imported {
-		log.Printf("[TRACE] graphNodeImportState: import %s %q produced instance object of type %s", absAddr.String(), n.ID, obj.TypeName)
+		log.Printf("[TRACE] graphNodeImportState: import %s %q produced instance object of type %s", absAddr.String(), n.displayID(), obj.TypeName)
 	}
 	n.states = imported
 
@@ -207,6 +222,7 @@ func (n *graphNodeImportState) DynamicExpand(evalCtx EvalContext) (*Graph, error
 		g.Add(&graphNodeImportStateSub{
 			TargetAddr:          addrs[i],
 			State:               state,
+			IDSensitive:         n.IDSensitive,
 			ResolvedProvider:    n.ResolvedProvider,
 			ResolvedProviderKey: n.ResolvedProviderKey,
 			Schema:              n.Schema,
@@ -227,6 +243,7 @@ func (n *graphNodeImportState) DynamicExpand(evalCtx EvalContext) (*Graph, error
 type graphNodeImportStateSub struct {
 	TargetAddr          addrs.AbsResourceInstance
 	State               providers.ImportedResource
+	IDSensitive         bool // IDSensitive hides the "id" attribute from the refresh hooks
 	ResolvedProvider    ResolvedProvider
 	ResolvedProviderKey addrs.InstanceKey // the dynamic instance ResolvedProvider
 
@@ -248,6 +265,24 @@ func (n *graphNodeImportStateSub) Path() addrs.ModuleInstance {
 	return n.TargetAddr.Module
 }
 
+// importIDPathMarks marks the "id" attribute of an imported object as
+// sensitive while it is refreshed, when the import ID is sensitive.
+var importIDPathMarks = cty.PathValueMarks{
+	Path:  cty.GetAttrPath("id"),
+	Marks: cty.NewValueMarks(marks.Sensitive),
+}
+
+// schemaMarksIDSensitive returns true if the resource type schema itself
+// declares the "id" attribute as sensitive, in which case the mark added for
+// a sensitive import ID must be kept.
+func (n *graphNodeImportStateSub) schemaMarksIDSensitive() bool {
+	if n.Schema == nil {
+		return false
+	}
+	attr, ok := n.Schema.Attributes["id"]
+	return ok && attr.Sensitive
+}
+
 // GraphNodeExecutable impl.
 func (n *graphNodeImportStateSub) Execute(ctx context.Context, evalCtx EvalContext, op walkOperation) (diags tfdiags.Diagnostics) {
 	// If the Ephemeral type isn't set, then it is an error
@@ -266,11 +301,26 @@ func (n *graphNodeImportStateSub) Execute(ctx context.Context, evalCtx EvalConte
 		},
 		ResolvedProviderKey: n.ResolvedProviderKey,
 	}
+	if n.IDSensitive {
+		// The refresh hooks show the "id" attribute, which normally holds
+		// the import ID, so it's marked as sensitive for the refresh only.
+		state.Value = state.Value.MarkWithPaths([]cty.PathValueMarks{importIDPathMarks})
+	}
 	state, refreshDiags := riNode.refresh(ctx, evalCtx, states.NotDeposed, state)
 	diags = diags.Append(refreshDiags)
 	if diags.HasErrors() {
 		return diags
 	}
+	if n.IDSensitive && !n.schemaMarksIDSensitive() {
+		unmarked, pvms := state.Value.UnmarkDeepWithPaths()
+		var kept []cty.PathValueMarks
+		for _, pvm := range pvms {
+			if !pvm.Path.Equals(importIDPathMarks.Path) {
+				kept = append(kept, pvm)
+			}
+		}
+		state.Value = unmarked.MarkWithPaths(kept)
+	}
 
 	// Verify the existence of the imported resource
 	if state.Value.IsNull() {
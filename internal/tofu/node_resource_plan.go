@@ -358,6 +358,8 @@ func (n *nodeExpandPlannableResource) resourceInstanceSubgraph(ctx context.Conte
 				return &graphNodeImportState{
 					Addr:             c.Addr,
 					ID:               c.ID,
+					IDSensitive:      c.IDSensitive,
+					Identity:         c.Identity,
 					ResolvedProvider: n.ResolvedProvider,
 					Schema:           n.Schema,
 					SchemaVersion:    n.SchemaVersion,